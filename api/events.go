@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+)
+
+// eventKeepAlive is how often an idle event stream sends a comment line so
+// proxies do not close it.
+const eventKeepAlive = 15 * time.Second
+
+type headEventJSON struct {
+	Slot          string `json:"slot"`
+	Root          string `json:"root"`
+	ProposerIndex string `json:"proposer_index"`
+}
+
+type blockEventJSON struct {
+	Slot          string `json:"slot"`
+	Root          string `json:"root"`
+	ParentRoot    string `json:"parent_root"`
+	ProposerIndex string `json:"proposer_index"`
+}
+
+type attestationEventJSON struct {
+	ValidatorID string         `json:"validator_id"`
+	Slot        string         `json:"slot"`
+	Head        checkpointJSON `json:"head"`
+	Target      checkpointJSON `json:"target"`
+	Source      checkpointJSON `json:"source"`
+	FromBlock   bool           `json:"from_block"`
+}
+
+type reorgEventJSON struct {
+	Slot           string `json:"slot"`
+	OldHead        string `json:"old_head"`
+	OldHeadSlot    string `json:"old_head_slot"`
+	NewHead        string `json:"new_head"`
+	NewHeadSlot    string `json:"new_head_slot"`
+	CommonAncestor string `json:"common_ancestor"`
+	Depth          string `json:"depth"`
+}
+
+func toEventJSON(data any) any {
+	switch e := data.(type) {
+	case forkchoice.HeadEvent:
+		return headEventJSON{Slot: u64(e.Slot), Root: hexBytes(e.Root[:]), ProposerIndex: u64(e.ProposerIndex)}
+	case forkchoice.BlockEvent:
+		return blockEventJSON{
+			Slot:          u64(e.Slot),
+			Root:          hexBytes(e.Root[:]),
+			ParentRoot:    hexBytes(e.ParentRoot[:]),
+			ProposerIndex: u64(e.ProposerIndex),
+		}
+	case forkchoice.AttestationEvent:
+		return attestationEventJSON{
+			ValidatorID: u64(e.ValidatorID),
+			Slot:        u64(e.Slot),
+			Head:        toCheckpointJSON(&e.Head),
+			Target:      toCheckpointJSON(&e.Target),
+			Source:      toCheckpointJSON(&e.Source),
+			FromBlock:   e.FromBlock,
+		}
+	case forkchoice.CheckpointEvent:
+		return checkpointJSON{Root: hexBytes(e.Root[:]), Slot: u64(e.Slot)}
+	case forkchoice.ReorgEvent:
+		return reorgEventJSON{
+			Slot:           u64(e.Slot),
+			OldHead:        hexBytes(e.OldHead[:]),
+			OldHeadSlot:    u64(e.OldHeadSlot),
+			NewHead:        hexBytes(e.NewHead[:]),
+			NewHeadSlot:    u64(e.NewHeadSlot),
+			CommonAncestor: hexBytes(e.CommonAncestor[:]),
+			Depth:          u64(e.Depth),
+		}
+	}
+	return data
+}
+
+// handleEvents streams fork choice events as server-sent events. The
+// comma-separated topics query parameter selects topics; all are sent when
+// it is omitted.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var topics []string
+	if q := r.URL.Query().Get("topics"); q != "" {
+		for _, t := range strings.Split(q, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(forkchoice.EventTopics, t) {
+				writeError(w, errBadRequest(fmt.Sprintf("unknown topic %q", t)))
+				return
+			}
+			topics = append(topics, t)
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	events, cancel := s.FC.SubscribeEvents(topics...)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(toEventJSON(ev.Data))
+			if err != nil {
+				s.log.Warn("encode event failed", "topic", ev.Topic, "err", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Topic, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/geanlabs/gean/syncer"
+	"github.com/geanlabs/gean/types"
+)
+
+// handleHealth returns 200 once the node follows the chain, 206 while it is
+// syncing and 503 before genesis.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	switch s.syncStatus().State {
+	case syncer.StatePreGenesis:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case syncer.StateSyncing:
+		w.WriteHeader(http.StatusPartialContent)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// handleSyncing returns the node's sync state.
+func (s *Server) handleSyncing(w http.ResponseWriter, r *http.Request) {
+	status := s.syncStatus()
+	writeData(w, map[string]any{
+		"state":         status.State.String(),
+		"is_syncing":    status.State == syncer.StateSyncing,
+		"head_slot":     u64(status.HeadSlot),
+		"current_slot":  u64(status.CurrentSlot),
+		"target_slot":   u64(status.TargetSlot),
+		"sync_distance": u64(status.SyncDistance),
+	})
+}
+
+// handlePeers returns the latest status of every peer that completed a
+// status exchange with us, ordered by peer ID.
+func (s *Server) handlePeers(w http.ResponseWriter, r *http.Request) {
+	peers := []peerJSON{}
+	if s.Sync != nil {
+		for pid, status := range s.Sync.PeerStatuses() {
+			peers = append(peers, peerJSON{
+				PeerID:    pid.String(),
+				Head:      toCheckpointJSON(status.Head),
+				Finalized: toCheckpointJSON(status.Finalized),
+			})
+		}
+	}
+	slices.SortFunc(peers, func(a, b peerJSON) int { return strings.Compare(a.PeerID, b.PeerID) })
+	writeData(w, peers)
+}
+
+// syncStatus returns the node's sync state, or a synced state at our head
+// when no syncer is attached.
+func (s *Server) syncStatus() syncer.Status {
+	if s.Sync != nil {
+		return s.Sync.Status()
+	}
+	return syncer.Status{State: syncer.StateSynced, HeadSlot: s.FC.HeadSlot()}
+}
+
+// handleHead returns the header of the current head block.
+func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
+	head, _, _ := s.FC.Checkpoints()
+	block, ok := s.Storage.GetBlock(head)
+	if !ok {
+		writeError(w, errNotFound("head block not found"))
+		return
+	}
+	bodyRoot, err := block.Body.HashTreeRoot()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, map[string]any{
+		"root": hexBytes(head[:]),
+		"header": toBlockHeaderJSON(&types.BlockHeader{
+			Slot:          block.Slot,
+			ProposerIndex: block.ProposerIndex,
+			ParentRoot:    block.ParentRoot,
+			StateRoot:     block.StateRoot,
+			BodyRoot:      bodyRoot,
+		}),
+	})
+}
+
+// handleCheckpoints returns the head, justified and finalized checkpoints.
+func (s *Server) handleCheckpoints(w http.ResponseWriter, r *http.Request) {
+	head, justified, finalized := s.FC.Checkpoints()
+	headCp, err := s.headCheckpoint(head)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, map[string]checkpointJSON{
+		"head":      toCheckpointJSON(headCp),
+		"justified": toCheckpointJSON(justified),
+		"finalized": toCheckpointJSON(finalized),
+	})
+}
+
+// handleCheckpoint returns a single checkpoint: head, justified or finalized.
+func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	head, justified, finalized := s.FC.Checkpoints()
+	var cp *types.Checkpoint
+	switch id := r.PathValue("checkpoint_id"); id {
+	case "head":
+		var err error
+		if cp, err = s.headCheckpoint(head); err != nil {
+			writeError(w, err)
+			return
+		}
+	case "justified":
+		cp = justified
+	case "finalized":
+		cp = finalized
+	default:
+		writeError(w, errBadRequest(fmt.Sprintf("invalid checkpoint id %q", id)))
+		return
+	}
+	writeData(w, toCheckpointJSON(cp))
+}
+
+func (s *Server) headCheckpoint(head [32]byte) (*types.Checkpoint, error) {
+	block, ok := s.Storage.GetBlock(head)
+	if !ok {
+		return nil, errNotFound("head block not found")
+	}
+	return &types.Checkpoint{Root: head, Slot: block.Slot}, nil
+}
+
+// handleBlock returns a block as JSON or, on request, as SSZ.
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request) {
+	root, err := s.resolveBlockID(r.PathValue("block_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	block, ok := s.Storage.GetBlock(root)
+	if !ok {
+		writeError(w, errNotFound("block not found"))
+		return
+	}
+	if wantsSSZ(r) {
+		data, err := block.MarshalSSZ()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeSSZ(w, data)
+		return
+	}
+	writeData(w, map[string]any{
+		"root":  hexBytes(root[:]),
+		"block": toBlockJSON(block),
+	})
+}
+
+// handleSignedBlock returns the signed block envelope as JSON or SSZ.
+func (s *Server) handleSignedBlock(w http.ResponseWriter, r *http.Request) {
+	root, err := s.resolveBlockID(r.PathValue("block_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sb, ok := s.Storage.GetSignedBlock(root)
+	if !ok {
+		writeError(w, errNotFound("signed block not found"))
+		return
+	}
+	if wantsSSZ(r) {
+		data, err := sb.MarshalSSZ()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeSSZ(w, data)
+		return
+	}
+	writeData(w, map[string]any{
+		"root":         hexBytes(root[:]),
+		"signed_block": toSignedBlockJSON(sb),
+	})
+}
+
+// handleState returns the post-state of a block as JSON or SSZ. States are
+// keyed by block root and accept the same identifiers as blocks.
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	root, err := s.resolveBlockID(r.PathValue("state_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	state, ok := s.Storage.GetState(root)
+	if !ok {
+		writeError(w, errNotFound("state not found"))
+		return
+	}
+	if wantsSSZ(r) {
+		data, err := state.MarshalSSZ()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeSSZ(w, data)
+		return
+	}
+	writeData(w, toStateJSON(state))
+}
+
+// handleForkChoice returns every block in the fork choice tree together with
+// its vote weight.
+func (s *Server) handleForkChoice(w http.ResponseWriter, r *http.Request) {
+	head, justified, finalized := s.FC.Checkpoints()
+	nodes := s.FC.ForkChoiceNodes()
+	out := make([]forkChoiceNodeJSON, len(nodes))
+	for i, n := range nodes {
+		out[i] = toForkChoiceNodeJSON(n)
+	}
+	writeData(w, map[string]any{
+		"head":      hexBytes(head[:]),
+		"justified": toCheckpointJSON(justified),
+		"finalized": toCheckpointJSON(finalized),
+		"nodes":     out,
+	})
+}
+
+// handleReorgs returns the most recent reorgs, oldest first.
+func (s *Server) handleReorgs(w http.ResponseWriter, r *http.Request) {
+	reorgs := s.FC.RecentReorgs()
+	out := make([]any, len(reorgs))
+	for i, reorg := range reorgs {
+		out[i] = toEventJSON(reorg)
+	}
+	writeData(w, out)
+}
+
+// handleEquivocations returns the double votes and double proposals the
+// fork choice has seen, oldest first.
+func (s *Server) handleEquivocations(w http.ResponseWriter, r *http.Request) {
+	attester := s.FC.AttesterEquivocations()
+	proposer := s.FC.ProposerEquivocations()
+	out := equivocationsJSON{
+		Attester: make([]attesterEquivocationJSON, len(attester)),
+		Proposer: make([]proposerEquivocationJSON, len(proposer)),
+	}
+	for i, e := range attester {
+		out.Attester[i] = toAttesterEquivocationJSON(e)
+	}
+	for i, e := range proposer {
+		out.Proposer[i] = toProposerEquivocationJSON(e)
+	}
+	writeData(w, out)
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/types"
+)
+
+// JSON views of consensus types. Following the beacon API, integers are
+// encoded as decimal strings and byte arrays as 0x-prefixed hex.
+
+type checkpointJSON struct {
+	Root string `json:"root"`
+	Slot string `json:"slot"`
+}
+
+type blockHeaderJSON struct {
+	Slot          string `json:"slot"`
+	ProposerIndex string `json:"proposer_index"`
+	ParentRoot    string `json:"parent_root"`
+	StateRoot     string `json:"state_root"`
+	BodyRoot      string `json:"body_root"`
+}
+
+type attestationDataJSON struct {
+	Slot   string         `json:"slot"`
+	Head   checkpointJSON `json:"head"`
+	Target checkpointJSON `json:"target"`
+	Source checkpointJSON `json:"source"`
+}
+
+type attestationJSON struct {
+	ValidatorID string              `json:"validator_id"`
+	Data        attestationDataJSON `json:"data"`
+}
+
+type blockBodyJSON struct {
+	Attestations []attestationJSON `json:"attestations"`
+}
+
+type blockJSON struct {
+	Slot          string        `json:"slot"`
+	ProposerIndex string        `json:"proposer_index"`
+	ParentRoot    string        `json:"parent_root"`
+	StateRoot     string        `json:"state_root"`
+	Body          blockBodyJSON `json:"body"`
+}
+
+type blockWithAttestationJSON struct {
+	Block               blockJSON       `json:"block"`
+	ProposerAttestation attestationJSON `json:"proposer_attestation"`
+}
+
+type signedBlockJSON struct {
+	Message   blockWithAttestationJSON `json:"message"`
+	Signature []string                 `json:"signature"`
+}
+
+type validatorJSON struct {
+	Pubkey string `json:"pubkey"`
+	Index  string `json:"index"`
+}
+
+type configJSON struct {
+	GenesisTime string `json:"genesis_time"`
+}
+
+type stateJSON struct {
+	Config                   configJSON      `json:"config"`
+	Slot                     string          `json:"slot"`
+	LatestBlockHeader        blockHeaderJSON `json:"latest_block_header"`
+	LatestJustified          checkpointJSON  `json:"latest_justified"`
+	LatestFinalized          checkpointJSON  `json:"latest_finalized"`
+	HistoricalBlockHashes    []string        `json:"historical_block_hashes"`
+	JustifiedSlots           string          `json:"justified_slots"`
+	Validators               []validatorJSON `json:"validators"`
+	JustificationsRoots      []string        `json:"justifications_roots"`
+	JustificationsValidators string          `json:"justifications_validators"`
+}
+
+type peerJSON struct {
+	PeerID    string         `json:"peer_id"`
+	Head      checkpointJSON `json:"head"`
+	Finalized checkpointJSON `json:"finalized"`
+}
+
+type forkChoiceNodeJSON struct {
+	Root       string `json:"root"`
+	ParentRoot string `json:"parent_root"`
+	Slot       string `json:"slot"`
+	Weight     string `json:"weight"`
+}
+
+type signedAttestationJSON struct {
+	Message   attestationJSON `json:"message"`
+	Signature string          `json:"signature"`
+}
+
+type attesterEquivocationJSON struct {
+	ValidatorID string                `json:"validator_id"`
+	Slot        string                `json:"slot"`
+	First       signedAttestationJSON `json:"first"`
+	Second      signedAttestationJSON `json:"second"`
+}
+
+type proposerEquivocationJSON struct {
+	ProposerIndex string          `json:"proposer_index"`
+	Slot          string          `json:"slot"`
+	First         blockHeaderJSON `json:"first"`
+	Second        blockHeaderJSON `json:"second"`
+}
+
+type equivocationsJSON struct {
+	Attester []attesterEquivocationJSON `json:"attester"`
+	Proposer []proposerEquivocationJSON `json:"proposer"`
+}
+
+func u64(v uint64) string { return strconv.FormatUint(v, 10) }
+
+func hexBytes(b []byte) string { return "0x" + hex.EncodeToString(b) }
+
+func hexRoots(roots [][32]byte) []string {
+	out := make([]string, len(roots))
+	for i := range roots {
+		out[i] = hexBytes(roots[i][:])
+	}
+	return out
+}
+
+func toCheckpointJSON(cp *types.Checkpoint) checkpointJSON {
+	if cp == nil {
+		return checkpointJSON{Root: hexBytes(types.ZeroHash[:]), Slot: "0"}
+	}
+	return checkpointJSON{Root: hexBytes(cp.Root[:]), Slot: u64(cp.Slot)}
+}
+
+func toBlockHeaderJSON(h *types.BlockHeader) blockHeaderJSON {
+	return blockHeaderJSON{
+		Slot:          u64(h.Slot),
+		ProposerIndex: u64(h.ProposerIndex),
+		ParentRoot:    hexBytes(h.ParentRoot[:]),
+		StateRoot:     hexBytes(h.StateRoot[:]),
+		BodyRoot:      hexBytes(h.BodyRoot[:]),
+	}
+}
+
+func toAttestationJSON(a *types.Attestation) attestationJSON {
+	if a == nil || a.Data == nil {
+		return attestationJSON{}
+	}
+	return attestationJSON{
+		ValidatorID: u64(a.ValidatorID),
+		Data: attestationDataJSON{
+			Slot:   u64(a.Data.Slot),
+			Head:   toCheckpointJSON(a.Data.Head),
+			Target: toCheckpointJSON(a.Data.Target),
+			Source: toCheckpointJSON(a.Data.Source),
+		},
+	}
+}
+
+func toSignedAttestationJSON(sa *types.SignedAttestation) signedAttestationJSON {
+	return signedAttestationJSON{
+		Message:   toAttestationJSON(sa.Message),
+		Signature: hexBytes(sa.Signature[:]),
+	}
+}
+
+func toAttesterEquivocationJSON(e *forkchoice.AttesterEquivocation) attesterEquivocationJSON {
+	return attesterEquivocationJSON{
+		ValidatorID: u64(e.ValidatorID),
+		Slot:        u64(e.Slot),
+		First:       toSignedAttestationJSON(e.First),
+		Second:      toSignedAttestationJSON(e.Second),
+	}
+}
+
+func toProposerEquivocationJSON(e *forkchoice.ProposerEquivocation) proposerEquivocationJSON {
+	return proposerEquivocationJSON{
+		ProposerIndex: u64(e.ProposerIndex),
+		Slot:          u64(e.Slot),
+		First:         toBlockHeaderJSON(e.First),
+		Second:        toBlockHeaderJSON(e.Second),
+	}
+}
+
+func toBlockJSON(b *types.Block) blockJSON {
+	out := blockJSON{
+		Slot:          u64(b.Slot),
+		ProposerIndex: u64(b.ProposerIndex),
+		ParentRoot:    hexBytes(b.ParentRoot[:]),
+		StateRoot:     hexBytes(b.StateRoot[:]),
+		Body:          blockBodyJSON{Attestations: []attestationJSON{}},
+	}
+	if b.Body != nil {
+		for _, a := range b.Body.Attestations {
+			out.Body.Attestations = append(out.Body.Attestations, toAttestationJSON(a))
+		}
+	}
+	return out
+}
+
+func toSignedBlockJSON(sb *types.SignedBlockWithAttestation) signedBlockJSON {
+	sigs := make([]string, len(sb.Signature))
+	for i := range sb.Signature {
+		sigs[i] = hexBytes(sb.Signature[i][:])
+	}
+	return signedBlockJSON{
+		Message: blockWithAttestationJSON{
+			Block:               toBlockJSON(sb.Message.Block),
+			ProposerAttestation: toAttestationJSON(sb.Message.ProposerAttestation),
+		},
+		Signature: sigs,
+	}
+}
+
+func toStateJSON(s *types.State) stateJSON {
+	validators := make([]validatorJSON, len(s.Validators))
+	for i, v := range s.Validators {
+		validators[i] = validatorJSON{Pubkey: hexBytes(v.Pubkey[:]), Index: u64(v.Index)}
+	}
+	return stateJSON{
+		Config:                   configJSON{GenesisTime: u64(s.Config.GenesisTime)},
+		Slot:                     u64(s.Slot),
+		LatestBlockHeader:        toBlockHeaderJSON(s.LatestBlockHeader),
+		LatestJustified:          toCheckpointJSON(s.LatestJustified),
+		LatestFinalized:          toCheckpointJSON(s.LatestFinalized),
+		HistoricalBlockHashes:    hexRoots(s.HistoricalBlockHashes),
+		JustifiedSlots:           hexBytes(s.JustifiedSlots),
+		Validators:               validators,
+		JustificationsRoots:      hexRoots(s.JustificationsRoots),
+		JustificationsValidators: hexBytes(s.JustificationsValidators),
+	}
+}
+
+func toForkChoiceNodeJSON(n forkchoice.ForkChoiceNode) forkChoiceNodeJSON {
+	return forkChoiceNodeJSON{
+		Root:       hexBytes(n.Root[:]),
+		ParentRoot: hexBytes(n.ParentRoot[:]),
+		Slot:       u64(n.Slot),
+		Weight:     strconv.Itoa(n.Weight),
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/syncer"
+	"github.com/geanlabs/gean/types"
+)
+
+// Content types for JSON and raw SSZ responses.
+const (
+	contentTypeJSON = "application/json"
+	contentTypeSSZ  = "application/octet-stream"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server serves chain data over HTTP following the beacon API layout under
+// the /lean/v0 prefix used by other lean clients.
+type Server struct {
+	FC      *forkchoice.Store
+	Storage storage.Store
+	// Sync reports the node's sync state to the health and syncing
+	// endpoints. A nil Sync reports the node as synced.
+	Sync *syncer.Syncer
+	http *http.Server
+	done chan struct{}
+	log  *slog.Logger
+}
+
+// New creates an API server for the given fork choice and storage.
+func New(fc *forkchoice.Store, store storage.Store) *Server {
+	s := &Server{
+		FC:      fc,
+		Storage: store,
+		done:    make(chan struct{}),
+		log:     logging.NewComponentLogger(logging.CompAPI),
+	}
+	s.http = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the router with all endpoints registered.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lean/v0/health", s.handleHealth)
+	mux.HandleFunc("GET /lean/v0/node/syncing", s.handleSyncing)
+	mux.HandleFunc("GET /lean/v0/node/peers", s.handlePeers)
+	mux.HandleFunc("GET /lean/v0/headers/head", s.handleHead)
+	mux.HandleFunc("GET /lean/v0/checkpoints", s.handleCheckpoints)
+	mux.HandleFunc("GET /lean/v0/checkpoints/{checkpoint_id}", s.handleCheckpoint)
+	mux.HandleFunc("GET /lean/v0/blocks/{block_id}", s.handleBlock)
+	mux.HandleFunc("GET /lean/v0/blocks/{block_id}/signed", s.handleSignedBlock)
+	mux.HandleFunc("GET /lean/v0/states/{state_id}", s.handleState)
+	mux.HandleFunc("GET /lean/v0/fork_choice", s.handleForkChoice)
+	mux.HandleFunc("GET /lean/v0/reorgs", s.handleReorgs)
+	mux.HandleFunc("GET /lean/v0/equivocations", s.handleEquivocations)
+	mux.HandleFunc("GET /lean/v0/events", s.handleEvents)
+	return mux
+}
+
+// Serve listens on port and serves until Close is called.
+func (s *Server) Serve(port int) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("api server error", "err", err)
+		}
+	}()
+	return nil
+}
+
+// Close ends open event streams and gracefully shuts the server down.
+func (s *Server) Close() error {
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+// resolveBlockID maps a block identifier to a block root. Identifiers are
+// "head", "genesis", "justified", "finalized", a decimal slot on the
+// canonical chain, or a 0x-prefixed block root.
+func (s *Server) resolveBlockID(id string) ([32]byte, error) {
+	head, justified, finalized := s.FC.Checkpoints()
+	switch id {
+	case "head":
+		return head, nil
+	case "genesis":
+		return s.canonicalRootAtSlot(head, 0)
+	case "justified":
+		return s.checkpointRoot(head, justified)
+	case "finalized":
+		return s.checkpointRoot(head, finalized)
+	}
+	if strings.HasPrefix(id, "0x") {
+		return parseRoot(id)
+	}
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return [32]byte{}, errBadRequest(fmt.Sprintf("invalid block id %q", id))
+	}
+	return s.canonicalRootAtSlot(head, slot)
+}
+
+// checkpointRoot resolves a checkpoint. The genesis checkpoint carries a zero
+// root until the first block is processed.
+func (s *Server) checkpointRoot(head [32]byte, cp *types.Checkpoint) ([32]byte, error) {
+	if cp.Root == types.ZeroHash {
+		return s.canonicalRootAtSlot(head, cp.Slot)
+	}
+	return cp.Root, nil
+}
+
+// canonicalRootAtSlot walks back from head to the block at slot. Skipped
+// slots have no block.
+func (s *Server) canonicalRootAtSlot(head [32]byte, slot uint64) ([32]byte, error) {
+	root := head
+	for {
+		b, ok := s.Storage.GetBlock(root)
+		if !ok {
+			return [32]byte{}, errNotFound(fmt.Sprintf("no canonical block at slot %d", slot))
+		}
+		if b.Slot == slot {
+			return root, nil
+		}
+		if b.Slot < slot {
+			return [32]byte{}, errNotFound(fmt.Sprintf("no canonical block at slot %d", slot))
+		}
+		root = b.ParentRoot
+	}
+}
+
+func parseRoot(s string) ([32]byte, error) {
+	var root [32]byte
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(raw) != 32 {
+		return root, errBadRequest(fmt.Sprintf("invalid root %q", s))
+	}
+	copy(root[:], raw)
+	return root, nil
+}
+
+// apiError carries the HTTP status for an error response.
+type apiError struct {
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func errBadRequest(msg string) error { return &apiError{code: http.StatusBadRequest, message: msg} }
+func errNotFound(msg string) error   { return &apiError{code: http.StatusNotFound, message: msg} }
+
+// writeError writes a beacon-API style error body.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		code = apiErr.code
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"code": code, "message": err.Error()})
+}
+
+// writeData writes v wrapped in a {"data": ...} envelope.
+func writeData(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	json.NewEncoder(w).Encode(map[string]any{"data": v})
+}
+
+func writeSSZ(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", contentTypeSSZ)
+	w.Write(data)
+}
+
+// wantsSSZ reports whether the client asked for raw SSZ.
+func wantsSSZ(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), contentTypeSSZ)
+}
@@ -1,17 +1,27 @@
 package forkchoice
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/geanlabs/gean/observability/metrics"
 	"github.com/geanlabs/gean/types"
 )
 
-// ProcessAttestation processes an attestation from the network.
-func (c *Store) ProcessAttestation(sa *types.SignedAttestation) {
+// ProcessAttestation processes an attestation from the network. Its
+// signature is checked against the validators of the head state.
+func (c *Store) ProcessAttestation(sa *types.SignedAttestation) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	state, ok := c.Storage.GetState(c.Head)
+	if !ok {
+		return fmt.Errorf("head state %x not found", c.Head)
+	}
+	if err := c.verifyAttestationSignature(state, sa.Message, &sa.Signature, "gossip"); err != nil {
+		return err
+	}
 	c.processAttestationLocked(sa, false)
+	return nil
 }
 
 func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBlock bool) {
@@ -25,9 +35,22 @@ func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBloc
 		source = "block"
 	}
 
+	// Hold attestations that outran one of their blocks; they are replayed
+	// when ProcessBlock stores it. The zero root never arrives, so votes
+	// referencing it fall through to validation and are rejected.
+	if root, missing := c.missingAttestationRootLocked(data); missing && root != types.ZeroHash {
+		if data.Slot >= c.LatestFinalized.Slot &&
+			c.pendingAttestations.add(root, sa, isFromBlock, c.Time/types.IntervalsPerSlot) {
+			metrics.AttestationsHeld.Inc()
+		}
+		return
+	}
+
 	if !c.validateAttestationLocked(att) {
+		metrics.AttestationsInvalid.WithLabelValues(source).Inc()
 		return
 	}
+	c.checkAttesterEquivocationLocked(sa)
 
 	if isFromBlock {
 		// On-chain: update known attestations if this is newer.
@@ -54,6 +77,15 @@ func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBloc
 		}
 	}
 
+	c.events.publish(TopicAttestation, AttestationEvent{
+		ValidatorID: validatorID,
+		Slot:        data.Slot,
+		Head:        *data.Head,
+		Target:      *data.Target,
+		Source:      *data.Source,
+		FromBlock:   isFromBlock,
+	})
+
 	metrics.AttestationsValid.WithLabelValues(source).Inc()
 	metrics.AttestationValidationTime.Observe(time.Since(start).Seconds())
 }
@@ -1,6 +1,7 @@
 package forkchoice
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,10 @@ import (
 	"github.com/geanlabs/gean/types"
 )
 
+// ErrUnknownParent is returned by ProcessBlock when the parent state is not
+// in storage yet. Callers may hold the block until the parent arrives.
+var ErrUnknownParent = errors.New("parent state not found")
+
 // ProcessBlock processes a new signed block envelope and updates chain state.
 // Attestation processing follows leanSpec on_block ordering:
 //  1. State transition on the bare block.
@@ -29,7 +34,7 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 
 	parentState, ok := c.Storage.GetState(block.ParentRoot)
 	if !ok {
-		return fmt.Errorf("parent state not found for %x", block.ParentRoot)
+		return fmt.Errorf("%w for %x", ErrUnknownParent, block.ParentRoot)
 	}
 
 	state, err := statetransition.StateTransition(parentState, block)
@@ -52,10 +57,11 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 				len(envelope.Signature), numBodyAtts, numBodyAtts)
 		}
 	}
+	if err := c.verifyBlockSignaturesLocked(parentState, envelope); err != nil {
+		return err
+	}
 
-	c.Storage.PutBlock(blockHash, block)
-	c.Storage.PutSignedBlock(blockHash, envelope)
-	c.Storage.PutState(blockHash, state)
+	c.putBlockLocked(blockHash, envelope, state)
 
 	// Step 2: Process body attestations as on-chain votes.
 	// Pair each body attestation with its signature from the envelope.
@@ -79,6 +85,9 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 		c.processAttestationLocked(proposerSA, false)
 	}
 
+	// Attestations that arrived before this block can now be validated.
+	c.replayPendingAttestationsLocked(blockHash)
+
 	metrics.ForkChoiceBlockProcessingTime.Observe(time.Since(start).Seconds())
 	metrics.StateTransitionTime.Observe(time.Since(start).Seconds())
 	return nil
@@ -0,0 +1,207 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+const (
+	// maxEquivocationEvidence bounds how much evidence of each kind is
+	// retained; the oldest is evicted first.
+	maxEquivocationEvidence = 1024
+	// equivocationWindowSlots is how many slots back votes and proposals
+	// are remembered to detect conflicts.
+	equivocationWindowSlots = 64
+)
+
+// AttesterEquivocation is evidence of a validator signing two different
+// AttestationData for the same slot.
+type AttesterEquivocation struct {
+	ValidatorID uint64
+	Slot        uint64
+	First       *types.SignedAttestation
+	Second      *types.SignedAttestation
+}
+
+// ProposerEquivocation is evidence of a proposer publishing two different
+// blocks for the same slot.
+type ProposerEquivocation struct {
+	ProposerIndex uint64
+	Slot          uint64
+	First         *types.BlockHeader
+	Second        *types.BlockHeader
+}
+
+type seenVote struct {
+	dataRoot [32]byte
+	sa       *types.SignedAttestation
+}
+
+type seenProposal struct {
+	root   [32]byte
+	header *types.BlockHeader
+}
+
+// equivocations records the first vote and proposal seen per validator and
+// slot, and evidence whenever a conflicting one shows up.
+type equivocations struct {
+	votes     map[uint64]map[uint64]seenVote     // slot -> validator -> vote
+	proposals map[uint64]map[uint64]seenProposal // slot -> proposer -> block
+	attester  []*AttesterEquivocation
+	proposer  []*ProposerEquivocation
+}
+
+func newEquivocations() *equivocations {
+	return &equivocations{
+		votes:     make(map[uint64]map[uint64]seenVote),
+		proposals: make(map[uint64]map[uint64]seenProposal),
+	}
+}
+
+// AttesterEquivocations returns the recorded double-vote evidence.
+func (c *Store) AttesterEquivocations() []*AttesterEquivocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*AttesterEquivocation, len(c.equivocations.attester))
+	copy(out, c.equivocations.attester)
+	return out
+}
+
+// ProposerEquivocations returns the recorded double-proposal evidence.
+func (c *Store) ProposerEquivocations() []*ProposerEquivocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*ProposerEquivocation, len(c.equivocations.proposer))
+	copy(out, c.equivocations.proposer)
+	return out
+}
+
+// checkAttesterEquivocationLocked records sa and records evidence if it
+// conflicts with an earlier vote by the same validator for the same slot.
+// sa must have passed validation; votes older than the window are ignored,
+// and so are all votes when signatures are not verified, since anyone
+// could forge the evidence.
+func (c *Store) checkAttesterEquivocationLocked(sa *types.SignedAttestation) {
+	att := sa.Message
+	if !c.verifiesSignatures() || att.Data.Slot < c.equivocationMinSlotLocked() {
+		return
+	}
+	dataRoot, err := att.Data.HashTreeRoot()
+	if err != nil {
+		return
+	}
+
+	bySlot, ok := c.equivocations.votes[att.Data.Slot]
+	if !ok {
+		bySlot = make(map[uint64]seenVote)
+		c.equivocations.votes[att.Data.Slot] = bySlot
+	}
+	first, ok := bySlot[att.ValidatorID]
+	if !ok {
+		bySlot[att.ValidatorID] = seenVote{dataRoot: dataRoot, sa: sa}
+		return
+	}
+	if first.dataRoot == dataRoot {
+		return
+	}
+
+	evidence := &AttesterEquivocation{
+		ValidatorID: att.ValidatorID,
+		Slot:        att.Data.Slot,
+		First:       first.sa,
+		Second:      sa,
+	}
+	c.equivocations.attester = appendEvidence(c.equivocations.attester, evidence, "attester")
+	metrics.Equivocations.WithLabelValues("attester").Inc()
+	c.log.Warn("attester equivocation detected",
+		"validator", att.ValidatorID,
+		"slot", att.Data.Slot,
+		"first_head", logging.ShortHash(first.sa.Message.Data.Head.Root),
+		"second_head", logging.ShortHash(att.Data.Head.Root),
+	)
+}
+
+// checkProposerEquivocationLocked records block and records evidence if its
+// proposer already produced a different block for the same slot. Like
+// votes, blocks are only recorded when signatures are verified.
+func (c *Store) checkProposerEquivocationLocked(root [32]byte, block *types.Block) {
+	if !c.verifiesSignatures() || block.Slot < c.equivocationMinSlotLocked() {
+		return
+	}
+	bySlot, ok := c.equivocations.proposals[block.Slot]
+	if !ok {
+		bySlot = make(map[uint64]seenProposal)
+		c.equivocations.proposals[block.Slot] = bySlot
+	}
+	bodyRoot, _ := block.Body.HashTreeRoot()
+	header := &types.BlockHeader{
+		Slot:          block.Slot,
+		ProposerIndex: block.ProposerIndex,
+		ParentRoot:    block.ParentRoot,
+		StateRoot:     block.StateRoot,
+		BodyRoot:      bodyRoot,
+	}
+
+	first, ok := bySlot[block.ProposerIndex]
+	if !ok {
+		bySlot[block.ProposerIndex] = seenProposal{root: root, header: header}
+		return
+	}
+	if first.root == root {
+		return
+	}
+
+	evidence := &ProposerEquivocation{
+		ProposerIndex: block.ProposerIndex,
+		Slot:          block.Slot,
+		First:         first.header,
+		Second:        header,
+	}
+	c.equivocations.proposer = appendEvidence(c.equivocations.proposer, evidence, "proposer")
+	metrics.Equivocations.WithLabelValues("proposer").Inc()
+	c.log.Warn("proposer equivocation detected",
+		"proposer", block.ProposerIndex,
+		"slot", block.Slot,
+		"first_root", logging.ShortHash(first.root),
+		"second_root", logging.ShortHash(root),
+	)
+}
+
+// appendEvidence appends e to list, evicting the oldest entry once the list
+// holds maxEquivocationEvidence.
+func appendEvidence[E any](list []E, e E, kind string) []E {
+	if len(list) >= maxEquivocationEvidence {
+		n := copy(list, list[len(list)-maxEquivocationEvidence+1:])
+		list = list[:n]
+		metrics.EquivocationEvidenceDropped.WithLabelValues(kind).Inc()
+	}
+	return append(list, e)
+}
+
+// equivocationMinSlotLocked returns the oldest slot whose votes and
+// proposals are still remembered: the finalized slot or the start of the
+// window, whichever is later.
+func (c *Store) equivocationMinSlotLocked() uint64 {
+	minSlot := c.LatestFinalized.Slot
+	if currentSlot := c.Time / types.IntervalsPerSlot; currentSlot > equivocationWindowSlots+minSlot {
+		minSlot = currentSlot - equivocationWindowSlots
+	}
+	return minSlot
+}
+
+// pruneEquivocationsLocked forgets votes and proposals that fell out of the
+// window or below finality. Recorded evidence is kept.
+func (c *Store) pruneEquivocationsLocked() {
+	minSlot := c.equivocationMinSlotLocked()
+	for slot := range c.equivocations.votes {
+		if slot < minSlot {
+			delete(c.equivocations.votes, slot)
+		}
+	}
+	for slot := range c.equivocations.proposals {
+		if slot < minSlot {
+			delete(c.equivocations.proposals, slot)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package forkchoice
+
+import "testing"
+
+func TestAppendEvidenceEvictsOldest(t *testing.T) {
+	var list []int
+	for i := 0; i < maxEquivocationEvidence+2; i++ {
+		list = appendEvidence(list, i, "test")
+	}
+	if len(list) != maxEquivocationEvidence {
+		t.Fatalf("len = %d, want %d", len(list), maxEquivocationEvidence)
+	}
+	if list[0] != 2 || list[len(list)-1] != maxEquivocationEvidence+1 {
+		t.Fatalf("list spans %d..%d, want 2..%d", list[0], list[len(list)-1], maxEquivocationEvidence+1)
+	}
+}
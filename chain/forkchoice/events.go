@@ -0,0 +1,130 @@
+package forkchoice
+
+import (
+	"sync"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// Event topics published by the store.
+const (
+	TopicHead          = "head"
+	TopicBlock         = "block"
+	TopicAttestation   = "attestation"
+	TopicJustification = "justification"
+	TopicFinalization  = "finalization"
+	TopicReorg         = "reorg"
+)
+
+// EventTopics lists every topic in a stable order.
+var EventTopics = []string{TopicHead, TopicBlock, TopicAttestation, TopicJustification, TopicFinalization, TopicReorg}
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const eventBufferSize = 256
+
+// Event is a notification of a fork choice change. Data holds one of
+// HeadEvent, BlockEvent, AttestationEvent, CheckpointEvent or ReorgEvent.
+type Event struct {
+	Topic string
+	Data  any
+}
+
+// HeadEvent is published when the head moves to a different block.
+type HeadEvent struct {
+	Slot          uint64
+	Root          [32]byte
+	ProposerIndex uint64
+}
+
+// BlockEvent is published when a block is imported.
+type BlockEvent struct {
+	Slot          uint64
+	Root          [32]byte
+	ParentRoot    [32]byte
+	ProposerIndex uint64
+}
+
+// AttestationEvent is published when a valid attestation is applied.
+type AttestationEvent struct {
+	ValidatorID uint64
+	Slot        uint64
+	Head        types.Checkpoint
+	Target      types.Checkpoint
+	Source      types.Checkpoint
+	FromBlock   bool
+}
+
+// CheckpointEvent is published when the justified or finalized checkpoint
+// advances.
+type CheckpointEvent struct {
+	Slot uint64
+	Root [32]byte
+}
+
+// ReorgEvent is published when the new head does not descend from the old
+// one. Depth is the number of slots between the old head and the common
+// ancestor.
+type ReorgEvent struct {
+	Slot           uint64
+	OldHead        [32]byte
+	OldHeadSlot    uint64
+	NewHead        [32]byte
+	NewHeadSlot    uint64
+	CommonAncestor [32]byte
+	Depth          uint64
+}
+
+// eventFeed fans events out to subscribers without blocking the publisher.
+// Slow subscribers lose events rather than stall fork choice.
+type eventFeed struct {
+	mu   sync.Mutex
+	subs map[chan Event]map[string]bool
+}
+
+func newEventFeed() *eventFeed {
+	return &eventFeed{subs: make(map[chan Event]map[string]bool)}
+}
+
+func (f *eventFeed) subscribe(topics []string) chan Event {
+	want := make(map[string]bool, len(topics))
+	for _, t := range topics {
+		want[t] = true
+	}
+	ch := make(chan Event, eventBufferSize)
+	f.mu.Lock()
+	f.subs[ch] = want
+	f.mu.Unlock()
+	return ch
+}
+
+func (f *eventFeed) unsubscribe(ch chan Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+func (f *eventFeed) publish(topic string, data any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch, want := range f.subs {
+		if len(want) > 0 && !want[topic] {
+			continue
+		}
+		select {
+		case ch <- Event{Topic: topic, Data: data}:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns a channel receiving events for the given topics,
+// or for every topic when none are given, and a function that cancels the
+// subscription and closes the channel.
+func (c *Store) SubscribeEvents(topics ...string) (<-chan Event, func()) {
+	ch := c.events.subscribe(topics)
+	return ch, func() { c.events.unsubscribe(ch) }
+}
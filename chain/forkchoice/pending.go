@@ -0,0 +1,161 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Pending attestation buffer defaults.
+const (
+	pendingAttestationsLimit = 4096
+	// pendingPerRootLimit bounds the attestations held for one missing
+	// root, so that votes for a made-up root cannot crowd out the rest.
+	pendingPerRootLimit = 1024
+	// pendingPerValidatorLimit bounds the roots one validator can have
+	// attestations held for.
+	pendingPerValidatorLimit = 4
+	// DefaultPendingAttestationSlots is how long an attestation waits for a
+	// missing block before it is dropped.
+	DefaultPendingAttestationSlots = 8
+)
+
+// pendingAttestations holds attestations that reference a block not yet in
+// storage, keyed by the missing root and then by validator. Only the newest
+// attestation per validator and root is kept.
+type pendingAttestations struct {
+	byRoot map[[32]byte]map[uint64]*heldAttestation
+	// perValidator counts the roots each validator has attestations held for.
+	perValidator map[uint64]int
+	count        int
+	limit        int
+}
+
+type heldAttestation struct {
+	sa          *types.SignedAttestation
+	isFromBlock bool
+	heldSlot    uint64
+}
+
+func newPendingAttestations(limit int) *pendingAttestations {
+	return &pendingAttestations{
+		byRoot:       make(map[[32]byte]map[uint64]*heldAttestation),
+		perValidator: make(map[uint64]int),
+		limit:        limit,
+	}
+}
+
+// add holds sa until root arrives. It returns false when the buffer, the
+// root or the validator is at its limit, or when an attestation at least as
+// new is already held.
+func (p *pendingAttestations) add(root [32]byte, sa *types.SignedAttestation, isFromBlock bool, slot uint64) bool {
+	byValidator, ok := p.byRoot[root]
+	if !ok {
+		byValidator = make(map[uint64]*heldAttestation)
+		p.byRoot[root] = byValidator
+	}
+	id := sa.Message.ValidatorID
+	if existing, ok := byValidator[id]; ok {
+		if existing.sa.Message.Data.Slot >= sa.Message.Data.Slot {
+			return false
+		}
+		byValidator[id] = &heldAttestation{sa: sa, isFromBlock: isFromBlock, heldSlot: slot}
+		return true
+	}
+	if p.count >= p.limit || len(byValidator) >= pendingPerRootLimit || p.perValidator[id] >= pendingPerValidatorLimit {
+		if len(byValidator) == 0 {
+			delete(p.byRoot, root)
+		}
+		return false
+	}
+	byValidator[id] = &heldAttestation{sa: sa, isFromBlock: isFromBlock, heldSlot: slot}
+	p.perValidator[id]++
+	p.count++
+	metrics.AttestationsPending.Set(float64(p.count))
+	return true
+}
+
+// take removes and returns everything held for root.
+func (p *pendingAttestations) take(root [32]byte) []*heldAttestation {
+	byValidator, ok := p.byRoot[root]
+	if !ok {
+		return nil
+	}
+	delete(p.byRoot, root)
+	held := make([]*heldAttestation, 0, len(byValidator))
+	for id, h := range byValidator {
+		held = append(held, h)
+		p.release(id)
+	}
+	p.count -= len(held)
+	metrics.AttestationsPending.Set(float64(p.count))
+	return held
+}
+
+// expire drops attestations held since before minSlot.
+func (p *pendingAttestations) expire(minSlot uint64) int {
+	expired := 0
+	for root, byValidator := range p.byRoot {
+		for id, h := range byValidator {
+			if h.heldSlot < minSlot {
+				delete(byValidator, id)
+				p.release(id)
+				expired++
+			}
+		}
+		if len(byValidator) == 0 {
+			delete(p.byRoot, root)
+		}
+	}
+	p.count -= expired
+	metrics.AttestationsPending.Set(float64(p.count))
+	return expired
+}
+
+// release drops one held root from the count of validator id.
+func (p *pendingAttestations) release(id uint64) {
+	if p.perValidator[id] <= 1 {
+		delete(p.perValidator, id)
+		return
+	}
+	p.perValidator[id]--
+}
+
+// PendingAttestationCount returns how many attestations are waiting on
+// unknown blocks.
+func (c *Store) PendingAttestationCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pendingAttestations.count
+}
+
+// missingAttestationRootLocked returns the first of the source, target and
+// head roots that is not in storage.
+func (c *Store) missingAttestationRootLocked(data *types.AttestationData) ([32]byte, bool) {
+	for _, root := range [][32]byte{data.Source.Root, data.Target.Root, data.Head.Root} {
+		if _, ok := c.Storage.GetBlock(root); !ok {
+			return root, true
+		}
+	}
+	return [32]byte{}, false
+}
+
+// replayPendingAttestationsLocked re-processes attestations that were
+// waiting on root. Ones still missing another block are held again.
+func (c *Store) replayPendingAttestationsLocked(root [32]byte) {
+	for _, h := range c.pendingAttestations.take(root) {
+		metrics.AttestationsReplayed.Inc()
+		c.processAttestationLocked(h.sa, h.isFromBlock)
+	}
+}
+
+// expirePendingAttestationsLocked drops attestations held for longer than
+// PendingAttestationSlots.
+func (c *Store) expirePendingAttestationsLocked() {
+	currentSlot := c.Time / types.IntervalsPerSlot
+	if currentSlot <= c.PendingAttestationSlots {
+		return
+	}
+	if expired := c.pendingAttestations.expire(currentSlot - c.PendingAttestationSlots); expired > 0 {
+		metrics.AttestationsExpired.Add(float64(expired))
+	}
+}
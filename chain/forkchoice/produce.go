@@ -63,7 +63,9 @@ func (c *Store) getVoteTargetLocked() *types.Checkpoint {
 //   - the proposer's own attestation (head = produced block)
 //   - the signature list (body attestation sigs + proposer sig last)
 //
-// Signatures are zero-filled until XMSS signing is integrated.
+// The proposer signature is left zero for the validator to fill in. The
+// block is not imported: the validator signs it first and then passes it
+// to ProcessBlock, so an unsigned block never becomes our head.
 func (c *Store) ProduceBlock(slot, validatorIndex uint64) (*types.SignedBlockWithAttestation, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -164,12 +166,11 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64) (*types.SignedBlockWit
 	}
 
 	// Build signature list: body attestation sigs in order, proposer sig last.
-	// TODO: populate with real XMSS signatures once leanSig is integrated.
 	sigs := make([][3116]byte, len(collectedSigned)+1)
 	for i, sa := range collectedSigned {
 		sigs[i] = sa.Signature
 	}
-	// sigs[len(collectedSigned)] is the proposer sig (zero for now).
+	// sigs[len(collectedSigned)] is the proposer sig, signed by the caller.
 
 	envelope := &types.SignedBlockWithAttestation{
 		Message: &types.BlockWithAttestation{
@@ -178,16 +179,11 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64) (*types.SignedBlockWit
 		},
 		Signature: sigs,
 	}
-
-	c.Storage.PutBlock(blockHash, finalBlock)
-	c.Storage.PutSignedBlock(blockHash, envelope)
-	c.Storage.PutState(blockHash, finalState)
-
 	return envelope, nil
 }
 
-// ProduceAttestation produces a signed attestation for the given slot and validator.
-// Signature is zero-filled until XMSS signing is integrated.
+// ProduceAttestation produces an attestation for the given slot and validator.
+// The signature is left zero for the validator to fill in.
 func (c *Store) ProduceAttestation(slot, validatorIndex uint64) *types.SignedAttestation {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -217,6 +213,5 @@ func (c *Store) ProduceAttestation(slot, validatorIndex uint64) *types.SignedAtt
 				Source: c.LatestJustified,
 			},
 		},
-		// TODO: sign with XMSS once leanSig is integrated.
 	}
 }
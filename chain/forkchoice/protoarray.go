@@ -0,0 +1,254 @@
+package forkchoice
+
+import (
+	"sort"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// ProtoArray is an incremental LMD GHOST tree.
+//
+// Blocks are appended as they are imported, so a parent always has a lower
+// index than its children. Each node carries the number of latest votes for
+// it or any of its descendants. When votes change, only the difference from
+// the previously applied votes is pushed up the tree in one backward pass,
+// and a head query walks down from the root choosing the heaviest child.
+type ProtoArray struct {
+	nodes   []*protoNode
+	indices map[[32]byte]int
+	votes   map[uint64][32]byte // validator -> head root currently counted
+	// deltas is scratch space for ApplyVotes, all zero between calls.
+	deltas []int
+}
+
+type protoNode struct {
+	root     [32]byte
+	slot     uint64
+	parent   int // -1 when the parent is unknown or pruned
+	children []int
+	weight   int
+}
+
+// NewProtoArray creates an empty tree.
+func NewProtoArray() *ProtoArray {
+	return &ProtoArray{
+		indices: make(map[[32]byte]int),
+		votes:   make(map[uint64][32]byte),
+	}
+}
+
+// newProtoArrayFromBlocks builds a tree from a block set, inserting parents
+// before children.
+func newProtoArrayFromBlocks(blocks map[[32]byte]*types.Block) *ProtoArray {
+	roots := make([][32]byte, 0, len(blocks))
+	for root := range blocks {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return blocks[roots[i]].Slot < blocks[roots[j]].Slot
+	})
+
+	p := NewProtoArray()
+	for _, root := range roots {
+		b := blocks[root]
+		p.OnBlock(root, b.ParentRoot, b.Slot)
+	}
+	return p
+}
+
+// OnBlock inserts a block. Inserting a known root is a no-op.
+func (p *ProtoArray) OnBlock(root, parentRoot [32]byte, slot uint64) {
+	if _, ok := p.indices[root]; ok {
+		return
+	}
+	parent := -1
+	if idx, ok := p.indices[parentRoot]; ok {
+		parent = idx
+	}
+	idx := len(p.nodes)
+	p.nodes = append(p.nodes, &protoNode{root: root, slot: slot, parent: parent})
+	p.indices[root] = idx
+	if parent >= 0 {
+		p.nodes[parent].children = append(p.nodes[parent].children, idx)
+	}
+}
+
+// Contains reports whether root is in the tree.
+func (p *ProtoArray) Contains(root [32]byte) bool {
+	_, ok := p.indices[root]
+	return ok
+}
+
+// Weight returns the vote weight of root and its descendants.
+func (p *ProtoArray) Weight(root [32]byte) int {
+	idx, ok := p.indices[root]
+	if !ok {
+		return 0
+	}
+	return p.nodes[idx].weight
+}
+
+// ApplyVotes brings node weights in line with latest, touching only the
+// validators whose head vote changed since the previous call. Votes for
+// unknown blocks are not counted until the block is inserted.
+func (p *ProtoArray) ApplyVotes(latest map[uint64]*types.SignedAttestation) {
+	if cap(p.deltas) < len(p.nodes) {
+		p.deltas = make([]int, len(p.nodes), 2*len(p.nodes))
+	}
+	deltas := p.deltas[:len(p.nodes)]
+	changed := false
+
+	for id, root := range p.votes {
+		if _, ok := latest[id]; ok {
+			continue
+		}
+		if idx, ok := p.indices[root]; ok {
+			deltas[idx]--
+			changed = true
+		}
+		delete(p.votes, id)
+	}
+
+	for id, sa := range latest {
+		newRoot := sa.Message.Data.Head.Root
+		oldRoot, had := p.votes[id]
+		if had && oldRoot == newRoot {
+			continue
+		}
+		if had {
+			if idx, ok := p.indices[oldRoot]; ok {
+				deltas[idx]--
+				changed = true
+			}
+			delete(p.votes, id)
+		}
+		if idx, ok := p.indices[newRoot]; ok {
+			deltas[idx]++
+			changed = true
+			p.votes[id] = newRoot
+		}
+	}
+
+	if !changed {
+		return
+	}
+	for i := len(p.nodes) - 1; i >= 0; i-- {
+		if deltas[i] == 0 {
+			continue
+		}
+		n := p.nodes[i]
+		n.weight += deltas[i]
+		if n.parent >= 0 {
+			deltas[n.parent] += deltas[i]
+		}
+		deltas[i] = 0
+	}
+}
+
+// Head applies latest and walks down from root, at each step picking the
+// child with the most votes among children with at least minScore votes.
+// Tiebreak: highest slot, then largest hash. A zero root starts at the
+// earliest block in the tree.
+func (p *ProtoArray) Head(root [32]byte, latest map[uint64]*types.SignedAttestation, minScore int) [32]byte {
+	p.ApplyVotes(latest)
+
+	if root == types.ZeroHash && len(p.nodes) > 0 {
+		root = p.nodes[0].root
+	}
+	if len(latest) == 0 {
+		return root
+	}
+	current, ok := p.indices[root]
+	if !ok {
+		return root
+	}
+
+	for {
+		best := -1
+		for _, c := range p.nodes[current].children {
+			child := p.nodes[c]
+			if child.weight < minScore {
+				continue
+			}
+			if best < 0 {
+				best = c
+				continue
+			}
+			b := p.nodes[best]
+			if child.weight > b.weight ||
+				(child.weight == b.weight && child.slot > b.slot) ||
+				(child.weight == b.weight && child.slot == b.slot && hashGreater(child.root, b.root)) {
+				best = c
+			}
+		}
+		if best < 0 {
+			return p.nodes[current].root
+		}
+		current = best
+	}
+}
+
+// Prune drops every node that is not finalizedRoot or one of its
+// descendants. Weights of the remaining nodes are unaffected.
+func (p *ProtoArray) Prune(finalizedRoot [32]byte) {
+	finalizedIdx, ok := p.indices[finalizedRoot]
+	if !ok {
+		return
+	}
+
+	keep := make([]bool, len(p.nodes))
+	keep[finalizedIdx] = true
+	for i := finalizedIdx + 1; i < len(p.nodes); i++ {
+		if parent := p.nodes[i].parent; parent >= 0 && keep[parent] {
+			keep[i] = true
+		}
+	}
+
+	remap := make([]int, len(p.nodes))
+	nodes := make([]*protoNode, 0, len(p.nodes)-finalizedIdx)
+	indices := make(map[[32]byte]int, len(nodes))
+	for i, n := range p.nodes {
+		if !keep[i] {
+			remap[i] = -1
+			continue
+		}
+		remap[i] = len(nodes)
+		indices[n.root] = len(nodes)
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		if n.parent >= 0 {
+			n.parent = remap[n.parent]
+		}
+		children := n.children[:0]
+		for _, c := range n.children {
+			if remap[c] >= 0 {
+				children = append(children, remap[c])
+			}
+		}
+		n.children = children
+	}
+
+	p.nodes = nodes
+	p.indices = indices
+}
+
+// ForkChoiceNode describes one block in the fork choice tree.
+type ForkChoiceNode struct {
+	Root       [32]byte
+	ParentRoot [32]byte // zero when the parent is unknown or pruned
+	Slot       uint64
+	Weight     int
+}
+
+// Nodes returns every node in insertion order, parents before children.
+func (p *ProtoArray) Nodes() []ForkChoiceNode {
+	out := make([]ForkChoiceNode, len(p.nodes))
+	for i, n := range p.nodes {
+		out[i] = ForkChoiceNode{Root: n.root, Slot: n.slot, Weight: n.weight}
+		if n.parent >= 0 {
+			out[i].ParentRoot = p.nodes[n.parent].root
+		}
+	}
+	return out
+}
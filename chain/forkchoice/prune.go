@@ -0,0 +1,116 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// Prune drops history that the current finalized checkpoint makes
+// unreachable. It runs automatically whenever finalization advances.
+func (c *Store) Prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked()
+}
+
+// pruneLocked removes from storage:
+//   - every block, envelope and state that does not descend from the
+//     finalized block and is not one of its ancestors,
+//   - states of finalized ancestors (only the finalized state is kept),
+//   - finalized ancestor blocks themselves when PruneFinalizedBlocks is set.
+//
+// Attestations whose head is older than the finalized slot are dropped as
+// they can no longer affect fork choice.
+func (c *Store) pruneLocked() {
+	finalized := *c.LatestFinalized
+	if _, ok := c.Storage.GetBlock(finalized.Root); !ok {
+		return
+	}
+	c.prunedSlot = finalized.Slot
+
+	blocks := c.Storage.GetAllBlocks()
+
+	// Canonical chain at and below the finalized block.
+	canonical := make(map[[32]byte]bool)
+	for root := finalized.Root; ; {
+		b, ok := blocks[root]
+		if !ok {
+			break
+		}
+		canonical[root] = true
+		root = b.ParentRoot
+	}
+
+	// Memoized "descends from finalized" check for blocks above it.
+	descends := map[[32]byte]bool{finalized.Root: true}
+	var isDescendant func(root [32]byte) bool
+	isDescendant = func(root [32]byte) bool {
+		if d, ok := descends[root]; ok {
+			return d
+		}
+		b, ok := blocks[root]
+		if !ok || b.Slot <= finalized.Slot {
+			descends[root] = false
+			return false
+		}
+		d := isDescendant(b.ParentRoot)
+		descends[root] = d
+		return d
+	}
+
+	prunedBlocks := 0
+	for root := range blocks {
+		if canonical[root] {
+			if root != finalized.Root && c.PruneFinalizedBlocks {
+				c.Storage.DeleteBlock(root)
+				c.Storage.DeleteSignedBlock(root)
+				prunedBlocks++
+			}
+			continue
+		}
+		if isDescendant(root) {
+			continue
+		}
+		c.Storage.DeleteBlock(root)
+		c.Storage.DeleteSignedBlock(root)
+		prunedBlocks++
+	}
+
+	prunedStates := 0
+	for root := range c.Storage.GetAllStates() {
+		if root == finalized.Root || (!canonical[root] && isDescendant(root)) {
+			continue
+		}
+		c.Storage.DeleteState(root)
+		prunedStates++
+	}
+
+	c.pruneEquivocationsLocked()
+	c.knownVotes.Prune(finalized.Root)
+	c.newVotes.Prune(finalized.Root)
+
+	prunedAttestations := pruneAttestations(c.LatestKnownAttestations, finalized.Slot) +
+		pruneAttestations(c.LatestNewAttestations, finalized.Slot)
+
+	if prunedBlocks > 0 || prunedStates > 0 || prunedAttestations > 0 {
+		c.log.Info("pruned finalized history",
+			"finalized_slot", finalized.Slot,
+			"finalized_root", logging.ShortHash(finalized.Root),
+			"blocks", prunedBlocks,
+			"states", prunedStates,
+			"attestations", prunedAttestations,
+		)
+	}
+}
+
+// pruneAttestations removes votes whose head is older than the finalized slot.
+func pruneAttestations(atts map[uint64]*types.SignedAttestation, finalizedSlot uint64) int {
+	pruned := 0
+	for id, sa := range atts {
+		if sa.Message.Data.Head.Slot < finalizedSlot {
+			delete(atts, id)
+			pruned++
+		}
+	}
+	return pruned
+}
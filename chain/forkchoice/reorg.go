@@ -0,0 +1,101 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// reorgHistorySize is how many recent reorgs are kept for RecentReorgs.
+const reorgHistorySize = 64
+
+// RecentReorgs returns the most recent reorgs, oldest first.
+func (c *Store) RecentReorgs() []ReorgEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]ReorgEvent, len(c.reorgs))
+	copy(out, c.reorgs)
+	return out
+}
+
+// onHeadChangeLocked publishes a head event for the new head. When the new
+// head does not descend from oldHead it records a reorg with its common
+// ancestor and depth.
+func (c *Store) onHeadChangeLocked(oldHead [32]byte) {
+	newBlock, ok := c.Storage.GetBlock(c.Head)
+	if !ok {
+		return
+	}
+	c.events.publish(TopicHead, HeadEvent{
+		Slot:          newBlock.Slot,
+		Root:          c.Head,
+		ProposerIndex: newBlock.ProposerIndex,
+	})
+
+	oldBlock, ok := c.Storage.GetBlock(oldHead)
+	if !ok {
+		return
+	}
+	ancestor, ancestorSlot, ok := c.commonAncestorLocked(oldHead, c.Head)
+	if !ok || ancestor == oldHead {
+		return
+	}
+	reorg := ReorgEvent{
+		Slot:           newBlock.Slot,
+		OldHead:        oldHead,
+		OldHeadSlot:    oldBlock.Slot,
+		NewHead:        c.Head,
+		NewHeadSlot:    newBlock.Slot,
+		CommonAncestor: ancestor,
+		Depth:          oldBlock.Slot - ancestorSlot,
+	}
+	c.recordReorgLocked(reorg)
+	c.events.publish(TopicReorg, reorg)
+}
+
+// recordReorgLocked logs a reorg, updates metrics and appends it to the
+// bounded history.
+func (c *Store) recordReorgLocked(reorg ReorgEvent) {
+	metrics.ForkChoiceReorgs.Inc()
+	metrics.ForkChoiceReorgDepth.Observe(float64(reorg.Depth))
+	c.log.Warn("chain reorg",
+		"depth", reorg.Depth,
+		"old_head_slot", reorg.OldHeadSlot,
+		"old_head", logging.ShortHash(reorg.OldHead),
+		"new_head_slot", reorg.NewHeadSlot,
+		"new_head", logging.ShortHash(reorg.NewHead),
+		"common_ancestor", logging.ShortHash(reorg.CommonAncestor),
+	)
+
+	if len(c.reorgs) == reorgHistorySize {
+		copy(c.reorgs, c.reorgs[1:])
+		c.reorgs = c.reorgs[:reorgHistorySize-1]
+	}
+	c.reorgs = append(c.reorgs, reorg)
+}
+
+// commonAncestorLocked walks both chains back until they meet. It returns
+// false if either chain runs out of stored blocks first.
+func (c *Store) commonAncestorLocked(a, b [32]byte) ([32]byte, uint64, bool) {
+	blockA, ok := c.Storage.GetBlock(a)
+	if !ok {
+		return [32]byte{}, 0, false
+	}
+	blockB, ok := c.Storage.GetBlock(b)
+	if !ok {
+		return [32]byte{}, 0, false
+	}
+	for a != b {
+		if blockA.Slot >= blockB.Slot {
+			a = blockA.ParentRoot
+			if blockA, ok = c.Storage.GetBlock(a); !ok {
+				return [32]byte{}, 0, false
+			}
+		} else {
+			b = blockB.ParentRoot
+			if blockB, ok = c.Storage.GetBlock(b); !ok {
+				return [32]byte{}, 0, false
+			}
+		}
+	}
+	return a, blockA.Slot, true
+}
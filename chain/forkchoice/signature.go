@@ -0,0 +1,93 @@
+package forkchoice
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss"
+)
+
+// Errors returned for blocks and attestations with bad signatures.
+var (
+	ErrUnknownValidator            = errors.New("unknown validator")
+	ErrInvalidAttestationSignature = errors.New("invalid attestation signature")
+	ErrInvalidProposerSignature    = errors.New("invalid proposer attestation signature")
+)
+
+// SignatureVerifier checks a validator's signature over message in the
+// epoch given by slot.
+type SignatureVerifier interface {
+	Verify(pubkey [52]byte, slot uint64, message [32]byte, signature *[3116]byte) error
+}
+
+// XMSSVerifier verifies signatures with the xmss package.
+type XMSSVerifier struct{}
+
+func (XMSSVerifier) Verify(pubkey [52]byte, slot uint64, message [32]byte, signature *[3116]byte) error {
+	return xmss.Verify(pubkey, slot, message, signature)
+}
+
+// NoopVerifier accepts every signature. It is for tests and devnets only.
+type NoopVerifier struct{}
+
+func (NoopVerifier) Verify([52]byte, uint64, [32]byte, *[3116]byte) error {
+	return nil
+}
+
+// verifiesSignatures reports whether c.Verifier checks signatures at all.
+func (c *Store) verifiesSignatures() bool {
+	_, noop := c.Verifier.(NoopVerifier)
+	return !noop
+}
+
+// AttestationSigningRoot returns the message a validator signs for att.
+// The signature epoch is the attestation slot.
+func AttestationSigningRoot(att *types.Attestation) ([32]byte, error) {
+	return att.HashTreeRoot()
+}
+
+// verifyBlockSignaturesLocked checks the body attestation signatures and
+// the proposer attestation signature of envelope against the validators of
+// the parent state.
+func (c *Store) verifyBlockSignaturesLocked(parentState *types.State, envelope *types.SignedBlockWithAttestation) error {
+	atts := envelope.Message.Block.Body.Attestations
+	for i, att := range atts {
+		if err := c.verifyAttestationSignature(parentState, att, &envelope.Signature[i], "block"); err != nil {
+			return fmt.Errorf("body attestation %d: %w", i, err)
+		}
+	}
+	if proposerAtt := envelope.Message.ProposerAttestation; proposerAtt != nil {
+		err := c.verifyAttestationSignature(parentState, proposerAtt, &envelope.Signature[len(atts)], "proposer")
+		if errors.Is(err, ErrInvalidAttestationSignature) {
+			return fmt.Errorf("%w: validator %d", ErrInvalidProposerSignature, proposerAtt.ValidatorID)
+		}
+		return err
+	}
+	return nil
+}
+
+// verifyAttestationSignature checks sig over att against the validator
+// registry of state. source labels the metrics: block, proposer or gossip.
+func (c *Store) verifyAttestationSignature(state *types.State, att *types.Attestation, sig *[3116]byte, source string) error {
+	if att.ValidatorID >= uint64(len(state.Validators)) {
+		metrics.SignatureVerifications.WithLabelValues(source, "unknown_validator").Inc()
+		return fmt.Errorf("%w: %d", ErrUnknownValidator, att.ValidatorID)
+	}
+	root, err := AttestationSigningRoot(att)
+	if err != nil {
+		return fmt.Errorf("attestation root: %w", err)
+	}
+
+	start := time.Now()
+	err = c.Verifier.Verify(state.Validators[att.ValidatorID].Pubkey, att.Data.Slot, root, sig)
+	metrics.SignatureVerificationTime.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.SignatureVerifications.WithLabelValues(source, "invalid").Inc()
+		return fmt.Errorf("%w: validator %d slot %d: %v", ErrInvalidAttestationSignature, att.ValidatorID, att.Data.Slot, err)
+	}
+	metrics.SignatureVerifications.WithLabelValues(source, "valid").Inc()
+	return nil
+}
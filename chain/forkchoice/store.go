@@ -2,8 +2,10 @@ package forkchoice
 
 import (
 	"fmt"
+	"log/slog"
 	"sync"
 
+	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
 )
@@ -22,8 +24,37 @@ type Store struct {
 	LatestFinalized *types.Checkpoint
 	Storage         storage.Store
 
+	// bestJustified is the justified checkpoint with the highest slot
+	// among the post-states of imported blocks.
+	bestJustified *types.Checkpoint
+
 	LatestKnownAttestations map[uint64]*types.SignedAttestation
 	LatestNewAttestations   map[uint64]*types.SignedAttestation
+
+	// PruneFinalizedBlocks drops canonical blocks below the finalized
+	// checkpoint. When false they are kept to serve BlocksByRoot requests.
+	PruneFinalizedBlocks bool
+
+	// PendingAttestationSlots is how many slots an attestation referencing
+	// an unknown block is held before it is dropped.
+	PendingAttestationSlots uint64
+
+	// Verifier checks block and attestation signatures. It defaults to
+	// XMSSVerifier.
+	Verifier SignatureVerifier
+
+	// knownVotes tracks LatestKnownAttestations for head selection and
+	// newVotes tracks LatestNewAttestations for the safe target.
+	knownVotes *ProtoArray
+	newVotes   *ProtoArray
+
+	pendingAttestations *pendingAttestations
+	equivocations       *equivocations
+	events              *eventFeed
+	reorgs              []ReorgEvent
+
+	prunedSlot uint64
+	log        *slog.Logger
 }
 
 // NewStore initializes a store from an anchor state and block.
@@ -36,21 +67,162 @@ func NewStore(state *types.State, anchorBlock *types.Block, store storage.Store)
 	anchorRoot, _ := anchorBlock.HashTreeRoot()
 
 	store.PutBlock(anchorRoot, anchorBlock)
-	store.PutSignedBlock(anchorRoot, &types.SignedBlockWithAttestation{
-		Message: &types.BlockWithAttestation{Block: anchorBlock},
-	})
+	// The genesis block is unsigned. A later anchor's envelope is stored by
+	// the caller when it has one; without it the anchor is not served.
+	if anchorBlock.Slot == 0 {
+		store.PutSignedBlock(anchorRoot, &types.SignedBlockWithAttestation{
+			Message: &types.BlockWithAttestation{Block: anchorBlock},
+		})
+	}
 	store.PutState(anchorRoot, state)
 
+	// A genesis anchor keeps the state's own checkpoints. Any later anchor is
+	// trusted as both justified and finalized, since the blocks its state
+	// points at are not available.
+	justified, finalized := state.LatestJustified, state.LatestFinalized
+	if anchorBlock.Slot > 0 {
+		anchor := &types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot}
+		justified, finalized = anchor, anchor
+	}
+	store.PutCheckpoints(&storage.Checkpoints{
+		Head:      anchorRoot,
+		Justified: justified,
+		Finalized: finalized,
+	})
+
+	// The genesis state names the genesis block by the zero root, which the
+	// state transition replaces with the real root when it processes the
+	// first block. Track the justified checkpoint under the real root from
+	// the start, so that the first head update does not adopt a source
+	// attestations cannot reference.
+	bestJustified := justified
+	if justified.Root == types.ZeroHash {
+		bestJustified = &types.Checkpoint{Root: anchorRoot, Slot: justified.Slot}
+	}
+
+	knownVotes := NewProtoArray()
+	knownVotes.OnBlock(anchorRoot, anchorBlock.ParentRoot, anchorBlock.Slot)
+	newVotes := NewProtoArray()
+	newVotes.OnBlock(anchorRoot, anchorBlock.ParentRoot, anchorBlock.Slot)
+
 	return &Store{
 		Time:                    anchorBlock.Slot * types.SecondsPerSlot,
 		GenesisTime:             state.Config.GenesisTime,
 		NumValidators:           uint64(len(state.Validators)),
 		Head:                    anchorRoot,
 		SafeTarget:              anchorRoot,
-		LatestJustified:         state.LatestJustified,
-		LatestFinalized:         state.LatestFinalized,
+		LatestJustified:         justified,
+		LatestFinalized:         finalized,
 		Storage:                 store,
+		bestJustified:           bestJustified,
 		LatestKnownAttestations: make(map[uint64]*types.SignedAttestation),
 		LatestNewAttestations:   make(map[uint64]*types.SignedAttestation),
+		knownVotes:              knownVotes,
+		newVotes:                newVotes,
+		PendingAttestationSlots: DefaultPendingAttestationSlots,
+		Verifier:                XMSSVerifier{},
+		pendingAttestations:     newPendingAttestations(pendingAttestationsLimit),
+		equivocations:           newEquivocations(),
+		events:                  newEventFeed(),
+		log:                     logging.NewComponentLogger(logging.CompForkChoice),
+	}
+}
+
+// NewStoreFromStorage resumes a store from the checkpoints and head state
+// previously persisted in storage. Attestations seen before the restart are
+// not persisted and are rebuilt from gossip and incoming blocks.
+func NewStoreFromStorage(store storage.Store) (*Store, error) {
+	cp, ok := store.GetCheckpoints()
+	if !ok {
+		return nil, fmt.Errorf("no persisted checkpoints")
+	}
+	headBlock, ok := store.GetBlock(cp.Head)
+	if !ok {
+		return nil, fmt.Errorf("head block %x not found", cp.Head)
+	}
+	headState, ok := store.GetState(cp.Head)
+	if !ok {
+		return nil, fmt.Errorf("head state %x not found", cp.Head)
+	}
+
+	blocks := store.GetAllBlocks()
+	// As in NewStore, the zero root of the genesis state stands for the
+	// genesis block.
+	bestJustified := GetLatestJustified(store)
+	if bestJustified.Root == types.ZeroHash {
+		for root, block := range blocks {
+			if block.Slot == 0 {
+				bestJustified = &types.Checkpoint{Root: root, Slot: bestJustified.Slot}
+			}
+		}
+	}
+
+	return &Store{
+		Time:                    headBlock.Slot * types.SecondsPerSlot,
+		GenesisTime:             headState.Config.GenesisTime,
+		NumValidators:           uint64(len(headState.Validators)),
+		Head:                    cp.Head,
+		SafeTarget:              cp.Head,
+		LatestJustified:         cp.Justified,
+		LatestFinalized:         cp.Finalized,
+		Storage:                 store,
+		bestJustified:           bestJustified,
+		LatestKnownAttestations: make(map[uint64]*types.SignedAttestation),
+		LatestNewAttestations:   make(map[uint64]*types.SignedAttestation),
+		knownVotes:              newProtoArrayFromBlocks(blocks),
+		newVotes:                newProtoArrayFromBlocks(blocks),
+		PendingAttestationSlots: DefaultPendingAttestationSlots,
+		Verifier:                XMSSVerifier{},
+		pendingAttestations:     newPendingAttestations(pendingAttestationsLimit),
+		equivocations:           newEquivocations(),
+		events:                  newEventFeed(),
+		log:                     logging.NewComponentLogger(logging.CompForkChoice),
+	}, nil
+}
+
+// putBlockLocked stores a block with its envelope and post-state and inserts
+// it into the fork choice trees.
+func (c *Store) putBlockLocked(root [32]byte, envelope *types.SignedBlockWithAttestation, state *types.State) {
+	block := envelope.Message.Block
+	c.checkProposerEquivocationLocked(root, block)
+	c.Storage.PutBlock(root, block)
+	c.Storage.PutSignedBlock(root, envelope)
+	c.Storage.PutState(root, state)
+	if c.bestJustified == nil || state.LatestJustified.Slot > c.bestJustified.Slot {
+		c.bestJustified = state.LatestJustified
+	}
+	c.knownVotes.OnBlock(root, block.ParentRoot, block.Slot)
+	c.newVotes.OnBlock(root, block.ParentRoot, block.Slot)
+
+	c.events.publish(TopicBlock, BlockEvent{
+		Slot:          block.Slot,
+		Root:          root,
+		ParentRoot:    block.ParentRoot,
+		ProposerIndex: block.ProposerIndex,
+	})
+}
+
+// Checkpoints returns the current head root and the latest justified and
+// finalized checkpoints under the store lock.
+func (c *Store) Checkpoints() (head [32]byte, justified, finalized *types.Checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Head, c.LatestJustified, c.LatestFinalized
+}
+
+// ForkChoiceNodes returns the head-selection tree with current vote weights.
+func (c *Store) ForkChoiceNodes() []ForkChoiceNode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.knownVotes.Nodes()
+}
+
+// HeadSlot returns the slot of the current head block.
+func (c *Store) HeadSlot() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.Storage.GetBlock(c.Head); ok {
+		return b.Slot
 	}
+	return 0
 }
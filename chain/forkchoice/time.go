@@ -2,6 +2,7 @@ package forkchoice
 
 import (
 	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -36,6 +37,8 @@ func (c *Store) tickIntervalLocked(hasProposal bool) {
 
 	switch currentInterval {
 	case 0:
+		c.expirePendingAttestationsLocked()
+		c.pruneEquivocationsLocked()
 		if hasProposal {
 			c.acceptNewAttestationsLocked()
 		}
@@ -64,15 +67,50 @@ func (c *Store) acceptNewAttestationsLocked() {
 }
 
 func (c *Store) updateHeadLocked() {
-	if latest := GetLatestJustified(c.Storage); latest != nil {
+	oldHead := c.Head
+	oldJustified := *c.LatestJustified
+	oldFinalized := *c.LatestFinalized
+
+	if latest := c.bestJustified; latest != nil && c.isKnownCheckpointLocked(latest) {
 		c.LatestJustified = latest
 	}
 
-	c.Head = GetForkChoiceHead(c.Storage, c.LatestJustified.Root, c.LatestKnownAttestations, 0)
+	c.Head = c.knownVotes.Head(c.LatestJustified.Root, c.LatestKnownAttestations, 0)
 
-	if headState, ok := c.Storage.GetState(c.Head); ok {
+	if headState, ok := c.Storage.GetState(c.Head); ok && c.isKnownCheckpointLocked(headState.LatestFinalized) {
 		c.LatestFinalized = headState.LatestFinalized
 	}
+
+	if c.Head != oldHead {
+		c.onHeadChangeLocked(oldHead)
+	}
+	if *c.LatestJustified != oldJustified {
+		c.events.publish(TopicJustification, CheckpointEvent{Slot: c.LatestJustified.Slot, Root: c.LatestJustified.Root})
+	}
+	if *c.LatestFinalized != oldFinalized {
+		c.events.publish(TopicFinalization, CheckpointEvent{Slot: c.LatestFinalized.Slot, Root: c.LatestFinalized.Root})
+	}
+
+	if c.LatestFinalized.Slot > c.prunedSlot {
+		c.pruneLocked()
+	}
+
+	// Persist only on change; disk-backed stores fsync every write.
+	prev, ok := c.Storage.GetCheckpoints()
+	if !ok || prev.Head != c.Head || *prev.Justified != *c.LatestJustified || *prev.Finalized != *c.LatestFinalized {
+		c.Storage.PutCheckpoints(&storage.Checkpoints{
+			Head:      c.Head,
+			Justified: c.LatestJustified,
+			Finalized: c.LatestFinalized,
+		})
+	}
+}
+
+// isKnownCheckpointLocked reports whether cp points at a block in the fork
+// choice tree. The zero root stands for the earliest block. Checkpoints from
+// before a non-genesis anchor are unknown and must not replace it.
+func (c *Store) isKnownCheckpointLocked(cp *types.Checkpoint) bool {
+	return cp.Root == types.ZeroHash || c.knownVotes.Contains(cp.Root)
 }
 
 // UpdateSafeTarget finds the head with sufficient (2/3+) vote support.
@@ -84,7 +122,7 @@ func (c *Store) UpdateSafeTarget() {
 
 func (c *Store) updateSafeTargetLocked() {
 	minScore := int(ceilDiv(c.NumValidators*2, 3))
-	c.SafeTarget = GetForkChoiceHead(c.Storage, c.LatestJustified.Root, c.LatestNewAttestations, minScore)
+	c.SafeTarget = c.newVotes.Head(c.LatestJustified.Root, c.LatestNewAttestations, minScore)
 	if block, ok := c.Storage.GetBlock(c.SafeTarget); ok {
 		metrics.SafeTargetSlot.Set(float64(block.Slot))
 	}
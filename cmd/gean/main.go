@@ -11,7 +11,10 @@ import (
 	"strconv"
 	"syscall"
 
+	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/node"
 )
@@ -24,9 +27,26 @@ func main() {
 	validatorsPath := flag.String("validator-registry-path", "", "Path to validators.yaml")
 	nodeID := flag.String("node-id", "", "Node name (index into validators.yaml)")
 	nodeKey := flag.String("node-key", "", "Path to secp256k1 private key file")
+	targetPeers := flag.Int("target-peers", network.DefaultTargetPeers, "Number of peers the node tries to stay connected to")
+	maxPeers := flag.Int("max-peers", network.DefaultMaxPeers, "Maximum number of connected peers")
 	listenAddr := flag.String("listen-addr", "/ip4/0.0.0.0/udp/9000/quic-v1", "QUIC listen address")
 	metricsPort := flag.Int("metrics-port", 0, "Prometheus metrics port (0 = disabled)")
-	devnetID := flag.String("devnet-id", "devnet0", "Devnet identifier for gossip topics")
+	apiPort := flag.Int("api-port", 0, "HTTP API port (0 = disabled)")
+	devnetID := flag.String("devnet-id", "", "Deprecated: gossip topics are namespaced by the fork digest derived from genesis")
+	dataDir := flag.String("data-dir", "", "Directory for persistent chain data (empty = in-memory only)")
+	pruneBlocks := flag.Bool("prune-blocks", false, "Drop finalized canonical blocks (states are always pruned)")
+	checkpointURL := flag.String("checkpoint-sync-url", "", "HTTP API of a synced node to fetch the finalized anchor from")
+	checkpointState := flag.String("checkpoint-state", "", "Anchor state as an SSZ file path or URL (with --checkpoint-block)")
+	checkpointBlock := flag.String("checkpoint-block", "", "Anchor block as an SSZ file path or URL (with --checkpoint-state)")
+	checkpointRoot := flag.String("checkpoint-root", "", "Trusted 0x-prefixed anchor block root (optional)")
+	pendingAttSlots := flag.Uint64("pending-attestation-slots", forkchoice.DefaultPendingAttestationSlots, "Slots to hold attestations that reference unknown blocks")
+	statusDisparity := flag.Uint64("status-clock-disparity-slots", gossipsub.MaxClockDisparitySlots, "Slots a peer's head may be ahead of our clock before the peer is disconnected")
+	keyDir := flag.String("key-dir", "", "Directory with the validators' secret keys and signing state (see cmd/keygen)")
+	remoteSignerURL := flag.String("remote-signer-url", "", "URL of a remote signer holding the validators' keys (see cmd/remotesigner)")
+	remoteSignerTimeout := flag.Duration("remote-signer-timeout", 0, "Timeout of each remote signer request (default 400ms)")
+	slashingDir := flag.String("slashing-protection-dir", "", "Directory of the slashing protection database (default: slashing_protection under --data-dir)")
+	skipSigVerify := flag.Bool("skip-signature-verification", false, "Accept blocks and attestations without verifying signatures (tests and devnets only)")
+	gossipScoringPath := flag.String("gossip-scoring", "", "Path to a YAML file overriding gossipsub peer scoring for this devnet")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
 	flag.Parse()
 
@@ -53,7 +73,11 @@ func main() {
 	logger.Info("genesis config loaded",
 		"genesis_time", genCfg.GenesisTime,
 		"validators", len(genCfg.Validators),
+		"scheduled_forks", len(genCfg.Forks),
 	)
+	if *devnetID != "" {
+		logger.Warn("--devnet-id is deprecated and ignored; gossip topics are namespaced by the fork digest")
+	}
 
 	// Load bootnodes.
 	var bootnodes []string
@@ -90,15 +114,44 @@ func main() {
 		}
 	}
 
+	// Load peer scoring overrides.
+	var gossipScoring *gossipsub.ScoringConfig
+	if *gossipScoringPath != "" {
+		gossipScoring, err = config.LoadScoringConfig(*gossipScoringPath, len(genCfg.Validators))
+		if err != nil {
+			logger.Error("failed to load gossip scoring config", "err", err)
+			os.Exit(1)
+		}
+	}
+
 	nodeCfg := node.Config{
-		GenesisTime:  genCfg.GenesisTime,
-		Validators:   genCfg.Validators,
-		ListenAddr:   *listenAddr,
-		NodeKeyPath:  *nodeKey,
-		Bootnodes:    bootnodes,
-		ValidatorIDs: validatorIDs,
-		MetricsPort:  *metricsPort,
-		DevnetID:     *devnetID,
+		GenesisTime:               genCfg.GenesisTime,
+		Validators:                genCfg.Validators,
+		Forks:                     genCfg.Forks,
+		ListenAddr:                *listenAddr,
+		NodeKeyPath:               *nodeKey,
+		Bootnodes:                 bootnodes,
+		TargetPeers:               *targetPeers,
+		MaxPeers:                  *maxPeers,
+		ValidatorIDs:              validatorIDs,
+		MetricsPort:               *metricsPort,
+		APIPort:                   *apiPort,
+		DataDir:                   *dataDir,
+		PruneBlocks:               *pruneBlocks,
+		PendingAttestationSlots:   *pendingAttSlots,
+		StatusClockDisparitySlots: *statusDisparity,
+		CheckpointSync: node.CheckpointSource{
+			URL:         *checkpointURL,
+			State:       *checkpointState,
+			Block:       *checkpointBlock,
+			TrustedRoot: *checkpointRoot,
+		},
+		GossipScoring:             gossipScoring,
+		SkipSignatureVerification: *skipSigVerify,
+		KeyDir:                    *keyDir,
+		RemoteSignerURL:           *remoteSignerURL,
+		RemoteSignerTimeout:       *remoteSignerTimeout,
+		SlashingProtectionDir:     *slashingDir,
 	}
 
 	n, err := node.New(nodeCfg)
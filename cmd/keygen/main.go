@@ -0,0 +1,48 @@
+// Command keygen creates validator keys for a devnet. It writes the secret
+// key and a fresh signing state of each validator to a key directory and
+// prints the public keys in the GENESIS_VALIDATORS format of config.yaml.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/geanlabs/gean/keymanager"
+	"github.com/geanlabs/gean/xmss"
+)
+
+func main() {
+	outDir := flag.String("out", "", "Key directory to create keys in")
+	count := flag.Uint64("validators", 0, "Number of validators, indexed from 0")
+	firstEpoch := flag.Uint64("first-epoch", 0, "First epoch (slot) the keys can sign; a multiple of --epochs")
+	epochs := flag.Uint64("epochs", 1<<16, "Number of epochs (slots) each key can sign; a power of two")
+	flag.Parse()
+
+	if *outDir == "" || *count == 0 {
+		fmt.Fprintln(os.Stderr, "--out and --validators are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := os.MkdirAll(*outDir, 0o700); err != nil {
+		fmt.Fprintln(os.Stderr, "create key directory:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("GENESIS_VALIDATORS:")
+	for idx := uint64(0); idx < *count; idx++ {
+		key, err := xmss.GenerateKey(rand.Reader, *firstEpoch, *epochs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "validator %d: %v\n", idx, err)
+			os.Exit(1)
+		}
+		if err := keymanager.Create(*outDir, keymanager.XMSS{}, idx, key); err != nil {
+			fmt.Fprintf(os.Stderr, "validator %d: %v\n", idx, err)
+			os.Exit(1)
+		}
+		pk := key.PublicKey()
+		fmt.Printf("  - \"0x%s\"\n", hex.EncodeToString(pk[:]))
+	}
+}
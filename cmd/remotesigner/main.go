@@ -0,0 +1,83 @@
+// Command remotesigner is a reference remote signer for devnets and tests.
+// It serves the keys of a key directory created by cmd/keygen to nodes
+// started with --remote-signer-url.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/keymanager"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/signer"
+)
+
+func main() {
+	genesisPath := flag.String("genesis", "", "Path to config.yaml")
+	keyDir := flag.String("key-dir", "", "Directory with the validators' secret keys and signing state")
+	listenAddr := flag.String("listen-addr", "127.0.0.1:9010", "HTTP listen address")
+	flag.Parse()
+
+	logging.Init(slog.LevelInfo)
+	log.SetOutput(io.Discard)
+	logger := logging.NewComponentLogger(logging.CompValidator)
+
+	if *genesisPath == "" || *keyDir == "" {
+		logger.Error("--genesis and --key-dir flags are required")
+		os.Exit(1)
+	}
+	genCfg, err := config.LoadGenesisConfig(*genesisPath)
+	if err != nil {
+		logger.Error("failed to load genesis config", "err", err)
+		os.Exit(1)
+	}
+
+	// Serve every genesis validator with a key in the directory.
+	var indices []uint64
+	for idx := range genCfg.Validators {
+		if _, err := os.Stat(keymanager.KeyPath(*keyDir, uint64(idx))); err == nil {
+			indices = append(indices, uint64(idx))
+		}
+	}
+	keys, err := keymanager.Load(*keyDir, keymanager.XMSS{}, indices)
+	if err != nil {
+		logger.Error("failed to load keys", "err", err)
+		os.Exit(1)
+	}
+	for _, idx := range indices {
+		if pk, _ := keys.PublicKey(idx); pk != genCfg.Validators[idx].Pubkey {
+			logger.Error("key does not match genesis pubkey", "validator", idx)
+			os.Exit(1)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           signer.NewServer(signer.Local{Keys: keys}, genCfg.Validators, indices).Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("server error", "err", err)
+			os.Exit(1)
+		}
+	}()
+	logger.Info("remote signer listening", "addr", *listenAddr, "validators", indices)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+}
@@ -0,0 +1,77 @@
+// Command slashprotect moves a slashing protection database between
+// machines. It exports the database to interchange JSON, or imports
+// interchange JSON into it, while the node using the database is stopped.
+//
+//	slashprotect --genesis config.yaml --dir DIR export FILE
+//	slashprotect --genesis config.yaml --dir DIR import FILE
+//
+// FILE "-" is standard output or input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/slashing"
+	"github.com/geanlabs/gean/types"
+)
+
+func main() {
+	genesisPath := flag.String("genesis", "", "Path to config.yaml of the chain the validators sign on")
+	dir := flag.String("dir", "", "Slashing protection directory (slashing_protection under the node's --data-dir)")
+	flag.Parse()
+
+	if *genesisPath == "" || *dir == "" || flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: slashprotect --genesis config.yaml --dir DIR (import|export) FILE")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if err := run(*genesisPath, *dir, flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(genesisPath, dir, command, path string) error {
+	genCfg, err := config.LoadGenesisConfig(genesisPath)
+	if err != nil {
+		return err
+	}
+	genesisRoot := types.ComputeGenesisRoot(genCfg.GenesisTime, genCfg.Validators)
+	db, err := slashing.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "export":
+		if path == "-" {
+			return db.Export(os.Stdout, genesisRoot)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := db.Export(f, genesisRoot); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case "import":
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		return db.Import(r, genesisRoot)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
@@ -14,12 +14,22 @@ import (
 type GenesisConfig struct {
 	GenesisTime uint64               `yaml:"GENESIS_TIME"`
 	Validators  []*types.Validator   // populated from GENESIS_VALIDATORS
+	// Forks are the forks scheduled after genesis, from FORK_SCHEDULE.
+	Forks []types.Fork
 }
 
 // rawGenesisConfig is the on-disk YAML shape.
 type rawGenesisConfig struct {
-	GenesisTime       uint64   `yaml:"GENESIS_TIME"`
-	GenesisValidators []string `yaml:"GENESIS_VALIDATORS"`
+	GenesisTime       uint64    `yaml:"GENESIS_TIME"`
+	GenesisValidators []string  `yaml:"GENESIS_VALIDATORS"`
+	ForkSchedule      []rawFork `yaml:"FORK_SCHEDULE"`
+}
+
+// rawFork is a FORK_SCHEDULE entry: a 4-byte hex version and the slot it
+// activates at.
+type rawFork struct {
+	Version string `yaml:"VERSION"`
+	Slot    uint64 `yaml:"SLOT"`
 }
 
 // LoadGenesisConfig loads and parses a genesis config YAML file.
@@ -53,8 +63,22 @@ func LoadGenesisConfig(path string) (*GenesisConfig, error) {
 		validators[i] = &types.Validator{Pubkey: pubkey, Index: uint64(i)}
 	}
 
+	forks := make([]types.Fork, len(raw.ForkSchedule))
+	for i, f := range raw.ForkSchedule {
+		version, err := hex.DecodeString(strings.TrimPrefix(f.Version, "0x"))
+		if err != nil || len(version) != 4 {
+			return nil, fmt.Errorf("FORK_SCHEDULE[%d]: version %q is not 4 hex bytes", i, f.Version)
+		}
+		copy(forks[i].Version[:], version)
+		forks[i].Slot = f.Slot
+	}
+	if _, err := types.NewForkSchedule(raw.GenesisTime, validators, forks); err != nil {
+		return nil, fmt.Errorf("FORK_SCHEDULE: %w", err)
+	}
+
 	return &GenesisConfig{
 		GenesisTime: raw.GenesisTime,
 		Validators:  validators,
+		Forks:       forks,
 	}, nil
 }
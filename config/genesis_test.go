@@ -107,3 +107,32 @@ func writeTempYAML(t *testing.T, content string) string {
 	}
 	return path
 }
+
+func TestLoadGenesisConfigForkSchedule(t *testing.T) {
+	const validator = `  - "0xe2a03c16122c7e0f940e2301aa460c54a2e1e8343968bb2782f26636f051e65ec589c858b9c7980b276ebe550056b23f0bdc3b5a"
+`
+	path := writeTempYAML(t, `
+GENESIS_TIME: 1000
+GENESIS_VALIDATORS:
+`+validator+`FORK_SCHEDULE:
+  - VERSION: "0x01000000"
+    SLOT: 64
+`)
+	cfg, err := LoadGenesisConfig(path)
+	if err != nil {
+		t.Fatalf("LoadGenesisConfig: %v", err)
+	}
+	if len(cfg.Forks) != 1 || cfg.Forks[0].Slot != 64 || cfg.Forks[0].Version[0] != 0x01 {
+		t.Fatalf("Forks = %+v, want version 01000000 at slot 64", cfg.Forks)
+	}
+
+	for _, schedule := range []string{
+		"  - VERSION: \"0x0100\"\n    SLOT: 64\n",
+		"  - VERSION: \"0x01000000\"\n    SLOT: 0\n",
+	} {
+		path := writeTempYAML(t, "GENESIS_TIME: 1000\nGENESIS_VALIDATORS:\n"+validator+"FORK_SCHEDULE:\n"+schedule)
+		if _, err := LoadGenesisConfig(path); err == nil {
+			t.Fatalf("LoadGenesisConfig accepted schedule %q", schedule)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/geanlabs/gean/network/gossipsub"
+)
+
+// LoadScoringConfig loads gossipsub peer scoring overrides for a devnet.
+// Fields missing from the file keep the defaults for numValidators.
+func LoadScoringConfig(path string, numValidators int) (*gossipsub.ScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scoring config: %w", err)
+	}
+
+	cfg := gossipsub.DefaultScoringConfig(numValidators)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse scoring config: %w", err)
+	}
+
+	if cfg.GossipThreshold >= 0 || cfg.PublishThreshold > cfg.GossipThreshold || cfg.GraylistThreshold > cfg.PublishThreshold {
+		return nil, fmt.Errorf("scoring thresholds must satisfy graylist <= publish <= gossip < 0")
+	}
+	return &cfg, nil
+}
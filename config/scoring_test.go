@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadScoringConfigOverridesDefaults(t *testing.T) {
+	path := writeTempYAML(t, `
+ip_colocation_threshold: 64
+graylist_threshold: -20000
+`)
+	cfg, err := LoadScoringConfig(path, 12)
+	if err != nil {
+		t.Fatalf("LoadScoringConfig: %v", err)
+	}
+	if cfg.IPColocationThreshold != 64 {
+		t.Fatalf("IPColocationThreshold = %d, want 64", cfg.IPColocationThreshold)
+	}
+	if cfg.GraylistThreshold != -20000 {
+		t.Fatalf("GraylistThreshold = %v, want -20000", cfg.GraylistThreshold)
+	}
+	if cfg.GossipThreshold != -4000 {
+		t.Fatalf("GossipThreshold = %v, want default -4000", cfg.GossipThreshold)
+	}
+	if cfg.ExpectedAttestationsPerSlot != 12 {
+		t.Fatalf("ExpectedAttestationsPerSlot = %v, want 12", cfg.ExpectedAttestationsPerSlot)
+	}
+}
+
+func TestLoadScoringConfigRejectsBadInput(t *testing.T) {
+	if _, err := LoadScoringConfig(writeTempYAML(t, "graylist_treshold: -1\n"), 4); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	_, err := LoadScoringConfig(writeTempYAML(t, "publish_threshold: -100\n"), 4)
+	if err == nil || !strings.Contains(err.Error(), "thresholds") {
+		t.Fatalf("err = %v, want threshold ordering error", err)
+	}
+}
@@ -0,0 +1,288 @@
+// Package keymanager holds the secret keys of a node's validators and
+// makes sure no one-time key is ever used twice.
+//
+// A key directory holds, per validator index N, the secret key in
+// validator_N.key and its signing state in validator_N.state. The state
+// records the lowest epoch the key has not signed yet. Before a signature
+// is released the state is advanced and synced to disk, so a crash can
+// lose an epoch but never reuse one. The manager refuses to sign when the
+// state file is missing or has gone backwards since it was last written,
+// for instance because the key directory was restored from a backup.
+package keymanager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// DefaultWarnRemaining is how many signatures a key has left when the
+// manager starts warning about its exhaustion: one hour of slots.
+const DefaultWarnRemaining = 900
+
+var (
+	ErrUnknownValidator = errors.New("no key for validator")
+	ErrStateMissing     = errors.New("key state file missing")
+	ErrStateRolledBack  = errors.New("key state rolled back")
+	ErrEpochUsed        = errors.New("epoch already signed")
+	ErrKeyExhausted     = errors.New("key exhausted")
+)
+
+// Manager signs on behalf of a node's validators.
+type Manager struct {
+	// WarnRemaining is the remaining signature count below which each
+	// signature logs a warning.
+	WarnRemaining uint64
+
+	dir  string
+	keys map[uint64]*validatorKey
+	log  *slog.Logger
+}
+
+type validatorKey struct {
+	mu     sync.Mutex
+	index  uint64
+	key    SecretKey
+	scheme string
+	// next is the lowest epoch not yet signed, as last written to the
+	// state file.
+	next uint64
+}
+
+// state is the content of a key state file.
+type state struct {
+	Scheme    string `json:"scheme"`
+	Pubkey    string `json:"pubkey"`
+	NextEpoch uint64 `json:"next_epoch"`
+}
+
+// KeyPath returns the path of the secret key of validator index in dir.
+func KeyPath(dir string, index uint64) string {
+	return filepath.Join(dir, "validator_"+strconv.FormatUint(index, 10)+".key")
+}
+
+// StatePath returns the path of the signing state of validator index in dir.
+func StatePath(dir string, index uint64) string {
+	return filepath.Join(dir, "validator_"+strconv.FormatUint(index, 10)+".state")
+}
+
+// Create writes a new key for validator index to dir along with a fresh
+// state file. It refuses to overwrite an existing key or state.
+func Create(dir string, scheme Scheme, index uint64, key SecretKey) error {
+	keyData, err := key.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encode key: %w", err)
+	}
+	if _, err := os.Stat(StatePath(dir, index)); err == nil {
+		return fmt.Errorf("state file for validator %d already exists", index)
+	}
+	f, err := os.OpenFile(KeyPath(dir, index), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(keyData); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	first, _ := key.Epochs()
+	return writeState(dir, index, scheme.Name(), key.PublicKey(), first)
+}
+
+// Load reads the keys of indices from dir. Every key must have a state
+// file written for the same public key.
+func Load(dir string, scheme Scheme, indices []uint64) (*Manager, error) {
+	m := &Manager{
+		WarnRemaining: DefaultWarnRemaining,
+		dir:           dir,
+		keys:          make(map[uint64]*validatorKey, len(indices)),
+		log:           logging.NewComponentLogger(logging.CompValidator),
+	}
+	for _, idx := range indices {
+		data, err := os.ReadFile(KeyPath(dir, idx))
+		if err != nil {
+			return nil, fmt.Errorf("validator %d: %w", idx, err)
+		}
+		key, err := scheme.ParseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d: parse key: %w", idx, err)
+		}
+		vk := &validatorKey{index: idx, key: key, scheme: scheme.Name()}
+		next, err := m.readState(vk)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d: %w", idx, err)
+		}
+		vk.next = next
+		m.keys[idx] = vk
+		m.updateRemaining(vk)
+		first, end := key.Epochs()
+		m.log.Info("validator key loaded",
+			"validator", idx,
+			"scheme", vk.scheme,
+			"first_epoch", first,
+			"end_epoch", end,
+			"next_epoch", next,
+		)
+		if remaining := vk.remaining(); remaining <= m.WarnRemaining {
+			m.log.Warn("validator key close to exhaustion",
+				"validator", idx,
+				"remaining_signatures", remaining,
+			)
+		}
+	}
+	return m, nil
+}
+
+// PublicKey returns the public key of validator index.
+func (m *Manager) PublicKey(index uint64) ([52]byte, bool) {
+	vk, ok := m.keys[index]
+	if !ok {
+		return [52]byte{}, false
+	}
+	return vk.key.PublicKey(), true
+}
+
+// Remaining returns how many more epochs validator index can sign.
+func (m *Manager) Remaining(index uint64) uint64 {
+	vk, ok := m.keys[index]
+	if !ok {
+		return 0
+	}
+	vk.mu.Lock()
+	defer vk.mu.Unlock()
+	return vk.remaining()
+}
+
+// Sign signs msg for validator index in epoch. Epochs must increase from
+// one signature to the next; the new state is on disk before the
+// signature is returned.
+func (m *Manager) Sign(index, epoch uint64, msg [32]byte) ([3116]byte, error) {
+	var sig [3116]byte
+	vk, ok := m.keys[index]
+	if !ok {
+		return sig, fmt.Errorf("%w %d", ErrUnknownValidator, index)
+	}
+	vk.mu.Lock()
+	defer vk.mu.Unlock()
+
+	// The state must still be what we last wrote.
+	onDisk, err := m.readState(vk)
+	if err != nil {
+		return sig, err
+	}
+	if onDisk < vk.next {
+		return sig, fmt.Errorf("%w: next epoch is %d on disk, %d in memory", ErrStateRolledBack, onDisk, vk.next)
+	}
+	vk.next = onDisk
+
+	if epoch < vk.next {
+		return sig, fmt.Errorf("%w: epoch %d, next unused is %d", ErrEpochUsed, epoch, vk.next)
+	}
+	if _, end := vk.key.Epochs(); epoch >= end {
+		return sig, fmt.Errorf("%w: epoch %d, key ends at %d", ErrKeyExhausted, epoch, end)
+	}
+	if err := writeState(m.dir, vk.index, vk.scheme, vk.key.PublicKey(), epoch+1); err != nil {
+		return sig, fmt.Errorf("persist key state: %w", err)
+	}
+	vk.next = epoch + 1
+	m.updateRemaining(vk)
+
+	sig, err = vk.key.Sign(epoch, msg)
+	if err != nil {
+		return sig, err
+	}
+	if remaining := vk.remaining(); remaining <= m.WarnRemaining {
+		m.log.Warn("validator key close to exhaustion",
+			"validator", vk.index,
+			"remaining_signatures", remaining,
+		)
+	}
+	return sig, nil
+}
+
+func (vk *validatorKey) remaining() uint64 {
+	_, end := vk.key.Epochs()
+	if vk.next >= end {
+		return 0
+	}
+	return end - vk.next
+}
+
+func (m *Manager) updateRemaining(vk *validatorKey) {
+	metrics.ValidatorSignaturesRemaining.WithLabelValues(strconv.FormatUint(vk.index, 10)).Set(float64(vk.remaining()))
+}
+
+// readState returns the next unused epoch recorded for vk.
+func (m *Manager) readState(vk *validatorKey) (uint64, error) {
+	data, err := os.ReadFile(StatePath(m.dir, vk.index))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("%w for validator %d", ErrStateMissing, vk.index)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return 0, fmt.Errorf("decode key state: %w", err)
+	}
+	pk := vk.key.PublicKey()
+	if st.Scheme != vk.scheme || st.Pubkey != "0x"+hex.EncodeToString(pk[:]) {
+		return 0, fmt.Errorf("key state of validator %d belongs to another key", vk.index)
+	}
+	return st.NextEpoch, nil
+}
+
+// writeState atomically replaces the state file of validator index and
+// syncs it to disk.
+func writeState(dir string, index uint64, scheme string, pubkey [52]byte, next uint64) error {
+	data, err := json.Marshal(state{
+		Scheme:    scheme,
+		Pubkey:    "0x" + hex.EncodeToString(pubkey[:]),
+		NextEpoch: next,
+	})
+	if err != nil {
+		return err
+	}
+	path := StatePath(dir, index)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
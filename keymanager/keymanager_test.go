@@ -0,0 +1,142 @@
+package keymanager
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/geanlabs/gean/xmss"
+)
+
+// newKeyDir creates a key directory holding keys for validators 0 and 1,
+// each able to sign epochs 0 through 7.
+func newKeyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for idx := uint64(0); idx < 2; idx++ {
+		key, err := xmss.NewKey([xmss.SeedSize]byte{byte(idx)}, 0, 8)
+		if err != nil {
+			t.Fatalf("NewKey: %v", err)
+		}
+		if err := Create(dir, XMSS{}, idx, key); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	return dir
+}
+
+func load(t *testing.T, dir string, indices ...uint64) *Manager {
+	t.Helper()
+	m, err := Load(dir, XMSS{}, indices)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return m
+}
+
+func TestSignAdvancesState(t *testing.T) {
+	dir := newKeyDir(t)
+	m := load(t, dir, 0, 1)
+	msg := [32]byte{7}
+
+	sig, err := m.Sign(0, 2, msg)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	pk, _ := m.PublicKey(0)
+	if err := xmss.Verify(pk, 2, msg, &sig); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got := m.Remaining(0); got != 5 {
+		t.Fatalf("remaining = %d, want 5", got)
+	}
+	if got := m.Remaining(1); got != 8 {
+		t.Fatalf("validator 1 remaining = %d, want 8", got)
+	}
+
+	for _, epoch := range []uint64{1, 2} {
+		if _, err := m.Sign(0, epoch, [32]byte{8}); !errors.Is(err, ErrEpochUsed) {
+			t.Fatalf("Sign(epoch %d) err = %v, want %v", epoch, err, ErrEpochUsed)
+		}
+	}
+
+	// A restarted manager picks up the persisted state.
+	m = load(t, dir, 0)
+	if _, err := m.Sign(0, 2, msg); !errors.Is(err, ErrEpochUsed) {
+		t.Fatalf("Sign after reload err = %v, want %v", err, ErrEpochUsed)
+	}
+	if _, err := m.Sign(0, 3, msg); err != nil {
+		t.Fatalf("Sign(3) after reload: %v", err)
+	}
+	if _, err := m.Sign(5, 3, msg); !errors.Is(err, ErrUnknownValidator) {
+		t.Fatalf("unknown validator err = %v, want %v", err, ErrUnknownValidator)
+	}
+}
+
+func TestRefusesMissingState(t *testing.T) {
+	dir := newKeyDir(t)
+	m := load(t, dir, 0)
+	if err := os.Remove(StatePath(dir, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Sign(0, 1, [32]byte{}); !errors.Is(err, ErrStateMissing) {
+		t.Fatalf("Sign err = %v, want %v", err, ErrStateMissing)
+	}
+	if _, err := Load(dir, XMSS{}, []uint64{0}); !errors.Is(err, ErrStateMissing) {
+		t.Fatalf("Load err = %v, want %v", err, ErrStateMissing)
+	}
+}
+
+func TestRefusesRolledBackState(t *testing.T) {
+	dir := newKeyDir(t)
+	m := load(t, dir, 0)
+	backup, err := os.ReadFile(StatePath(dir, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Sign(0, 3, [32]byte{}); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := os.WriteFile(StatePath(dir, 0), backup, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Sign(0, 5, [32]byte{}); !errors.Is(err, ErrStateRolledBack) {
+		t.Fatalf("Sign err = %v, want %v", err, ErrStateRolledBack)
+	}
+}
+
+func TestRejectsForeignState(t *testing.T) {
+	dir := newKeyDir(t)
+	other, err := os.ReadFile(StatePath(dir, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(StatePath(dir, 0), other, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir, XMSS{}, []uint64{0}); err == nil {
+		t.Fatal("expected state of another key to be rejected")
+	}
+}
+
+func TestKeyExhaustion(t *testing.T) {
+	dir := newKeyDir(t)
+	m := load(t, dir, 0)
+	if _, err := m.Sign(0, 7, [32]byte{}); err != nil {
+		t.Fatalf("Sign(last epoch): %v", err)
+	}
+	if got := m.Remaining(0); got != 0 {
+		t.Fatalf("remaining = %d, want 0", got)
+	}
+	if _, err := m.Sign(0, 8, [32]byte{}); !errors.Is(err, ErrKeyExhausted) {
+		t.Fatalf("Sign err = %v, want %v", err, ErrKeyExhausted)
+	}
+
+	key, err := xmss.NewKey([xmss.SeedSize]byte{9}, 0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Create(dir, XMSS{}, 0, key); err == nil {
+		t.Fatal("Create overwrote an existing key")
+	}
+}
@@ -0,0 +1,32 @@
+package keymanager
+
+import (
+	"github.com/geanlabs/gean/xmss"
+)
+
+// Scheme is a stateful signature scheme whose keys sign each epoch at most
+// once.
+type Scheme interface {
+	// Name identifies the scheme in key state files.
+	Name() string
+	// ParseKey decodes a secret key file.
+	ParseKey(data []byte) (SecretKey, error)
+}
+
+// SecretKey signs 32-byte messages in epochs [first, end). MarshalBinary
+// gives the key file content ParseKey reads back.
+type SecretKey interface {
+	MarshalBinary() ([]byte, error)
+	PublicKey() [52]byte
+	Epochs() (first, end uint64)
+	Sign(epoch uint64, msg [32]byte) ([3116]byte, error)
+}
+
+// XMSS is the hash-based scheme of the xmss package.
+type XMSS struct{}
+
+func (XMSS) Name() string { return "xmss" }
+
+func (XMSS) ParseKey(data []byte) (SecretKey, error) {
+	return xmss.ParseKey(data)
+}
@@ -0,0 +1,119 @@
+package gossipsub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// ForkTransitionSlots is how many slots before a fork its topics are
+// joined, and how many slots after it the previous fork's topics are left.
+const ForkTransitionSlots = 4
+
+// ForkTopics keeps the node on the gossip topics of the active fork. The
+// next fork's topics are joined shortly before it activates so no message
+// is missed at the boundary, and the previous fork's are left shortly
+// after.
+type ForkTopics struct {
+	ps      *pubsub.PubSub
+	forks   *types.ForkSchedule
+	scoring *ScoringConfig
+
+	ctx       context.Context
+	validator *MessageValidator
+	handler   *GossipHandler
+
+	mu      sync.Mutex
+	joined  map[types.ForkDigest]*Topics
+	current *Topics
+
+	log *slog.Logger
+}
+
+// NewForkTopics creates the topic set of forks. Topics are joined by
+// Start; scoring may be nil.
+func NewForkTopics(ps *pubsub.PubSub, forks *types.ForkSchedule, scoring *ScoringConfig) *ForkTopics {
+	return &ForkTopics{
+		ps:      ps,
+		forks:   forks,
+		scoring: scoring,
+		joined:  make(map[types.ForkDigest]*Topics),
+		log:     logging.NewComponentLogger(logging.CompGossip),
+	}
+}
+
+// Start joins the topics active at slot, validating messages with
+// validator and dispatching them to handler until ctx is done.
+func (f *ForkTopics) Start(ctx context.Context, slot uint64, validator *MessageValidator, handler *GossipHandler) error {
+	f.mu.Lock()
+	f.ctx, f.validator, f.handler = ctx, validator, handler
+	f.mu.Unlock()
+	return f.Update(slot)
+}
+
+// Update joins and leaves topics for slot. It is called every slot.
+func (f *ForkTopics) Update(slot uint64) error {
+	want := map[types.ForkDigest]bool{f.forks.DigestAt(slot): true}
+	if next, ok := f.forks.NextFork(slot); ok && next.Slot <= slot+ForkTransitionSlots {
+		want[f.forks.DigestAt(next.Slot)] = true
+	}
+	if slot >= ForkTransitionSlots {
+		want[f.forks.DigestAt(slot-ForkTransitionSlots)] = true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for digest := range want {
+		if _, ok := f.joined[digest]; ok {
+			continue
+		}
+		topics, err := f.join(digest)
+		if err != nil {
+			return fmt.Errorf("join topics of fork digest %s: %w", digest, err)
+		}
+		f.joined[digest] = topics
+		f.log.Info("gossip topics joined", "fork_digest", digest.String())
+	}
+	for digest, topics := range f.joined {
+		if want[digest] {
+			continue
+		}
+		if err := topics.Leave(f.ps); err != nil {
+			f.log.Warn("failed to leave gossip topics", "fork_digest", digest.String(), "err", err)
+		}
+		delete(f.joined, digest)
+		f.log.Info("gossip topics left", "fork_digest", digest.String())
+	}
+	f.current = f.joined[f.forks.DigestAt(slot)]
+	return nil
+}
+
+// Current returns the topics of the active fork, which messages are
+// published on.
+func (f *ForkTopics) Current() *Topics {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current
+}
+
+func (f *ForkTopics) join(digest types.ForkDigest) (*Topics, error) {
+	topics, err := JoinTopics(f.ps, digest, f.scoring)
+	if err != nil {
+		return nil, err
+	}
+	if err := RegisterValidators(f.ps, topics, f.validator); err != nil {
+		topics.Leave(f.ps)
+		return nil, err
+	}
+	if err := SubscribeTopics(f.ctx, topics, f.handler); err != nil {
+		topics.Leave(f.ps)
+		return nil, fmt.Errorf("subscribe topics: %w", err)
+	}
+	return topics, nil
+}
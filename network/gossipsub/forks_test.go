@@ -0,0 +1,63 @@
+package gossipsub
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+
+	"github.com/geanlabs/gean/types"
+)
+
+func TestForkTopicsRotate(t *testing.T) {
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	if err != nil {
+		t.Fatalf("new host: %v", err)
+	}
+	defer h.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ps, _, err := NewGossipSub(ctx, h, nil)
+	if err != nil {
+		t.Fatalf("new gossipsub: %v", err)
+	}
+
+	forks, err := types.NewForkSchedule(1000, nil, []types.Fork{{Version: types.ForkVersion{1}, Slot: 20}})
+	if err != nil {
+		t.Fatalf("fork schedule: %v", err)
+	}
+	oldDigest, newDigest := forks.DigestAt(0), forks.DigestAt(20)
+	block := func(d types.ForkDigest) string { return fmt.Sprintf(BlockTopicFmt, d) }
+
+	ft := NewForkTopics(ps, forks, nil)
+	validator, _ := newTestValidator()
+	if err := ft.Start(ctx, 10, validator, &GossipHandler{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	steps := []struct {
+		slot    uint64
+		current types.ForkDigest
+		joined  []string
+	}{
+		{15, oldDigest, []string{block(oldDigest)}},
+		{16, oldDigest, []string{block(oldDigest), block(newDigest)}},
+		{20, newDigest, []string{block(oldDigest), block(newDigest)}},
+		{24, newDigest, []string{block(newDigest)}},
+	}
+	for _, step := range steps {
+		if err := ft.Update(step.slot); err != nil {
+			t.Fatalf("update(%d): %v", step.slot, err)
+		}
+		if got := ft.Current().Digest; got != step.current {
+			t.Fatalf("slot %d: current digest = %s, want %s", step.slot, got, step.current)
+		}
+		for _, topic := range []string{block(oldDigest), block(newDigest)} {
+			want := slices.Contains(step.joined, topic)
+			if got := slices.Contains(ps.GetTopics(), topic); got != want {
+				t.Fatalf("slot %d: subscribed to %s = %v, want %v", step.slot, topic, got, want)
+			}
+		}
+	}
+}
@@ -2,28 +2,36 @@ package gossipsub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+
+	"github.com/geanlabs/gean/types"
 )
 
-// Gossip topic names.
+// Gossip topic names, formatted with the hex fork digest.
 const (
 	BlockTopicFmt = "/leanconsensus/%s/block/ssz_snappy"
 	AttestationTopicFmt = "/leanconsensus/%s/attestation/ssz_snappy"
 )
 
-// Topics holds subscribed gossipsub topics.
+// Topics holds the gossipsub topics of one fork digest.
 type Topics struct {
+	Digest      types.ForkDigest
 	Block       *pubsub.Topic
 	Attestation *pubsub.Topic
+
+	subs []*pubsub.Subscription
 }
 
-// NewGossipSub creates a configured gossipsub instance.
-func NewGossipSub(ctx context.Context, h host.Host) (*pubsub.PubSub, error) {
-	return pubsub.NewGossipSub(ctx, h,
+// NewGossipSub creates a configured gossipsub instance. Unless scoring is
+// nil or disabled, peers are scored and their scores are inspected into the
+// returned PeerScores.
+func NewGossipSub(ctx context.Context, h host.Host, scoring *ScoringConfig) (*pubsub.PubSub, *PeerScores, error) {
+	opts := []pubsub.Option{
 		pubsub.WithMessageSignaturePolicy(pubsub.StrictNoSign),
 		pubsub.WithGossipSubParams(pubsub.GossipSubParams{
 			D:                         8,
@@ -49,20 +57,64 @@ func NewGossipSub(ctx context.Context, h host.Host) (*pubsub.PubSub, error) {
 			MaxIHaveMessages:          10,
 			IWantFollowupTime:         3 * time.Second,
 		}),
-		pubsub.WithSeenMessagesTTL(24*time.Second),
+		pubsub.WithSeenMessagesTTL(24 * time.Second),
 		pubsub.WithMessageIdFn(ComputeMessageID),
-	)
+	}
+
+	var scores *PeerScores
+	if scoring != nil && !scoring.Disabled {
+		thresholds := scoring.Thresholds()
+		scores = newPeerScores(thresholds)
+		inspectPeriod := time.Duration(max(scoring.InspectSlots, 1)) * slotDuration()
+		opts = append(opts,
+			pubsub.WithPeerScore(scoring.PeerScoreParams(), thresholds),
+			pubsub.WithPeerScoreInspect(pubsub.ExtendedPeerScoreInspectFn(scores.inspect), inspectPeriod),
+		)
+	}
+
+	ps, err := pubsub.NewGossipSub(ctx, h, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ps, scores, nil
 }
 
-// JoinTopics joins the block and vote gossip topics.
-func JoinTopics(ps *pubsub.PubSub, devnetID string) (*Topics, error) {
-	blockTopic, err := ps.Join(fmt.Sprintf(BlockTopicFmt, devnetID))
+// JoinTopics joins the block and vote gossip topics of digest, applying the
+// topic score parameters of scoring unless it is nil or disabled.
+func JoinTopics(ps *pubsub.PubSub, digest types.ForkDigest, scoring *ScoringConfig) (*Topics, error) {
+	blockTopic, err := ps.Join(fmt.Sprintf(BlockTopicFmt, digest))
 	if err != nil {
 		return nil, fmt.Errorf("join block topic: %w", err)
 	}
-	attTopic, err := ps.Join(fmt.Sprintf(AttestationTopicFmt, devnetID))
+	attTopic, err := ps.Join(fmt.Sprintf(AttestationTopicFmt, digest))
 	if err != nil {
 		return nil, fmt.Errorf("join attestation topic: %w", err)
 	}
-	return &Topics{Block: blockTopic, Attestation: attTopic}, nil
+	if scoring != nil && !scoring.Disabled {
+		if err := blockTopic.SetScoreParams(scoring.BlockTopicParams()); err != nil {
+			return nil, fmt.Errorf("block topic score params: %w", err)
+		}
+		if err := attTopic.SetScoreParams(scoring.AttestationTopicParams()); err != nil {
+			return nil, fmt.Errorf("attestation topic score params: %w", err)
+		}
+	}
+	return &Topics{Digest: digest, Block: blockTopic, Attestation: attTopic}, nil
+}
+
+// Leave cancels the subscriptions to t, removes its validators and closes
+// its topics, e.g. once the fork it belongs to has passed.
+func (t *Topics) Leave(ps *pubsub.PubSub) error {
+	for _, sub := range t.subs {
+		sub.Cancel()
+	}
+	t.subs = nil
+	var errs []error
+	for _, topic := range []*pubsub.Topic{t.Block, t.Attestation} {
+		// Topics without a registered validator return an error here.
+		ps.UnregisterTopicValidator(topic.String())
+		if err := topic.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", topic, err))
+		}
+	}
+	return errors.Join(errs...)
 }
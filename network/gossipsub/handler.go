@@ -3,15 +3,16 @@ package gossipsub
 import (
 	"context"
 
-	"github.com/golang/snappy"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/geanlabs/gean/types"
 )
 
-// GossipHandler processes decoded gossip messages.
+// GossipHandler processes decoded gossip messages. OnBlock also receives the
+// peer that forwarded the block so missing ancestors can be requested from it.
 type GossipHandler struct {
-	OnBlock       func(*types.SignedBlockWithAttestation)
+	OnBlock       func(*types.SignedBlockWithAttestation, peer.ID)
 	OnAttestation func(*types.SignedAttestation)
 }
 
@@ -21,10 +22,12 @@ func SubscribeTopics(ctx context.Context, topics *Topics, handler *GossipHandler
 	if err != nil {
 		return err
 	}
+	topics.subs = append(topics.subs, blockSub)
 	attSub, err := topics.Attestation.Subscribe()
 	if err != nil {
 		return err
 	}
+	topics.subs = append(topics.subs, attSub)
 
 	go readBlockMessages(ctx, blockSub, handler)
 	go readAttestationMessages(ctx, attSub, handler)
@@ -37,16 +40,16 @@ func readBlockMessages(ctx context.Context, sub *pubsub.Subscription, handler *G
 		if err != nil {
 			return
 		}
-		decoded, err := snappy.Decode(nil, msg.Data)
-		if err != nil {
-			continue
-		}
-		block := new(types.SignedBlockWithAttestation)
-		if err := block.UnmarshalSSZ(decoded); err != nil {
-			continue
+		// Messages that passed a topic validator arrive already decoded.
+		block, ok := msg.ValidatorData.(*types.SignedBlockWithAttestation)
+		if !ok {
+			block = new(types.SignedBlockWithAttestation)
+			if err := decodeMessage(msg.Data, block); err != nil {
+				continue
+			}
 		}
 		if handler.OnBlock != nil {
-			handler.OnBlock(block)
+			handler.OnBlock(block, msg.ReceivedFrom)
 		}
 	}
 }
@@ -57,13 +60,12 @@ func readAttestationMessages(ctx context.Context, sub *pubsub.Subscription, hand
 		if err != nil {
 			return
 		}
-		decoded, err := snappy.Decode(nil, msg.Data)
-		if err != nil {
-			continue
-		}
-		att := new(types.SignedAttestation)
-		if err := att.UnmarshalSSZ(decoded); err != nil {
-			continue
+		att, ok := msg.ValidatorData.(*types.SignedAttestation)
+		if !ok {
+			att = new(types.SignedAttestation)
+			if err := decodeMessage(msg.Data, att); err != nil {
+				continue
+			}
 		}
 		if handler.OnAttestation != nil {
 			handler.OnAttestation(att)
@@ -0,0 +1,258 @@
+package gossipsub
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Peer scoring follows the Ethereum consensus gossipsub scoring setup.
+// Its decay windows are counted in 32-slot epochs; lean has no epochs, so
+// the same windows are kept as slot counts and scaled by the lean slot
+// duration.
+const (
+	scoringEpochSlots = 32
+
+	// maxInMeshScore and maxFirstMessageDeliveriesScore bound the positive
+	// score a peer earns per unit of topic weight.
+	maxInMeshScore                 = 10
+	maxFirstMessageDeliveriesScore = 40
+
+	// Gossip mesh degree, see NewGossipSub.
+	meshDegree = 8
+)
+
+// ScoringConfig tunes gossipsub peer scoring. Devnets override fields
+// through a YAML file; see config.LoadScoringConfig.
+type ScoringConfig struct {
+	// Disabled turns peer scoring off.
+	Disabled bool `yaml:"disabled"`
+
+	GossipThreshold             float64 `yaml:"gossip_threshold"`
+	PublishThreshold            float64 `yaml:"publish_threshold"`
+	GraylistThreshold           float64 `yaml:"graylist_threshold"`
+	AcceptPXThreshold           float64 `yaml:"accept_px_threshold"`
+	OpportunisticGraftThreshold float64 `yaml:"opportunistic_graft_threshold"`
+
+	BlockTopicWeight       float64 `yaml:"block_topic_weight"`
+	AttestationTopicWeight float64 `yaml:"attestation_topic_weight"`
+	// ExpectedAttestationsPerSlot is the attestation rate the first-delivery
+	// cap is sized for, normally the validator count.
+	ExpectedAttestationsPerSlot float64 `yaml:"expected_attestations_per_slot"`
+
+	// IPColocationThreshold is how many peers may share an IP before they
+	// are penalized. Local devnets running many nodes on one host raise it.
+	IPColocationThreshold int `yaml:"ip_colocation_threshold"`
+	// BehaviourPenaltyThreshold is how many protocol misbehaviours are
+	// tolerated before they count against the score.
+	BehaviourPenaltyThreshold float64 `yaml:"behaviour_penalty_threshold"`
+
+	// InspectSlots is how often, in slots, scores are logged and exported.
+	InspectSlots uint64 `yaml:"inspect_slots"`
+}
+
+// DefaultScoringConfig returns the scoring defaults for a network of
+// numValidators validators.
+func DefaultScoringConfig(numValidators int) ScoringConfig {
+	return ScoringConfig{
+		GossipThreshold:             -4000,
+		PublishThreshold:            -8000,
+		GraylistThreshold:           -16000,
+		AcceptPXThreshold:           100,
+		OpportunisticGraftThreshold: 5,
+		BlockTopicWeight:            0.5,
+		AttestationTopicWeight:      0.5,
+		ExpectedAttestationsPerSlot: float64(max(numValidators, 1)),
+		IPColocationThreshold:       10,
+		BehaviourPenaltyThreshold:   6,
+		InspectSlots:                8,
+	}
+}
+
+func slotDuration() time.Duration {
+	return time.Duration(types.SecondsPerSlot) * time.Second
+}
+
+// scoreDecay returns the per-slot decay factor that brings a counter to
+// DecayToZero after slots slots.
+func scoreDecay(slots uint64) float64 {
+	return pubsub.ScoreParameterDecayWithBase(time.Duration(slots)*slotDuration(), slotDuration(), pubsub.DefaultDecayToZero)
+}
+
+// decayConvergence is the value a counter decaying by decay and growing by
+// rate per slot converges to.
+func decayConvergence(decay, rate float64) float64 {
+	return rate / (1 - decay)
+}
+
+// maxPositiveScore is the highest score a peer can reach across topics.
+func (c ScoringConfig) maxPositiveScore() float64 {
+	return (maxInMeshScore + maxFirstMessageDeliveriesScore) * (c.BlockTopicWeight + c.AttestationTopicWeight)
+}
+
+// Thresholds returns the score thresholds below which peers lose gossip,
+// publishing and all RPC with us.
+func (c ScoringConfig) Thresholds() *pubsub.PeerScoreThresholds {
+	return &pubsub.PeerScoreThresholds{
+		GossipThreshold:             c.GossipThreshold,
+		PublishThreshold:            c.PublishThreshold,
+		GraylistThreshold:           c.GraylistThreshold,
+		AcceptPXThreshold:           c.AcceptPXThreshold,
+		OpportunisticGraftThreshold: c.OpportunisticGraftThreshold,
+	}
+}
+
+// PeerScoreParams returns the topic-independent score parameters. Topic
+// parameters are set when the topics are joined.
+func (c ScoringConfig) PeerScoreParams() *pubsub.PeerScoreParams {
+	maxScore := c.maxPositiveScore()
+	// A peer that misbehaves 16 times above the threshold, before decay,
+	// falls to the gossip threshold.
+	penaltyTarget := 16.0
+	return &pubsub.PeerScoreParams{
+		Topics:                      make(map[string]*pubsub.TopicScoreParams),
+		TopicScoreCap:               maxScore / 2,
+		AppSpecificScore:            func(peer.ID) float64 { return 0 },
+		AppSpecificWeight:           1,
+		IPColocationFactorWeight:    -maxScore / 2,
+		IPColocationFactorThreshold: max(c.IPColocationThreshold, 1),
+		BehaviourPenaltyWeight:      c.GossipThreshold / (penaltyTarget * penaltyTarget),
+		BehaviourPenaltyThreshold:   c.BehaviourPenaltyThreshold,
+		BehaviourPenaltyDecay:       scoreDecay(10 * scoringEpochSlots),
+		DecayInterval:               slotDuration(),
+		DecayToZero:                 pubsub.DefaultDecayToZero,
+		RetainScore:                 100 * scoringEpochSlots * slotDuration(),
+	}
+}
+
+// BlockTopicParams returns the score parameters of the block topic, which
+// carries one block per slot.
+func (c ScoringConfig) BlockTopicParams() *pubsub.TopicScoreParams {
+	return c.topicParams(c.BlockTopicWeight, 1)
+}
+
+// AttestationTopicParams returns the score parameters of the attestation
+// topic, which carries one attestation per validator per slot.
+func (c ScoringConfig) AttestationTopicParams() *pubsub.TopicScoreParams {
+	return c.topicParams(c.AttestationTopicWeight, c.ExpectedAttestationsPerSlot)
+}
+
+// topicParams derives topic score parameters from the topic weight and the
+// expected message rate per slot. Mesh delivery penalties (P3) stay off:
+// devnets are small and bursty, and under-delivery there says more about
+// the network than the peer.
+func (c ScoringConfig) topicParams(weight, messagesPerSlot float64) *pubsub.TopicScoreParams {
+	timeInMeshCap := float64(time.Hour / slotDuration())
+	firstDecay := scoreDecay(20 * scoringEpochSlots)
+	// Each mesh peer is expected to deliver its share of messages first.
+	firstCap := decayConvergence(firstDecay, 2*messagesPerSlot/meshDegree)
+	// One invalid message wipes out the topic's share of the maximum score.
+	invalidWeight := -c.maxPositiveScore()
+	if weight > 0 {
+		invalidWeight /= weight
+	}
+	return &pubsub.TopicScoreParams{
+		TopicWeight:                    weight,
+		TimeInMeshWeight:               maxInMeshScore / timeInMeshCap,
+		TimeInMeshQuantum:              slotDuration(),
+		TimeInMeshCap:                  timeInMeshCap,
+		FirstMessageDeliveriesWeight:   maxFirstMessageDeliveriesScore / firstCap,
+		FirstMessageDeliveriesDecay:    firstDecay,
+		FirstMessageDeliveriesCap:      firstCap,
+		InvalidMessageDeliveriesWeight: invalidWeight,
+		InvalidMessageDeliveriesDecay:  scoreDecay(50 * scoringEpochSlots),
+	}
+}
+
+// PeerScores holds the most recent gossipsub score of every peer.
+type PeerScores struct {
+	thresholds *pubsub.PeerScoreThresholds
+
+	mu     sync.Mutex
+	scores map[peer.ID]float64
+
+	log *slog.Logger
+}
+
+func newPeerScores(thresholds *pubsub.PeerScoreThresholds) *PeerScores {
+	return &PeerScores{
+		thresholds: thresholds,
+		scores:     make(map[peer.ID]float64),
+		log:        logging.NewComponentLogger(logging.CompGossip),
+	}
+}
+
+// Score returns the last inspected score of pid.
+func (s *PeerScores) Score(pid peer.ID) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score, ok := s.scores[pid]
+	return score, ok
+}
+
+// inspect is the gossipsub score inspector. It records the scores, exports
+// their distribution and logs peers crossing the graylist threshold.
+func (s *PeerScores) inspect(snapshots map[peer.ID]*pubsub.PeerScoreSnapshot) {
+	scores := make(map[peer.ID]float64, len(snapshots))
+	values := make([]float64, 0, len(snapshots))
+	var belowGossip, belowPublish, belowGraylist int
+	for pid, snap := range snapshots {
+		scores[pid] = snap.Score
+		values = append(values, snap.Score)
+		if snap.Score < s.thresholds.GossipThreshold {
+			belowGossip++
+		}
+		if snap.Score < s.thresholds.PublishThreshold {
+			belowPublish++
+		}
+		if snap.Score < s.thresholds.GraylistThreshold {
+			belowGraylist++
+		}
+		var firstDeliveries, invalidDeliveries float64
+		for _, t := range snap.Topics {
+			firstDeliveries += t.FirstMessageDeliveries
+			invalidDeliveries += t.InvalidMessageDeliveries
+		}
+		s.log.Debug("peer score",
+			"peer", pid.String(),
+			"score", snap.Score,
+			"first_deliveries", firstDeliveries,
+			"invalid_deliveries", invalidDeliveries,
+			"behaviour_penalty", snap.BehaviourPenalty,
+			"ip_colocation", snap.IPColocationFactor,
+		)
+	}
+
+	s.mu.Lock()
+	prev := s.scores
+	s.scores = scores
+	s.mu.Unlock()
+
+	for pid, score := range scores {
+		if score < s.thresholds.GraylistThreshold && prev[pid] >= s.thresholds.GraylistThreshold {
+			s.log.Warn("peer graylisted by gossip score", "peer", pid.String(), "score", score)
+		}
+	}
+
+	metrics.GossipPeersBelowThreshold.WithLabelValues("gossip").Set(float64(belowGossip))
+	metrics.GossipPeersBelowThreshold.WithLabelValues("publish").Set(float64(belowPublish))
+	metrics.GossipPeersBelowThreshold.WithLabelValues("graylist").Set(float64(belowGraylist))
+	if len(values) == 0 {
+		return
+	}
+	slices.Sort(values)
+	metrics.GossipPeerScore.WithLabelValues("min").Set(values[0])
+	metrics.GossipPeerScore.WithLabelValues("median").Set(values[len(values)/2])
+	metrics.GossipPeerScore.WithLabelValues("max").Set(values[len(values)-1])
+}
@@ -0,0 +1,78 @@
+package gossipsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/types"
+)
+
+func TestScoringParamsAccepted(t *testing.T) {
+	disabledAttestations := DefaultScoringConfig(64)
+	disabledAttestations.AttestationTopicWeight = 0
+
+	for name, cfg := range map[string]ScoringConfig{
+		"default":              DefaultScoringConfig(5),
+		"no attestation score": disabledAttestations,
+	} {
+		t.Run(name, func(t *testing.T) {
+			h, err := libp2p.New(libp2p.NoListenAddrs)
+			if err != nil {
+				t.Fatalf("new host: %v", err)
+			}
+			defer h.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ps, scores, err := NewGossipSub(ctx, h, &cfg)
+			if err != nil {
+				t.Fatalf("new gossipsub: %v", err)
+			}
+			if scores == nil {
+				t.Fatal("expected peer scores with scoring enabled")
+			}
+			if _, err := JoinTopics(ps, types.ForkDigest{}, &cfg); err != nil {
+				t.Fatalf("join topics: %v", err)
+			}
+		})
+	}
+}
+
+func TestFirstDeliveriesCapScalesWithRate(t *testing.T) {
+	cfg := DefaultScoringConfig(100)
+	block, att := cfg.BlockTopicParams(), cfg.AttestationTopicParams()
+	if att.FirstMessageDeliveriesCap <= block.FirstMessageDeliveriesCap {
+		t.Fatalf("attestation cap %v should exceed block cap %v", att.FirstMessageDeliveriesCap, block.FirstMessageDeliveriesCap)
+	}
+	// Both topics top out at the same first-delivery score.
+	blockMax := block.FirstMessageDeliveriesCap * block.FirstMessageDeliveriesWeight
+	attMax := att.FirstMessageDeliveriesCap * att.FirstMessageDeliveriesWeight
+	if blockMax != maxFirstMessageDeliveriesScore || attMax != maxFirstMessageDeliveriesScore {
+		t.Fatalf("max first-delivery scores = %v, %v, want %v", blockMax, attMax, maxFirstMessageDeliveriesScore)
+	}
+}
+
+func TestPeerScoresInspect(t *testing.T) {
+	cfg := DefaultScoringConfig(5)
+	scores := newPeerScores(cfg.Thresholds())
+	scores.inspect(map[peer.ID]*pubsub.PeerScoreSnapshot{
+		"good": {Score: 12},
+		"bad":  {Score: -20000},
+	})
+	if s, ok := scores.Score("bad"); !ok || s != -20000 {
+		t.Fatalf("bad score = %v, %v, want -20000", s, ok)
+	}
+
+	// Peers missing from the next inspection are forgotten.
+	scores.inspect(map[peer.ID]*pubsub.PeerScoreSnapshot{"good": {Score: 15}})
+	if _, ok := scores.Score("bad"); ok {
+		t.Fatal("disconnected peer should be dropped")
+	}
+	if s, _ := scores.Score("good"); s != 15 {
+		t.Fatalf("good score = %v, want 15", s)
+	}
+}
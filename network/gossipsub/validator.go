@@ -0,0 +1,296 @@
+package gossipsub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/golang/snappy"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Gossip validation windows, in slots.
+const (
+	// MaxClockDisparitySlots is how far ahead of our clock a message may be
+	// before it is ignored.
+	MaxClockDisparitySlots = 1
+	// AttestationPropagationSlots is how far behind our clock an attestation
+	// may be and still be forwarded.
+	AttestationPropagationSlots = 32
+)
+
+// maxSeenMessages bounds the seen cache. Forged signatures make every copy
+// of a message distinct, so the window alone does not bound it.
+const maxSeenMessages = 1 << 16
+
+// ChainView is the chain state gossip validation checks messages against.
+type ChainView interface {
+	CurrentSlot() uint64
+	FinalizedSlot() uint64
+	NumValidators() uint64
+	// HasBlock reports whether the block with root has been imported.
+	HasBlock(root [32]byte) bool
+}
+
+// MessageValidator runs cheap checks on gossip messages before gossipsub
+// forwards them. Rejected messages count against the sender's peer score;
+// ignored ones are dropped without penalty.
+type MessageValidator struct {
+	chain ChainView
+	self  peer.ID
+
+	// OnUnknownParent receives blocks that are otherwise valid but whose
+	// parent has not been imported. They are not forwarded, but the node
+	// may still queue them and fetch the parent from the sender.
+	OnUnknownParent func(*types.SignedBlockWithAttestation, peer.ID)
+	// OnUnknownBlock receives attestations that are otherwise valid but
+	// whose head or target block has not been imported. They are not
+	// forwarded, but fork choice may hold them until the blocks arrive.
+	OnUnknownBlock func(*types.SignedAttestation)
+	// OnReject is told about peers that forwarded a rejected message.
+	OnReject func(from peer.ID, topic, reason string)
+
+	mu sync.Mutex
+	// seen maps the roots of validated messages, signatures included, to
+	// their slot, so a copy with a forged signature cannot shadow the real
+	// message.
+	seen map[[32]byte]uint64
+
+	log *slog.Logger
+}
+
+// NewMessageValidator creates a validator for the node with peer ID self.
+// Messages we publish ourselves are accepted once they decode.
+func NewMessageValidator(chain ChainView, self peer.ID) *MessageValidator {
+	return &MessageValidator{
+		chain: chain,
+		self:  self,
+		seen:  make(map[[32]byte]uint64),
+		log:   logging.NewComponentLogger(logging.CompGossip),
+	}
+}
+
+// RegisterValidators installs v as the validator of the block and
+// attestation topics.
+func RegisterValidators(ps *pubsub.PubSub, topics *Topics, v *MessageValidator) error {
+	if err := ps.RegisterTopicValidator(topics.Block.String(), v.ValidateBlockMessage); err != nil {
+		return fmt.Errorf("register block validator: %w", err)
+	}
+	if err := ps.RegisterTopicValidator(topics.Attestation.String(), v.ValidateAttestationMessage); err != nil {
+		return fmt.Errorf("register attestation validator: %w", err)
+	}
+	return nil
+}
+
+// ValidateBlockMessage is the pubsub validator of the block topic. Accepted
+// messages carry the decoded block in ValidatorData.
+func (v *MessageValidator) ValidateBlockMessage(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	sb := new(types.SignedBlockWithAttestation)
+	if err := decodeMessage(msg.Data, sb); err != nil {
+		return v.result("block", from, pubsub.ValidationReject, err.Error())
+	}
+	res, reason := pubsub.ValidationAccept, ""
+	if from != v.self {
+		res, reason = v.ValidateBlock(sb)
+		if reason == reasonUnknownParent && v.OnUnknownParent != nil {
+			v.OnUnknownParent(sb, msg.ReceivedFrom)
+		}
+	}
+	if res == pubsub.ValidationAccept {
+		msg.ValidatorData = sb
+	}
+	return v.result("block", from, res, reason)
+}
+
+// ValidateAttestationMessage is the pubsub validator of the attestation
+// topic. Accepted messages carry the decoded attestation in ValidatorData.
+func (v *MessageValidator) ValidateAttestationMessage(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	sa := new(types.SignedAttestation)
+	if err := decodeMessage(msg.Data, sa); err != nil {
+		return v.result("attestation", from, pubsub.ValidationReject, err.Error())
+	}
+	res, reason := pubsub.ValidationAccept, ""
+	if from != v.self {
+		res, reason = v.ValidateAttestation(sa)
+		if reason == reasonUnknownBlock && v.OnUnknownBlock != nil {
+			v.OnUnknownBlock(sa)
+		}
+	}
+	if res == pubsub.ValidationAccept {
+		msg.ValidatorData = sa
+	}
+	return v.result("attestation", from, res, reason)
+}
+
+const (
+	reasonUnknownParent = "unknown parent"
+	reasonUnknownBlock  = "unknown head or target"
+)
+
+// ValidateBlock checks a decoded block and returns the validation result
+// with the reason for anything but accept.
+func (v *MessageValidator) ValidateBlock(sb *types.SignedBlockWithAttestation) (pubsub.ValidationResult, string) {
+	if sb.Message == nil || sb.Message.Block == nil || sb.Message.Block.Body == nil {
+		return pubsub.ValidationReject, "missing block"
+	}
+	block := sb.Message.Block
+
+	if block.Slot > v.chain.CurrentSlot()+MaxClockDisparitySlots {
+		return pubsub.ValidationIgnore, "block from the future"
+	}
+	if block.Slot <= v.chain.FinalizedSlot() {
+		return pubsub.ValidationIgnore, "block at or before finalized slot"
+	}
+
+	numValidators := v.chain.NumValidators()
+	if numValidators == 0 || block.ProposerIndex >= numValidators {
+		return pubsub.ValidationReject, "proposer index out of range"
+	}
+	if !statetransition.IsProposer(block.ProposerIndex, block.Slot, numValidators) {
+		return pubsub.ValidationReject, "wrong proposer for slot"
+	}
+
+	// One signature per body attestation, plus one for the proposer
+	// attestation if present.
+	wantSigs := len(block.Body.Attestations)
+	if sb.Message.ProposerAttestation != nil {
+		wantSigs++
+	}
+	if len(sb.Signature) != wantSigs {
+		return pubsub.ValidationReject, fmt.Sprintf("signature count %d, want %d", len(sb.Signature), wantSigs)
+	}
+
+	root, err := block.HashTreeRoot()
+	if err != nil {
+		return pubsub.ValidationReject, err.Error()
+	}
+	signedRoot, err := sb.HashTreeRoot()
+	if err != nil {
+		return pubsub.ValidationReject, err.Error()
+	}
+	if v.chain.HasBlock(root) || !v.markSeen(signedRoot, block.Slot) {
+		return pubsub.ValidationIgnore, "already seen"
+	}
+	if !v.chain.HasBlock(block.ParentRoot) {
+		return pubsub.ValidationIgnore, reasonUnknownParent
+	}
+	return pubsub.ValidationAccept, ""
+}
+
+// ValidateAttestation checks a decoded attestation and returns the
+// validation result with the reason for anything but accept. Attestations
+// whose head or target block we do not have are ignored, since we cannot
+// tell whether they vote for a real block.
+func (v *MessageValidator) ValidateAttestation(sa *types.SignedAttestation) (pubsub.ValidationResult, string) {
+	att := sa.Message
+	if att == nil || att.Data == nil || att.Data.Head == nil || att.Data.Target == nil || att.Data.Source == nil {
+		return pubsub.ValidationReject, "missing attestation data"
+	}
+	data := att.Data
+
+	current := v.chain.CurrentSlot()
+	if data.Slot > current+MaxClockDisparitySlots {
+		return pubsub.ValidationIgnore, "attestation from the future"
+	}
+	if data.Slot+AttestationPropagationSlots < current || data.Slot < v.chain.FinalizedSlot() {
+		return pubsub.ValidationIgnore, "attestation too old"
+	}
+
+	if att.ValidatorID >= v.chain.NumValidators() {
+		return pubsub.ValidationReject, "validator index out of range"
+	}
+	if data.Source.Slot > data.Target.Slot {
+		return pubsub.ValidationReject, "source after target"
+	}
+
+	root, err := sa.HashTreeRoot()
+	if err != nil {
+		return pubsub.ValidationReject, err.Error()
+	}
+	if !v.chain.HasBlock(data.Head.Root) || !v.chain.HasBlock(data.Target.Root) {
+		return pubsub.ValidationIgnore, reasonUnknownBlock
+	}
+	if !v.markSeen(root, data.Slot) {
+		return pubsub.ValidationIgnore, "already seen"
+	}
+	return pubsub.ValidationAccept, ""
+}
+
+// markSeen records root and reports whether it was new. When the cache is
+// full, the messages of its oldest slot are forgotten first.
+func (v *MessageValidator) markSeen(root [32]byte, slot uint64) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.seen[root]; ok {
+		return false
+	}
+	if len(v.seen) >= maxSeenMessages {
+		oldest := ^uint64(0)
+		for _, s := range v.seen {
+			oldest = min(oldest, s)
+		}
+		for r, s := range v.seen {
+			if s == oldest {
+				delete(v.seen, r)
+			}
+		}
+	}
+	v.seen[root] = slot
+	return true
+}
+
+// Prune forgets seen messages at or before the finalized slot or older
+// than the propagation window at currentSlot. It is called once per slot.
+func (v *MessageValidator) Prune(currentSlot uint64) {
+	finalized := v.chain.FinalizedSlot()
+	oldest := currentSlot - min(currentSlot, AttestationPropagationSlots)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for root, slot := range v.seen {
+		if slot <= finalized || slot < oldest {
+			delete(v.seen, root)
+		}
+	}
+}
+
+// result records the outcome of a validation and returns res.
+func (v *MessageValidator) result(topic string, from peer.ID, res pubsub.ValidationResult, reason string) pubsub.ValidationResult {
+	switch res {
+	case pubsub.ValidationAccept:
+		metrics.GossipValidation.WithLabelValues(topic, "accept").Inc()
+	case pubsub.ValidationReject:
+		metrics.GossipValidation.WithLabelValues(topic, "reject").Inc()
+		v.log.Debug("rejected gossip message", "topic", topic, "peer", from.String(), "reason", reason)
+		if v.OnReject != nil {
+			v.OnReject(from, topic, reason)
+		}
+	default:
+		metrics.GossipValidation.WithLabelValues(topic, "ignore").Inc()
+		v.log.Debug("ignored gossip message", "topic", topic, "peer", from.String(), "reason", reason)
+	}
+	return res
+}
+
+// sszUnmarshaler is implemented by the gossip message types.
+type sszUnmarshaler interface {
+	UnmarshalSSZ([]byte) error
+}
+
+// decodeMessage snappy-decompresses and SSZ-decodes data into out.
+func decodeMessage(data []byte, out sszUnmarshaler) error {
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return fmt.Errorf("snappy decode: %w", err)
+	}
+	if err := out.UnmarshalSSZ(decoded); err != nil {
+		return fmt.Errorf("ssz decode: %w", err)
+	}
+	return nil
+}
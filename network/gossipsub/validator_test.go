@@ -0,0 +1,285 @@
+package gossipsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/snappy"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/types"
+)
+
+type fakeChain struct {
+	current, finalized, validators uint64
+	blocks                         map[[32]byte]bool
+}
+
+func (c *fakeChain) CurrentSlot() uint64         { return c.current }
+func (c *fakeChain) FinalizedSlot() uint64       { return c.finalized }
+func (c *fakeChain) NumValidators() uint64       { return c.validators }
+func (c *fakeChain) HasBlock(root [32]byte) bool { return c.blocks[root] }
+
+var parentRoot = [32]byte{0xaa}
+
+func newTestValidator() (*MessageValidator, *fakeChain) {
+	chain := &fakeChain{
+		current:    10,
+		validators: 4,
+		blocks:     map[[32]byte]bool{parentRoot: true},
+	}
+	return NewMessageValidator(chain, "self"), chain
+}
+
+func makeBlock(slot uint64) *types.SignedBlockWithAttestation {
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block: &types.Block{
+				Slot:          slot,
+				ProposerIndex: slot % 4,
+				ParentRoot:    parentRoot,
+				Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+			},
+			ProposerAttestation: makeAttestation(slot%4, slot).Message,
+		},
+		Signature: [][3116]byte{{}},
+	}
+}
+
+func makeAttestation(validator, slot uint64) *types.SignedAttestation {
+	return &types.SignedAttestation{
+		Message: &types.Attestation{
+			ValidatorID: validator,
+			Data: &types.AttestationData{
+				Slot:   slot,
+				Head:   &types.Checkpoint{Root: parentRoot, Slot: slot},
+				Target: &types.Checkpoint{Root: parentRoot, Slot: slot},
+				Source: &types.Checkpoint{},
+			},
+		},
+	}
+}
+
+func TestValidateBlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*types.SignedBlockWithAttestation)
+		want   pubsub.ValidationResult
+	}{
+		{"valid", func(*types.SignedBlockWithAttestation) {}, pubsub.ValidationAccept},
+		{"future slot", func(sb *types.SignedBlockWithAttestation) {
+			sb.Message.Block.Slot = 12
+			sb.Message.Block.ProposerIndex = 0
+		}, pubsub.ValidationIgnore},
+		{"wrong proposer", func(sb *types.SignedBlockWithAttestation) {
+			sb.Message.Block.ProposerIndex = 3
+		}, pubsub.ValidationReject},
+		{"proposer out of range", func(sb *types.SignedBlockWithAttestation) {
+			sb.Message.Block.ProposerIndex = 9
+		}, pubsub.ValidationReject},
+		{"signature count", func(sb *types.SignedBlockWithAttestation) {
+			sb.Signature = append(sb.Signature, [3116]byte{})
+		}, pubsub.ValidationReject},
+		{"unknown parent", func(sb *types.SignedBlockWithAttestation) {
+			sb.Message.Block.ParentRoot = [32]byte{0xbb}
+		}, pubsub.ValidationIgnore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := newTestValidator()
+			sb := makeBlock(9)
+			tt.modify(sb)
+			if got, reason := v.ValidateBlock(sb); got != tt.want {
+				t.Fatalf("result = %v (%s), want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBlockIgnoresFinalizedAndSeen(t *testing.T) {
+	v, chain := newTestValidator()
+	chain.finalized = 9
+	if got, _ := v.ValidateBlock(makeBlock(9)); got != pubsub.ValidationIgnore {
+		t.Fatalf("finalized slot result = %v, want ignore", got)
+	}
+
+	chain.finalized = 0
+	if got, _ := v.ValidateBlock(makeBlock(9)); got != pubsub.ValidationAccept {
+		t.Fatalf("first result = %v, want accept", got)
+	}
+	if got, _ := v.ValidateBlock(makeBlock(9)); got != pubsub.ValidationIgnore {
+		t.Fatalf("repeat result = %v, want ignore", got)
+	}
+
+	sb := makeBlock(10)
+	root, _ := sb.Message.Block.HashTreeRoot()
+	chain.blocks[root] = true
+	if got, _ := v.ValidateBlock(sb); got != pubsub.ValidationIgnore {
+		t.Fatalf("imported block result = %v, want ignore", got)
+	}
+}
+
+func TestValidateAttestation(t *testing.T) {
+	v, _ := newTestValidator()
+	if got, reason := v.ValidateAttestation(makeAttestation(1, 10)); got != pubsub.ValidationAccept {
+		t.Fatalf("valid result = %v (%s), want accept", got, reason)
+	}
+	if got, _ := v.ValidateAttestation(makeAttestation(1, 10)); got != pubsub.ValidationIgnore {
+		t.Fatalf("repeat result = %v, want ignore", got)
+	}
+	if got, _ := v.ValidateAttestation(makeAttestation(7, 10)); got != pubsub.ValidationReject {
+		t.Fatalf("unknown validator result = %v, want reject", got)
+	}
+	if got, _ := v.ValidateAttestation(makeAttestation(2, 12)); got != pubsub.ValidationIgnore {
+		t.Fatalf("future result = %v, want ignore", got)
+	}
+
+	bad := makeAttestation(2, 10)
+	bad.Message.Data.Source.Slot = 11
+	if got, _ := v.ValidateAttestation(bad); got != pubsub.ValidationReject {
+		t.Fatalf("source after target result = %v, want reject", got)
+	}
+}
+
+func TestValidateAttestationIgnoresUnknownBlocks(t *testing.T) {
+	v, chain := newTestValidator()
+	var got []*types.SignedAttestation
+	v.OnUnknownBlock = func(sa *types.SignedAttestation) { got = append(got, sa) }
+
+	unknownHead := makeAttestation(1, 10)
+	unknownHead.Message.Data.Head.Root = [32]byte{0xbb}
+	unknownTarget := makeAttestation(2, 10)
+	unknownTarget.Message.Data.Target.Root = [32]byte{0xbb}
+	for _, sa := range []*types.SignedAttestation{unknownHead, unknownTarget} {
+		if res := v.ValidateAttestationMessage(context.Background(), "peer", attestationMessage(t, sa)); res != pubsub.ValidationIgnore {
+			t.Fatalf("unknown block result = %v, want ignore", res)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("unknown block callbacks = %d, want 2", len(got))
+	}
+
+	// Once the block arrives, the same attestation is no longer a repeat.
+	chain.blocks[[32]byte{0xbb}] = true
+	if res, reason := v.ValidateAttestation(unknownHead); res != pubsub.ValidationAccept {
+		t.Fatalf("known block result = %v (%s), want accept", res, reason)
+	}
+}
+
+func TestSeenCacheIsBounded(t *testing.T) {
+	v, _ := newTestValidator()
+	for i := 0; i < maxSeenMessages; i++ {
+		v.markSeen([32]byte{byte(i), byte(i >> 8), byte(i >> 16), 1}, 5+uint64(i%2))
+	}
+	if !v.markSeen([32]byte{0xff}, 7) {
+		t.Fatal("new root should be marked seen")
+	}
+	if len(v.seen) != maxSeenMessages/2+1 {
+		t.Fatalf("seen = %d, want %d after evicting the oldest slot", len(v.seen), maxSeenMessages/2+1)
+	}
+	for _, slot := range v.seen {
+		if slot == 5 {
+			t.Fatal("messages of the oldest slot should be evicted")
+		}
+	}
+}
+
+func TestForgedCopyDoesNotShadowMessage(t *testing.T) {
+	v, _ := newTestValidator()
+	forged := makeAttestation(1, 10)
+	forged.Signature[0] = 0xff
+	if got, _ := v.ValidateAttestation(forged); got != pubsub.ValidationAccept {
+		t.Fatalf("forged result = %v, want accept", got)
+	}
+	if got, reason := v.ValidateAttestation(makeAttestation(1, 10)); got != pubsub.ValidationAccept {
+		t.Fatalf("real result = %v (%s), want accept", got, reason)
+	}
+
+	forgedBlock := makeBlock(9)
+	forgedBlock.Signature[0][0] = 0xff
+	if got, _ := v.ValidateBlock(forgedBlock); got != pubsub.ValidationAccept {
+		t.Fatalf("forged block result = %v, want accept", got)
+	}
+	if got, reason := v.ValidateBlock(makeBlock(9)); got != pubsub.ValidationAccept {
+		t.Fatalf("real block result = %v (%s), want accept", got, reason)
+	}
+}
+
+func TestPruneSeen(t *testing.T) {
+	v, chain := newTestValidator()
+	v.ValidateAttestation(makeAttestation(1, 10))
+	chain.current = 11
+	v.ValidateAttestation(makeAttestation(1, 11))
+
+	v.Prune(10 + AttestationPropagationSlots)
+	if len(v.seen) != 2 {
+		t.Fatalf("seen = %d, want 2 within the propagation window", len(v.seen))
+	}
+	v.Prune(11 + AttestationPropagationSlots)
+	if len(v.seen) != 1 {
+		t.Fatalf("seen = %d, want 1", len(v.seen))
+	}
+	chain.finalized = 11
+	v.Prune(12)
+	if len(v.seen) != 0 {
+		t.Fatalf("seen = %d, want 0 after finalization", len(v.seen))
+	}
+}
+
+func blockMessage(t *testing.T, sb *types.SignedBlockWithAttestation) *pubsub.Message {
+	t.Helper()
+	data, err := sb.MarshalSSZ()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return &pubsub.Message{Message: &pb.Message{Data: snappy.Encode(nil, data)}, ReceivedFrom: "peer"}
+}
+
+func attestationMessage(t *testing.T, sa *types.SignedAttestation) *pubsub.Message {
+	t.Helper()
+	data, err := sa.MarshalSSZ()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return &pubsub.Message{Message: &pb.Message{Data: snappy.Encode(nil, data)}, ReceivedFrom: "peer"}
+}
+
+func TestValidateBlockMessage(t *testing.T) {
+	v, _ := newTestValidator()
+	ctx := context.Background()
+
+	garbage := &pubsub.Message{Message: &pb.Message{Data: []byte("not a block")}}
+	if got := v.ValidateBlockMessage(ctx, "peer", garbage); got != pubsub.ValidationReject {
+		t.Fatalf("garbage result = %v, want reject", got)
+	}
+
+	msg := blockMessage(t, makeBlock(9))
+	if got := v.ValidateBlockMessage(ctx, "peer", msg); got != pubsub.ValidationAccept {
+		t.Fatalf("valid result = %v, want accept", got)
+	}
+	if _, ok := msg.ValidatorData.(*types.SignedBlockWithAttestation); !ok {
+		t.Fatal("accepted message should carry the decoded block")
+	}
+
+	// Our own blocks are already imported when published.
+	if got := v.ValidateBlockMessage(ctx, "self", blockMessage(t, makeBlock(9))); got != pubsub.ValidationAccept {
+		t.Fatalf("local result = %v, want accept", got)
+	}
+}
+
+func TestValidateBlockMessageReportsUnknownParent(t *testing.T) {
+	v, _ := newTestValidator()
+	var gotFrom peer.ID
+	v.OnUnknownParent = func(sb *types.SignedBlockWithAttestation, from peer.ID) { gotFrom = from }
+
+	sb := makeBlock(9)
+	sb.Message.Block.ParentRoot = [32]byte{0xbb}
+	if got := v.ValidateBlockMessage(context.Background(), "peer", blockMessage(t, sb)); got != pubsub.ValidationIgnore {
+		t.Fatalf("result = %v, want ignore", got)
+	}
+	if gotFrom != "peer" {
+		t.Fatalf("unknown parent callback peer = %q, want %q", gotFrom, "peer")
+	}
+}
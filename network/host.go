@@ -10,7 +10,6 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
-	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 
 	"github.com/geanlabs/gean/network/gossipsub"
@@ -23,12 +22,15 @@ var netLog = logging.NewComponentLogger(logging.CompNetwork)
 type Host struct {
 	P2P    host.Host
 	PubSub *pubsub.PubSub
+	Scores *gossipsub.PeerScores
+	Peers  *PeerManager
 	Ctx    context.Context
 	Cancel context.CancelFunc
 }
 
 // NewHost creates a libp2p host with QUIC transport and secp256k1 identity.
-func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string) (*Host, error) {
+// Gossip peers are scored with scoring unless it is nil or disabled.
+func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string, scoring *gossipsub.ScoringConfig) (*Host, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	privKey, err := loadOrGenerateKey(nodeKeyPath)
@@ -43,23 +45,26 @@ func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string) (*Host,
 		return nil, fmt.Errorf("parse listen addr: %w", err)
 	}
 
+	peers := NewPeerManager(bootnodes)
 	h, err := libp2p.New(
 		libp2p.Identity(privKey),
 		libp2p.ListenAddrs(addr),
+		libp2p.ConnectionGater(peers),
 	)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("new host: %w", err)
 	}
+	peers.attach(h)
 
-	gs, err := gossipsub.NewGossipSub(ctx, h)
+	gs, scores, err := gossipsub.NewGossipSub(ctx, h, scoring)
 	if err != nil {
 		h.Close()
 		cancel()
 		return nil, fmt.Errorf("gossipsub: %w", err)
 	}
 
-	return &Host{P2P: h, PubSub: gs, Ctx: ctx, Cancel: cancel}, nil
+	return &Host{P2P: h, PubSub: gs, Scores: scores, Peers: peers, Ctx: ctx, Cancel: cancel}, nil
 }
 
 // Close shuts down the host.
@@ -68,36 +73,6 @@ func (h *Host) Close() error {
 	return h.P2P.Close()
 }
 
-// ConnectBootnodes dials the given multiaddrs and connects to them.
-func ConnectBootnodes(ctx context.Context, h host.Host, addrs []string) error {
-	for _, addr := range addrs {
-		ma, err := multiaddr.NewMultiaddr(addr)
-		if err != nil {
-			netLog.Warn("invalid bootnode multiaddr", "addr", addr, "err", err)
-			continue
-		}
-		pi, err := peer.AddrInfoFromP2pAddr(ma)
-		if err != nil {
-			netLog.Warn("invalid bootnode peer info", "addr", addr, "err", err)
-			continue
-		}
-		if pi.ID == h.ID() {
-			continue // skip self
-		}
-		if err := h.Connect(ctx, *pi); err != nil {
-			netLog.Warn("failed to connect to bootnode",
-				"peer_id", pi.ID.String()[:16]+"...",
-				"err", err,
-			)
-			continue
-		}
-		netLog.Info("connected to bootnode",
-			"peer_id", pi.ID.String()[:16]+"...",
-		)
-	}
-	return nil
-}
-
 func loadOrGenerateKey(path string) (crypto.PrivKey, error) {
 	if path != "" {
 		data, err := os.ReadFile(path)
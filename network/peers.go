@@ -0,0 +1,551 @@
+package network
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/host"
+	lpnet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Peer manager defaults.
+const (
+	DefaultTargetPeers = 16
+	DefaultMaxPeers    = 24
+	DefaultBanDuration = 30 * time.Minute
+
+	dialTimeout     = 10 * time.Second
+	dialBackoffBase = 5 * time.Second
+	dialBackoffMax  = 2 * time.Minute
+	// maxDialFailures is how many failed dials in a row make us forget a
+	// peer that is not a bootnode.
+	maxDialFailures = 8
+)
+
+// Reputation bounds. Reputation decays towards zero with a half-life of
+// reputationHalfLife; peers falling to BanReputation are disconnected and
+// banned.
+const (
+	MaxReputation      = 100.0
+	BanReputation      = -50.0
+	reputationHalfLife = 10 * time.Minute
+)
+
+// PeerAction is a fault reported against a peer, named by how many of them
+// we tolerate before the peer is banned.
+type PeerAction int
+
+const (
+	// PeerActionFatal bans the peer at once.
+	PeerActionFatal PeerAction = iota
+	// PeerActionLowToleranceError is for invalid data, e.g. a block that
+	// fails the state transition. Five get a peer banned.
+	PeerActionLowToleranceError
+	// PeerActionMidToleranceError is for failed or timed out requests.
+	PeerActionMidToleranceError
+	// PeerActionHighToleranceError is for faults that are often innocent,
+	// e.g. serving a fork we cannot connect to.
+	PeerActionHighToleranceError
+)
+
+var peerActionNames = [...]string{"fatal", "low_tolerance", "mid_tolerance", "high_tolerance"}
+
+func (a PeerAction) String() string {
+	if int(a) < len(peerActionNames) {
+		return peerActionNames[a]
+	}
+	return "unknown"
+}
+
+func (a PeerAction) penalty() float64 {
+	switch a {
+	case PeerActionFatal:
+		return MaxReputation - BanReputation
+	case PeerActionLowToleranceError:
+		return 10
+	case PeerActionMidToleranceError:
+		return 5
+	default:
+		return 1
+	}
+}
+
+// knownPeer is what the manager remembers about a peer.
+type knownPeer struct {
+	addrs       []multiaddr.Multiaddr
+	bootnode    bool
+	reputation  float64
+	bannedUntil time.Time
+	// Dial backoff.
+	failures int
+	nextDial time.Time
+	dialing  bool
+}
+
+// PeerManager keeps the node connected to between TargetPeers and MaxPeers
+// peers. It redials bootnodes and previously connected peers with
+// exponential backoff, tracks a reputation per peer, and bans peers whose
+// reputation falls too low. It also acts as the host's connection gater so
+// banned peers cannot reconnect.
+type PeerManager struct {
+	TargetPeers int
+	MaxPeers    int
+	BanDuration time.Duration
+
+	// Goodbye, if set, tells a peer why we are about to disconnect it.
+	Goodbye func(pid peer.ID, reason reqresp.GoodbyeReason)
+
+	mu    sync.Mutex
+	host  host.Host
+	peers map[peer.ID]*knownPeer
+	now   func() time.Time
+
+	log *slog.Logger
+}
+
+var _ connmgr.ConnectionGater = (*PeerManager)(nil)
+
+// NewPeerManager creates a peer manager that keeps bootnodes, given as
+// multiaddrs with a /p2p component, connected.
+func NewPeerManager(bootnodes []string) *PeerManager {
+	m := &PeerManager{
+		TargetPeers: DefaultTargetPeers,
+		MaxPeers:    DefaultMaxPeers,
+		BanDuration: DefaultBanDuration,
+		peers:       make(map[peer.ID]*knownPeer),
+		now:         time.Now,
+		log:         logging.NewComponentLogger(logging.CompNetwork),
+	}
+	for _, addr := range bootnodes {
+		ma, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			m.log.Warn("invalid bootnode multiaddr", "addr", addr, "err", err)
+			continue
+		}
+		pi, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			m.log.Warn("invalid bootnode peer info", "addr", addr, "err", err)
+			continue
+		}
+		p := m.peerLocked(pi.ID)
+		p.bootnode = true
+		p.addrs = append(p.addrs, pi.Addrs...)
+	}
+	return m
+}
+
+// attach binds the manager to the host it gates.
+func (m *PeerManager) attach(h host.Host) {
+	m.mu.Lock()
+	m.host = h
+	delete(m.peers, h.ID())
+	m.mu.Unlock()
+
+	h.Network().Notify(&lpnet.NotifyBundle{
+		ConnectedF:    func(_ lpnet.Network, c lpnet.Conn) { m.onConnected(c.RemotePeer()) },
+		DisconnectedF: func(_ lpnet.Network, c lpnet.Conn) { m.onDisconnected(c.RemotePeer()) },
+	})
+}
+
+// Start dials the bootnodes and keeps the peer count in range until ctx is
+// done.
+func (m *PeerManager) Start(ctx context.Context) {
+	// Dial bootnodes before returning so the node starts with peers.
+	var wg sync.WaitGroup
+	for _, pid := range m.dialCandidates() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.dial(ctx, pid)
+		}()
+	}
+	wg.Wait()
+
+	go m.run(ctx)
+}
+
+func (m *PeerManager) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(types.SecondsPerSlot) * time.Second)
+	defer ticker.Stop()
+	last := m.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := m.now()
+			m.heartbeat(ctx, now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// heartbeat decays reputations, prunes excess peers and dials more peers
+// if we are below target.
+func (m *PeerManager) heartbeat(ctx context.Context, elapsed time.Duration) {
+	m.decay(elapsed)
+	m.pruneExcess()
+	for _, pid := range m.dialCandidates() {
+		go m.dial(ctx, pid)
+	}
+	m.updateMetrics()
+}
+
+// ReportPeer lowers the reputation of pid for action. Peers falling to
+// BanReputation are disconnected and banned for BanDuration.
+func (m *PeerManager) ReportPeer(pid peer.ID, action PeerAction, reason string) {
+	if pid == "" {
+		return
+	}
+	metrics.PeerReports.WithLabelValues(action.String()).Inc()
+
+	m.mu.Lock()
+	if m.host != nil && pid == m.host.ID() {
+		m.mu.Unlock()
+		return
+	}
+	p := m.peerLocked(pid)
+	if m.bannedLocked(p) {
+		m.mu.Unlock()
+		return
+	}
+	p.reputation = math.Max(p.reputation-action.penalty(), BanReputation)
+	reputation := p.reputation
+	ban := reputation <= BanReputation
+	if ban {
+		m.banLocked(p)
+	}
+	m.mu.Unlock()
+
+	m.log.Debug("peer reported",
+		"peer", pid.String(),
+		"action", action.String(),
+		"reason", reason,
+		"reputation", reputation,
+	)
+	if ban {
+		m.log.Warn("banning peer", "peer", pid.String(), "reason", reason, "duration", m.BanDuration)
+		go m.disconnect(pid, reqresp.GoodbyeBanned)
+	}
+}
+
+// Ban bans pid for BanDuration and disconnects it, telling it reason.
+// Banning a banned peer does nothing.
+func (m *PeerManager) Ban(pid peer.ID, reason reqresp.GoodbyeReason, why string) {
+	m.mu.Lock()
+	if m.host != nil && pid == m.host.ID() {
+		m.mu.Unlock()
+		return
+	}
+	p := m.peerLocked(pid)
+	if m.bannedLocked(p) {
+		m.mu.Unlock()
+		return
+	}
+	m.banLocked(p)
+	m.mu.Unlock()
+
+	m.log.Warn("banning peer", "peer", pid.String(), "reason", why, "duration", m.BanDuration)
+	go m.disconnect(pid, reason)
+}
+
+// Disconnect says goodbye to pid with reason and disconnects it. The peer
+// is not banned and may be redialed.
+func (m *PeerManager) Disconnect(pid peer.ID, reason reqresp.GoodbyeReason) {
+	go m.disconnect(pid, reason)
+}
+
+func (m *PeerManager) banLocked(p *knownPeer) {
+	p.reputation = BanReputation
+	p.bannedUntil = m.now().Add(m.BanDuration)
+}
+
+// Reputation returns the current reputation of pid.
+func (m *PeerManager) Reputation(pid peer.ID) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.peers[pid]; ok {
+		return p.reputation
+	}
+	return 0
+}
+
+// IsBanned reports whether pid is currently banned.
+func (m *PeerManager) IsBanned(pid peer.ID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.peers[pid]
+	return ok && m.bannedLocked(p)
+}
+
+func (m *PeerManager) getHost() host.Host {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.host
+}
+
+func (m *PeerManager) bannedLocked(p *knownPeer) bool {
+	return m.now().Before(p.bannedUntil)
+}
+
+// peerLocked returns the entry of pid, creating it if needed.
+func (m *PeerManager) peerLocked(pid peer.ID) *knownPeer {
+	p, ok := m.peers[pid]
+	if !ok {
+		p = &knownPeer{}
+		m.peers[pid] = p
+	}
+	return p
+}
+
+func (m *PeerManager) onConnected(pid peer.ID) {
+	m.mu.Lock()
+	p := m.peerLocked(pid)
+	p.failures = 0
+	p.nextDial = time.Time{}
+	banned := m.bannedLocked(p)
+	m.mu.Unlock()
+	if banned {
+		// Gating covers new connections; this catches a ban racing a dial.
+		go m.disconnect(pid, reqresp.GoodbyeBanned)
+	}
+}
+
+// onDisconnected remembers the addresses of pid so it can be redialed.
+func (m *PeerManager) onDisconnected(pid peer.ID) {
+	h := m.getHost()
+	if h.Network().Connectedness(pid) == lpnet.Connected {
+		return // another connection to the peer remains
+	}
+	addrs := h.Peerstore().Addrs(pid)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.peers[pid]; ok && len(addrs) > 0 && !p.bootnode {
+		p.addrs = addrs
+	}
+}
+
+// dialCandidates returns the peers to dial to get back to TargetPeers,
+// bootnodes first, then by reputation. The returned peers are marked as
+// being dialed.
+func (m *PeerManager) dialCandidates() []peer.ID {
+	connected := len(m.getHost().Network().Peers())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	want := m.TargetPeers - connected
+	if want <= 0 {
+		return nil
+	}
+
+	now := m.now()
+	var candidates []peer.ID
+	for pid, p := range m.peers {
+		if p.dialing || len(p.addrs) == 0 || m.bannedLocked(p) || now.Before(p.nextDial) {
+			continue
+		}
+		if m.host.Network().Connectedness(pid) == lpnet.Connected {
+			continue
+		}
+		candidates = append(candidates, pid)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := m.peers[candidates[i]], m.peers[candidates[j]]
+		if a.bootnode != b.bootnode {
+			return a.bootnode
+		}
+		return a.reputation > b.reputation
+	})
+	if len(candidates) > want {
+		candidates = candidates[:want]
+	}
+	for _, pid := range candidates {
+		m.peers[pid].dialing = true
+	}
+	return candidates
+}
+
+// dial connects to pid and updates its backoff.
+func (m *PeerManager) dial(ctx context.Context, pid peer.ID) {
+	m.mu.Lock()
+	p := m.peers[pid]
+	info := peer.AddrInfo{ID: pid, Addrs: append([]multiaddr.Multiaddr(nil), p.addrs...)}
+	bootnode := p.bootnode
+	m.mu.Unlock()
+
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	err := m.getHost().Connect(dialCtx, info)
+	cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.dialing = false
+	if err == nil {
+		metrics.PeerDials.WithLabelValues("success").Inc()
+		p.failures = 0
+		p.nextDial = time.Time{}
+		m.log.Info("connected to peer", "peer_id", pid.String()[:16]+"...", "bootnode", bootnode)
+		return
+	}
+	metrics.PeerDials.WithLabelValues("failure").Inc()
+	p.failures++
+	backoff := min(dialBackoffBase<<min(p.failures-1, 16), dialBackoffMax)
+	p.nextDial = m.now().Add(backoff)
+	m.log.Debug("peer dial failed",
+		"peer_id", pid.String()[:16]+"...",
+		"bootnode", bootnode,
+		"failures", p.failures,
+		"retry_in", backoff,
+		"err", err,
+	)
+	if !bootnode && p.failures >= maxDialFailures && p.reputation >= 0 && !m.bannedLocked(p) {
+		delete(m.peers, pid)
+	}
+}
+
+// decay moves every unbanned reputation towards zero. Peers we cannot
+// redial and hold nothing against are forgotten.
+func (m *PeerManager) decay(elapsed time.Duration) {
+	factor := math.Pow(0.5, elapsed.Seconds()/reputationHalfLife.Seconds())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for pid, p := range m.peers {
+		if m.bannedLocked(p) {
+			continue
+		}
+		p.reputation *= factor
+		if len(p.addrs) == 0 && !p.bootnode && p.reputation > -1 &&
+			m.host.Network().Connectedness(pid) != lpnet.Connected {
+			delete(m.peers, pid)
+		}
+	}
+}
+
+// pruneExcess disconnects the lowest-reputation peers above MaxPeers.
+// Bootnodes are kept.
+func (m *PeerManager) pruneExcess() {
+	connected := m.getHost().Network().Peers()
+	excess := len(connected) - m.MaxPeers
+	if excess <= 0 {
+		return
+	}
+	m.mu.Lock()
+	var prunable []peer.ID
+	for _, pid := range connected {
+		if p, ok := m.peers[pid]; !ok || !p.bootnode {
+			prunable = append(prunable, pid)
+		}
+	}
+	reputation := func(pid peer.ID) float64 {
+		if p, ok := m.peers[pid]; ok {
+			return p.reputation
+		}
+		return 0
+	}
+	sort.Slice(prunable, func(i, j int) bool { return reputation(prunable[i]) < reputation(prunable[j]) })
+	m.mu.Unlock()
+
+	for _, pid := range prunable[:min(excess, len(prunable))] {
+		m.log.Debug("pruning excess peer", "peer", pid.String(), "reputation", reputation(pid))
+		go m.disconnect(pid, reqresp.GoodbyeTooManyPeers)
+	}
+}
+
+// DisconnectAll says goodbye to every connected peer and disconnects it,
+// returning once all are done. It is used on shutdown.
+func (m *PeerManager) DisconnectAll(reason reqresp.GoodbyeReason) {
+	h := m.getHost()
+	if h == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, pid := range h.Network().Peers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.disconnect(pid, reason)
+		}()
+	}
+	wg.Wait()
+}
+
+// disconnect says goodbye to pid and closes its connections. The goodbye
+// is bounded by a short timeout, but callers on hot paths should still run
+// it in a goroutine.
+func (m *PeerManager) disconnect(pid peer.ID, reason reqresp.GoodbyeReason) {
+	h := m.getHost()
+	if h == nil {
+		return
+	}
+	if m.Goodbye != nil && h.Network().Connectedness(pid) == lpnet.Connected {
+		m.Goodbye(pid, reason)
+	}
+	if err := h.Network().ClosePeer(pid); err != nil {
+		m.log.Debug("disconnect failed", "peer", pid.String(), "err", err)
+	}
+}
+
+func (m *PeerManager) updateMetrics() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	banned := 0
+	for _, p := range m.peers {
+		if m.bannedLocked(p) {
+			banned++
+		}
+	}
+	metrics.BannedPeers.Set(float64(banned))
+	metrics.KnownPeers.Set(float64(len(m.peers)))
+}
+
+// InterceptPeerDial refuses to dial banned peers.
+func (m *PeerManager) InterceptPeerDial(pid peer.ID) bool {
+	return !m.IsBanned(pid)
+}
+
+// InterceptAddrDial allows all addresses.
+func (m *PeerManager) InterceptAddrDial(peer.ID, multiaddr.Multiaddr) bool {
+	return true
+}
+
+// InterceptAccept allows all inbound connections until the peer is known.
+func (m *PeerManager) InterceptAccept(lpnet.ConnMultiaddrs) bool {
+	return true
+}
+
+// InterceptSecured refuses banned peers, and inbound peers other than
+// bootnodes once MaxPeers are connected.
+func (m *PeerManager) InterceptSecured(dir lpnet.Direction, pid peer.ID, _ lpnet.ConnMultiaddrs) bool {
+	if m.IsBanned(pid) {
+		return false
+	}
+	h := m.getHost()
+	if dir != lpnet.DirInbound || h == nil {
+		return true
+	}
+	if h.Network().Connectedness(pid) == lpnet.Connected {
+		return true
+	}
+	m.mu.Lock()
+	p, ok := m.peers[pid]
+	bootnode := ok && p.bootnode
+	m.mu.Unlock()
+	return bootnode || len(h.Network().Peers()) < m.MaxPeers
+}
+
+// InterceptUpgraded allows all upgraded connections.
+func (m *PeerManager) InterceptUpgraded(lpnet.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
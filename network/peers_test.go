@@ -0,0 +1,177 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	lpnet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/network/reqresp"
+)
+
+func newTestHost(t *testing.T, bootnodes ...string) *Host {
+	t.Helper()
+	h, err := NewHost("/ip4/127.0.0.1/udp/0/quic-v1", "", bootnodes, nil)
+	if err != nil {
+		t.Fatalf("new host: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func bootnodeAddr(h *Host) string {
+	return fmt.Sprintf("%s/p2p/%s", h.P2P.Addrs()[0], h.P2P.ID())
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func connected(a, b *Host) bool {
+	return a.P2P.Network().Connectedness(b.P2P.ID()) == lpnet.Connected
+}
+
+func TestPeerManagerReconnectsBootnode(t *testing.T) {
+	boot := newTestHost(t)
+	h := newTestHost(t, bootnodeAddr(boot))
+
+	h.Peers.Start(h.Ctx)
+	if !connected(h, boot) {
+		t.Fatal("expected connection to bootnode after start")
+	}
+
+	// The bootnode drops us, e.g. because it restarted.
+	boot.P2P.Network().ClosePeer(h.P2P.ID())
+	waitFor(t, "disconnect", func() bool { return !connected(h, boot) })
+
+	h.Peers.heartbeat(h.Ctx, time.Second)
+	waitFor(t, "reconnect", func() bool { return connected(h, boot) })
+}
+
+func TestPeerManagerBansAfterRepeatedFaults(t *testing.T) {
+	h := newTestHost(t)
+	other := newTestHost(t)
+	if err := other.P2P.Connect(h.Ctx, peer.AddrInfo{ID: h.P2P.ID(), Addrs: h.P2P.Addrs()}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	waitFor(t, "connection", func() bool { return connected(h, other) })
+
+	pid := other.P2P.ID()
+	for i := 0; i < 4; i++ {
+		h.Peers.ReportPeer(pid, PeerActionLowToleranceError, "invalid block")
+	}
+	if h.Peers.IsBanned(pid) {
+		t.Fatalf("peer banned at reputation %v", h.Peers.Reputation(pid))
+	}
+	h.Peers.ReportPeer(pid, PeerActionLowToleranceError, "invalid block")
+	if !h.Peers.IsBanned(pid) {
+		t.Fatalf("peer not banned at reputation %v", h.Peers.Reputation(pid))
+	}
+	waitFor(t, "disconnect", func() bool { return !connected(h, other) })
+
+	// A banned peer cannot reconnect.
+	other.P2P.Peerstore().RemovePeer(h.P2P.ID())
+	if err := other.P2P.Connect(h.Ctx, peer.AddrInfo{ID: h.P2P.ID(), Addrs: h.P2P.Addrs()}); err == nil {
+		waitFor(t, "gated connection to close", func() bool { return !connected(h, other) })
+	}
+
+	// Bans expire.
+	h.Peers.now = func() time.Time { return time.Now().Add(DefaultBanDuration + time.Minute) }
+	if h.Peers.IsBanned(pid) {
+		t.Fatal("ban should expire after BanDuration")
+	}
+}
+
+func TestPeerManagerSaysGoodbyeOnShutdown(t *testing.T) {
+	h := newTestHost(t)
+	other := newTestHost(t)
+	if err := other.P2P.Connect(h.Ctx, peer.AddrInfo{ID: h.P2P.ID(), Addrs: h.P2P.Addrs()}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	waitFor(t, "connection", func() bool { return connected(h, other) })
+
+	var reasons []reqresp.GoodbyeReason
+	h.Peers.Goodbye = func(pid peer.ID, reason reqresp.GoodbyeReason) {
+		if pid != other.P2P.ID() {
+			t.Errorf("goodbye to %s, want %s", pid, other.P2P.ID())
+		}
+		reasons = append(reasons, reason)
+	}
+	h.Peers.DisconnectAll(reqresp.GoodbyeClientShutdown)
+	if len(reasons) != 1 || reasons[0] != reqresp.GoodbyeClientShutdown {
+		t.Fatalf("goodbyes = %v, want [%s]", reasons, reqresp.GoodbyeClientShutdown)
+	}
+	if connected(h, other) {
+		t.Fatal("peer still connected after DisconnectAll")
+	}
+}
+
+func TestPeerManagerReputationDecays(t *testing.T) {
+	m := NewPeerManager(nil)
+	m.host = newTestHost(t).P2P
+	m.ReportPeer("peer", PeerActionMidToleranceError, "timeout")
+	m.ReportPeer("peer", PeerActionMidToleranceError, "timeout")
+	if got := m.Reputation("peer"); got != -10 {
+		t.Fatalf("reputation = %v, want -10", got)
+	}
+	m.decay(reputationHalfLife)
+	if got := m.Reputation("peer"); got != -5 {
+		t.Fatalf("reputation after half-life = %v, want -5", got)
+	}
+
+	m.ReportPeer("fatal", PeerActionFatal, "bad")
+	if !m.IsBanned("fatal") {
+		t.Fatal("fatal action should ban at once")
+	}
+}
+
+func TestPeerManagerDialBackoff(t *testing.T) {
+	// Nothing listens on the bootnode address.
+	ghost := newTestHost(t)
+	addr := bootnodeAddr(ghost)
+	ghost.Close()
+
+	h := newTestHost(t, addr)
+	pid := ghost.P2P.ID()
+	h.Peers.Start(h.Ctx)
+
+	h.Peers.mu.Lock()
+	p := h.Peers.peers[pid]
+	failures, next := p.failures, p.nextDial
+	h.Peers.mu.Unlock()
+	if failures != 1 {
+		t.Fatalf("failures = %d, want 1", failures)
+	}
+	if wait := time.Until(next); wait <= 0 || wait > dialBackoffBase {
+		t.Fatalf("next dial in %v, want within %v", wait, dialBackoffBase)
+	}
+	if got := h.Peers.dialCandidates(); len(got) != 0 {
+		t.Fatalf("dial candidates during backoff = %v, want none", got)
+	}
+}
+
+func TestPeerManagerRejectsInboundAboveMax(t *testing.T) {
+	h := newTestHost(t)
+	h.Peers.MaxPeers = 1
+	first, second := newTestHost(t), newTestHost(t)
+
+	if err := first.P2P.Connect(h.Ctx, peer.AddrInfo{ID: h.P2P.ID(), Addrs: h.P2P.Addrs()}); err != nil {
+		t.Fatalf("first connect: %v", err)
+	}
+	waitFor(t, "first connection", func() bool { return connected(h, first) })
+
+	second.P2P.Connect(h.Ctx, peer.AddrInfo{ID: h.P2P.ID(), Addrs: h.P2P.Addrs()})
+	time.Sleep(100 * time.Millisecond)
+	if connected(h, second) {
+		t.Fatal("inbound peer above MaxPeers should be refused")
+	}
+}
@@ -0,0 +1,177 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// BlocksByRangeProtocol requests canonical blocks by slot range.
+const BlocksByRangeProtocol = "/leanconsensus/req/blocks_by_range/1/ssz_snappy"
+
+// BlocksByRangeRequest asks for up to Count canonical blocks at slots
+// StartSlot, StartSlot+Step, StartSlot+2*Step, ... Skipped slots have no
+// block and produce no response chunk.
+type BlocksByRangeRequest struct {
+	StartSlot uint64
+	Count     uint64
+	Step      uint64
+}
+
+const blocksByRangeRequestLen = 24
+
+// Slots returns the slots covered by the request, capped at
+// types.MaxRequestBlocks.
+func (r BlocksByRangeRequest) Slots() []uint64 {
+	count := min(r.Count, types.MaxRequestBlocks)
+	slots := make([]uint64, 0, count)
+	for i := uint64(0); i < count; i++ {
+		slots = append(slots, r.StartSlot+i*r.Step)
+	}
+	return slots
+}
+
+// lastSlot returns the last slot covered by the request, capped at
+// types.MaxRequestBlocks slots. It reports false if the slot overflows.
+// Count must not be zero.
+func (r BlocksByRangeRequest) lastSlot() (uint64, bool) {
+	hi, span := bits.Mul64(min(r.Count, types.MaxRequestBlocks)-1, r.Step)
+	last, carry := bits.Add64(r.StartSlot, span, 0)
+	return last, hi == 0 && carry == 0
+}
+
+func handleBlocksByRange(s network.Stream, handler *ReqRespHandler) {
+	if handler.OnBlocksByRange == nil {
+		return
+	}
+	req, err := readBlocksByRangeRequest(s)
+	if err != nil {
+		writeErrorResponse(s, ResponseInvalidRequest, err.Error())
+		return
+	}
+	req.Count = min(req.Count, types.MaxRequestBlocks)
+	if !allow(s, handler, BlocksByRangeProtocol, req.Count) {
+		return
+	}
+	for _, block := range handler.OnBlocksByRange(req) {
+		if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
+			return
+		}
+		if err := writeSignedBlock(s, block); err != nil {
+			return
+		}
+	}
+}
+
+// RequestBlocksByRange requests a range of canonical blocks from a peer. The
+// response is checked to be in ascending slot order and within the range.
+func RequestBlocksByRange(ctx context.Context, h host.Host, pid peer.ID, req BlocksByRangeRequest) ([]*types.SignedBlockWithAttestation, error) {
+	if req.Count == 0 || req.Step == 0 {
+		return nil, fmt.Errorf("invalid range request: count=%d step=%d", req.Count, req.Step)
+	}
+	if req.Count > types.MaxRequestBlocks {
+		return nil, fmt.Errorf("range request count %d exceeds %d", req.Count, types.MaxRequestBlocks)
+	}
+	endSlot, ok := req.lastSlot()
+	if !ok {
+		return nil, fmt.Errorf("range request overflows: start=%d count=%d step=%d", req.StartSlot, req.Count, req.Step)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, protocol.ID(BlocksByRangeProtocol))
+	if err != nil {
+		return nil, fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+
+	if err := writeBlocksByRangeRequest(s, req); err != nil {
+		return nil, fmt.Errorf("write request: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		return nil, fmt.Errorf("close write: %w", err)
+	}
+
+	var blocks []*types.SignedBlockWithAttestation
+	for {
+		code, err := readResponseCode(s)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return blocks, fmt.Errorf("read response code: %w", err)
+		}
+		if code != ResponseSuccess {
+			msg, _ := readSnappyFrame(s)
+			return blocks, responseError(code, msg)
+		}
+		if uint64(len(blocks)) == req.Count {
+			return blocks, fmt.Errorf("peer sent more than %d blocks", req.Count)
+		}
+		data, err := readSnappyFrame(s)
+		if err != nil {
+			return blocks, fmt.Errorf("read block: %w", err)
+		}
+		block := new(types.SignedBlockWithAttestation)
+		if err := block.UnmarshalSSZ(data); err != nil {
+			return blocks, fmt.Errorf("decode block: %w", err)
+		}
+		slot := block.Message.Block.Slot
+		if slot < req.StartSlot || slot > endSlot || (slot-req.StartSlot)%req.Step != 0 {
+			return blocks, fmt.Errorf("block at slot %d outside requested range", slot)
+		}
+		if n := len(blocks); n > 0 && slot <= blocks[n-1].Message.Block.Slot {
+			return blocks, fmt.Errorf("blocks out of order at slot %d", slot)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func readBlocksByRangeRequest(r io.Reader) (BlocksByRangeRequest, error) {
+	data, err := readSnappyFrameLimit(r, blocksByRangeRequestLen)
+	if err != nil {
+		return BlocksByRangeRequest{}, err
+	}
+	if len(data) != blocksByRangeRequestLen {
+		return BlocksByRangeRequest{}, fmt.Errorf("invalid blocks_by_range length: %d", len(data))
+	}
+	req := BlocksByRangeRequest{
+		StartSlot: binary.LittleEndian.Uint64(data[0:8]),
+		Count:     binary.LittleEndian.Uint64(data[8:16]),
+		Step:      binary.LittleEndian.Uint64(data[16:24]),
+	}
+	if req.Count == 0 || req.Step == 0 {
+		return BlocksByRangeRequest{}, fmt.Errorf("invalid range: count=%d step=%d", req.Count, req.Step)
+	}
+	if _, ok := req.lastSlot(); !ok {
+		return BlocksByRangeRequest{}, fmt.Errorf("range overflows: start=%d count=%d step=%d", req.StartSlot, req.Count, req.Step)
+	}
+	return req, nil
+}
+
+func writeBlocksByRangeRequest(w io.Writer, req BlocksByRangeRequest) error {
+	var buf [blocksByRangeRequestLen]byte
+	binary.LittleEndian.PutUint64(buf[0:8], req.StartSlot)
+	binary.LittleEndian.PutUint64(buf[8:16], req.Count)
+	binary.LittleEndian.PutUint64(buf[16:24], req.Step)
+	return writeSnappyFrame(w, buf[:])
+}
+
+// writeErrorResponse writes a non-success code followed by an error message.
+func writeErrorResponse(w io.Writer, code byte, msg string) {
+	if _, err := w.Write([]byte{code}); err != nil {
+		return
+	}
+	writeSnappyFrame(w, []byte(msg))
+}
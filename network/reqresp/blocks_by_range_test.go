@@ -0,0 +1,169 @@
+package reqresp
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// newTestHosts starts two connected in-process hosts. The first serves
+// handler; the second is the client.
+func newTestHosts(t *testing.T, handler *ReqRespHandler) (server, client host.Host) {
+	t.Helper()
+	newHost := func() host.Host {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/udp/0/quic-v1"))
+		if err != nil {
+			t.Fatalf("new host: %v", err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+	server, client = newHost(), newHost()
+	RegisterReqResp(server, handler)
+	if err := client.Connect(context.Background(), peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return server, client
+}
+
+func makeRangeBlock(slot uint64) *types.SignedBlockWithAttestation {
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block: &types.Block{
+				Slot: slot,
+				Body: &types.BlockBody{Attestations: []*types.Attestation{}},
+			},
+			ProposerAttestation: &types.Attestation{
+				Data: &types.AttestationData{
+					Head:   &types.Checkpoint{},
+					Target: &types.Checkpoint{},
+					Source: &types.Checkpoint{},
+				},
+			},
+		},
+		Signature: [][3116]byte{{}},
+	}
+}
+
+// chainHandler serves blocks at every slot in chain except skipped ones.
+func chainHandler(headSlot uint64, skipped map[uint64]bool) *ReqRespHandler {
+	return &ReqRespHandler{
+		OnBlocksByRange: func(req BlocksByRangeRequest) []*types.SignedBlockWithAttestation {
+			var blocks []*types.SignedBlockWithAttestation
+			for _, slot := range req.Slots() {
+				if slot <= headSlot && !skipped[slot] {
+					blocks = append(blocks, makeRangeBlock(slot))
+				}
+			}
+			return blocks
+		},
+	}
+}
+
+func TestBlocksByRangeRequestRoundTrip(t *testing.T) {
+	server, client := newTestHosts(t, chainHandler(100, map[uint64]bool{5: true}))
+
+	blocks, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: 3, Count: 5, Step: 1})
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	want := []uint64{3, 4, 6, 7}
+	if len(blocks) != len(want) {
+		t.Fatalf("blocks = %d, want %d", len(blocks), len(want))
+	}
+	for i, b := range blocks {
+		if b.Message.Block.Slot != want[i] {
+			t.Fatalf("block %d slot = %d, want %d", i, b.Message.Block.Slot, want[i])
+		}
+	}
+}
+
+func TestBlocksByRangeStep(t *testing.T) {
+	server, client := newTestHosts(t, chainHandler(100, nil))
+
+	blocks, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: 10, Count: 4, Step: 3})
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	want := []uint64{10, 13, 16, 19}
+	if len(blocks) != len(want) {
+		t.Fatalf("blocks = %d, want %d", len(blocks), len(want))
+	}
+	for i, b := range blocks {
+		if b.Message.Block.Slot != want[i] {
+			t.Fatalf("block %d slot = %d, want %d", i, b.Message.Block.Slot, want[i])
+		}
+	}
+}
+
+func TestBlocksByRangeClientRejectsOutOfRange(t *testing.T) {
+	server, client := newTestHosts(t, &ReqRespHandler{
+		OnBlocksByRange: func(req BlocksByRangeRequest) []*types.SignedBlockWithAttestation {
+			return []*types.SignedBlockWithAttestation{makeRangeBlock(req.StartSlot + req.Count)}
+		},
+	})
+
+	_, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: 1, Count: 2, Step: 1})
+	if err == nil || !strings.Contains(err.Error(), "outside requested range") {
+		t.Fatalf("err = %v, want out of range error", err)
+	}
+}
+
+func TestBlocksByRangeLimits(t *testing.T) {
+	server, client := newTestHosts(t, chainHandler(1<<20, nil))
+
+	if _, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: 0, Count: types.MaxRequestBlocks + 1, Step: 1}); err == nil {
+		t.Fatal("expected client to reject count above MaxRequestBlocks")
+	}
+	if _, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: 0, Count: 1, Step: 0}); err == nil {
+		t.Fatal("expected client to reject zero step")
+	}
+	if _, err := RequestBlocksByRange(context.Background(), client, server.ID(),
+		BlocksByRangeRequest{StartSlot: math.MaxUint64 - 1, Count: 2, Step: 2}); err == nil {
+		t.Fatal("expected client to reject a range past the last slot")
+	}
+
+	slots := BlocksByRangeRequest{StartSlot: 0, Count: types.MaxRequestBlocks * 2, Step: 1}.Slots()
+	if len(slots) != types.MaxRequestBlocks {
+		t.Fatalf("slots = %d, want capped at %d", len(slots), types.MaxRequestBlocks)
+	}
+}
+
+func TestBlocksByRangeServerRejectsInvalidRequest(t *testing.T) {
+	server, client := newTestHosts(t, chainHandler(100, nil))
+
+	for _, req := range []BlocksByRangeRequest{
+		{StartSlot: 1, Count: 1, Step: 0},
+		{StartSlot: 0, Count: 3, Step: math.MaxUint64/2 + 1},
+	} {
+		s, err := client.NewStream(context.Background(), server.ID(), BlocksByRangeProtocol)
+		if err != nil {
+			t.Fatalf("open stream: %v", err)
+		}
+		if err := writeBlocksByRangeRequest(s, req); err != nil {
+			t.Fatalf("write request: %v", err)
+		}
+		s.CloseWrite()
+
+		code, err := readResponseCode(s)
+		if err != nil {
+			t.Fatalf("read response code: %v", err)
+		}
+		if code != ResponseInvalidRequest {
+			t.Fatalf("%+v: code = 0x%02x, want 0x%02x", req, code, ResponseInvalidRequest)
+		}
+		s.Close()
+	}
+}
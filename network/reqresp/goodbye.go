@@ -0,0 +1,94 @@
+package reqresp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// GoodbyeProtocol tells a peer we are about to disconnect and why.
+const GoodbyeProtocol = "/leanconsensus/req/goodbye/1/ssz_snappy"
+
+// goodbyeTimeout bounds how long a goodbye may delay a disconnect.
+const goodbyeTimeout = 2 * time.Second
+
+// GoodbyeReason is the reason code sent with a goodbye. Codes follow the
+// Ethereum consensus networking spec.
+type GoodbyeReason uint64
+
+// Goodbye reason codes.
+const (
+	GoodbyeClientShutdown    GoodbyeReason = 1
+	GoodbyeIrrelevantNetwork GoodbyeReason = 2
+	GoodbyeFaultError        GoodbyeReason = 3
+	GoodbyeUnableToVerify    GoodbyeReason = 128
+	GoodbyeTooManyPeers      GoodbyeReason = 129
+	GoodbyeBadScore          GoodbyeReason = 250
+	GoodbyeBanned            GoodbyeReason = 251
+)
+
+func (r GoodbyeReason) String() string {
+	switch r {
+	case GoodbyeClientShutdown:
+		return "client_shutdown"
+	case GoodbyeIrrelevantNetwork:
+		return "irrelevant_network"
+	case GoodbyeFaultError:
+		return "fault_error"
+	case GoodbyeUnableToVerify:
+		return "unable_to_verify_network"
+	case GoodbyeTooManyPeers:
+		return "too_many_peers"
+	case GoodbyeBadScore:
+		return "bad_score"
+	case GoodbyeBanned:
+		return "banned"
+	default:
+		return "unknown"
+	}
+}
+
+func handleGoodbye(s network.Stream, handler *ReqRespHandler) {
+	reason, err := readUint64(s)
+	if err != nil {
+		return
+	}
+	if !allow(s, handler, GoodbyeProtocol, 1) {
+		return
+	}
+	if handler.OnGoodbye != nil {
+		handler.OnGoodbye(s.Conn().RemotePeer(), GoodbyeReason(reason))
+	}
+}
+
+// SendGoodbye tells a peer we are disconnecting for reason. It waits
+// briefly for the peer to close the stream so the message is delivered
+// before the caller closes the connection; goodbye has no response.
+func SendGoodbye(ctx context.Context, h host.Host, pid peer.ID, reason GoodbyeReason) error {
+	ctx, cancel := context.WithTimeout(ctx, goodbyeTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, protocol.ID(GoodbyeProtocol))
+	if err != nil {
+		return fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+
+	if err := writeUint64(s, uint64(reason)); err != nil {
+		return fmt.Errorf("write goodbye: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		return fmt.Errorf("close write: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetReadDeadline(deadline)
+	}
+	io.Copy(io.Discard, s)
+	return nil
+}
@@ -0,0 +1,32 @@
+package reqresp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestSendGoodbye(t *testing.T) {
+	type goodbye struct {
+		from   peer.ID
+		reason GoodbyeReason
+	}
+	got := make(chan goodbye, 1)
+	server, client := newTestHosts(t, &ReqRespHandler{
+		OnGoodbye: func(from peer.ID, reason GoodbyeReason) { got <- goodbye{from, reason} },
+	})
+
+	if err := SendGoodbye(context.Background(), client, server.ID(), GoodbyeTooManyPeers); err != nil {
+		t.Fatalf("goodbye: %v", err)
+	}
+	// SendGoodbye waits for the server to finish, so the handler has run.
+	select {
+	case g := <-got:
+		if g.from != client.ID() || g.reason != GoodbyeTooManyPeers {
+			t.Fatalf("goodbye = (%s, %s), want (%s, %s)", g.from, g.reason, client.ID(), GoodbyeTooManyPeers)
+		}
+	default:
+		t.Fatal("goodbye not delivered before SendGoodbye returned")
+	}
+}
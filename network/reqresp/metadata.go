@@ -0,0 +1,180 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Housekeeping protocols for liveness and peer metadata.
+const (
+	PingProtocol     = "/leanconsensus/req/ping/1/ssz_snappy"
+	MetadataProtocol = "/leanconsensus/req/metadata/1/ssz_snappy"
+)
+
+// MaxClientLength bounds the client info carried in Metadata.
+const MaxClientLength = 64
+
+// Metadata describes a node. SeqNumber increases whenever the metadata
+// changes, so peers learn from a ping whether to fetch it again.
+type Metadata struct {
+	SeqNumber uint64
+	// Client identifies the implementation, e.g. "gean/v0.1.0/linux-amd64".
+	Client string
+}
+
+// metadataFixedLen is the SSZ fixed part: seq_number and the offset of
+// the client field.
+const metadataFixedLen = 12
+
+func handlePing(s network.Stream, handler *ReqRespHandler) {
+	if handler.OnMetadata == nil {
+		return
+	}
+	seq, err := readUint64(s)
+	if err != nil {
+		writeErrorResponse(s, ResponseInvalidRequest, err.Error())
+		return
+	}
+	if !allow(s, handler, PingProtocol, 1) {
+		return
+	}
+	if handler.OnPing != nil {
+		handler.OnPing(s.Conn().RemotePeer(), seq)
+	}
+	if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
+		return
+	}
+	writeUint64(s, handler.OnMetadata().SeqNumber)
+}
+
+func handleMetadata(s network.Stream, handler *ReqRespHandler) {
+	if handler.OnMetadata == nil {
+		return
+	}
+	if !allow(s, handler, MetadataProtocol, 1) {
+		return
+	}
+	if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
+		return
+	}
+	writeMetadata(s, handler.OnMetadata())
+}
+
+// RequestPing sends our metadata sequence number to a peer and returns
+// theirs.
+func RequestPing(ctx context.Context, h host.Host, pid peer.ID, seq uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, protocol.ID(PingProtocol))
+	if err != nil {
+		return 0, fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+
+	if err := writeUint64(s, seq); err != nil {
+		return 0, fmt.Errorf("write ping: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		return 0, fmt.Errorf("close write: %w", err)
+	}
+	if err := readSuccess(s); err != nil {
+		return 0, err
+	}
+	peerSeq, err := readUint64(s)
+	if err != nil {
+		return 0, fmt.Errorf("read response: %w", err)
+	}
+	return peerSeq, nil
+}
+
+// RequestMetadata fetches a peer's metadata. The request has no body.
+func RequestMetadata(ctx context.Context, h host.Host, pid peer.ID) (*Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, protocol.ID(MetadataProtocol))
+	if err != nil {
+		return nil, fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+
+	if err := s.CloseWrite(); err != nil {
+		return nil, fmt.Errorf("close write: %w", err)
+	}
+	if err := readSuccess(s); err != nil {
+		return nil, err
+	}
+	md, err := readMetadata(s)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	return &md, nil
+}
+
+// readSuccess reads a response code, returning the peer's error message
+// for anything but success.
+func readSuccess(r io.Reader) error {
+	code, err := readResponseCode(r)
+	if err != nil {
+		return fmt.Errorf("read response code: %w", err)
+	}
+	if code != ResponseSuccess {
+		msg, _ := readSnappyFrame(r)
+		return responseError(code, msg)
+	}
+	return nil
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	data, err := readSnappyFrameLimit(r, 8)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid uint64 length: %d", len(data))
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func writeUint64(w io.Writer, v uint64) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return writeSnappyFrame(w, buf[:])
+}
+
+func readMetadata(r io.Reader) (Metadata, error) {
+	data, err := readSnappyFrameLimit(r, metadataFixedLen+MaxClientLength)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if len(data) < metadataFixedLen || len(data) > metadataFixedLen+MaxClientLength {
+		return Metadata{}, fmt.Errorf("invalid metadata length: %d", len(data))
+	}
+	if offset := binary.LittleEndian.Uint32(data[8:12]); offset != metadataFixedLen {
+		return Metadata{}, fmt.Errorf("invalid metadata client offset: %d", offset)
+	}
+	return Metadata{
+		SeqNumber: binary.LittleEndian.Uint64(data[0:8]),
+		Client:    string(data[metadataFixedLen:]),
+	}, nil
+}
+
+func writeMetadata(w io.Writer, md Metadata) error {
+	client := md.Client
+	if len(client) > MaxClientLength {
+		client = client[:MaxClientLength]
+	}
+	buf := make([]byte, metadataFixedLen, metadataFixedLen+len(client))
+	binary.LittleEndian.PutUint64(buf[0:8], md.SeqNumber)
+	binary.LittleEndian.PutUint32(buf[8:12], metadataFixedLen)
+	buf = append(buf, client...)
+	return writeSnappyFrame(w, buf)
+}
@@ -0,0 +1,68 @@
+package reqresp
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	in := Metadata{SeqNumber: 7, Client: "gean/v0.1.0/linux-amd64"}
+	var buf bytes.Buffer
+	if err := writeMetadata(&buf, in); err != nil {
+		t.Fatalf("writeMetadata: %v", err)
+	}
+	out, err := readMetadata(&buf)
+	if err != nil {
+		t.Fatalf("readMetadata: %v", err)
+	}
+	if out != in {
+		t.Fatalf("metadata = %+v, want %+v", out, in)
+	}
+
+	buf.Reset()
+	if err := writeMetadata(&buf, Metadata{Client: strings.Repeat("x", 2*MaxClientLength)}); err != nil {
+		t.Fatalf("writeMetadata: %v", err)
+	}
+	out, err = readMetadata(&buf)
+	if err != nil {
+		t.Fatalf("readMetadata: %v", err)
+	}
+	if len(out.Client) != MaxClientLength {
+		t.Fatalf("client length = %d, want %d", len(out.Client), MaxClientLength)
+	}
+}
+
+func TestPingAndMetadata(t *testing.T) {
+	var pingedBy peer.ID
+	var pingedSeq uint64
+	server, client := newTestHosts(t, &ReqRespHandler{
+		OnMetadata: func() Metadata { return Metadata{SeqNumber: 3, Client: "server"} },
+		OnPing: func(from peer.ID, seq uint64) {
+			pingedBy, pingedSeq = from, seq
+		},
+	})
+	ctx := context.Background()
+
+	seq, err := RequestPing(ctx, client, server.ID(), 5)
+	if err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if seq != 3 {
+		t.Fatalf("ping seq = %d, want 3", seq)
+	}
+	if pingedBy != client.ID() || pingedSeq != 5 {
+		t.Fatalf("OnPing got (%s, %d), want (%s, 5)", pingedBy, pingedSeq, client.ID())
+	}
+
+	md, err := RequestMetadata(ctx, client, server.ID())
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if md.SeqNumber != 3 || md.Client != "server" {
+		t.Fatalf("metadata = %+v, want seq 3 client server", md)
+	}
+}
@@ -0,0 +1,179 @@
+package reqresp
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// DefaultMaxStreamsPerPeer caps the inbound streams a peer may have open
+// at once across all protocols.
+const DefaultMaxStreamsPerPeer = 8
+
+// bucketPruneThreshold is the bucket count that triggers dropping full,
+// and so idle, buckets.
+const bucketPruneThreshold = 1024
+
+// Quota allows Capacity units per Period. Units are blocks for the block
+// protocols and requests for the others. Unused units accumulate up to
+// Capacity, so a quiet peer may burst.
+type Quota struct {
+	Capacity float64
+	Period   time.Duration
+}
+
+// DefaultQuotas returns the per-peer quota of each protocol. The block
+// protocols allow at least one request of the largest valid size.
+func DefaultQuotas() map[string]Quota {
+	return map[string]Quota{
+		StatusProtocol:        {Capacity: 5, Period: 15 * time.Second},
+		PingProtocol:          {Capacity: 2, Period: 10 * time.Second},
+		MetadataProtocol:      {Capacity: 2, Period: 15 * time.Second},
+		GoodbyeProtocol:       {Capacity: 1, Period: 10 * time.Second},
+		BlocksByRootProtocol:  {Capacity: types.MaxRequestBlocks, Period: 10 * time.Second},
+		BlocksByRangeProtocol: {Capacity: types.MaxRequestBlocks, Period: 10 * time.Second},
+	}
+}
+
+// RateLimiter limits the requests each peer may make of us with a token
+// bucket per peer and protocol, and caps the streams a peer may have open
+// at once.
+type RateLimiter struct {
+	MaxStreamsPerPeer int
+
+	quotas map[string]Quota
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+	streams map[peer.ID]int
+	now     func() time.Time
+}
+
+type bucketKey struct {
+	peer     peer.ID
+	protocol string
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a limiter enforcing quotas, keyed by protocol ID.
+// Protocols without a quota are not rate limited.
+func NewRateLimiter(quotas map[string]Quota) *RateLimiter {
+	return &RateLimiter{
+		MaxStreamsPerPeer: DefaultMaxStreamsPerPeer,
+		quotas:            quotas,
+		buckets:           make(map[bucketKey]*bucket),
+		streams:           make(map[peer.ID]int),
+		now:               time.Now,
+	}
+}
+
+// Allow takes cost units from the bucket of pid and proto and reports
+// whether there were enough. Requests costing more than the whole quota
+// are never allowed.
+func (l *RateLimiter) Allow(pid peer.ID, proto string, cost uint64) bool {
+	q, ok := l.quotas[proto]
+	if !ok || q.Capacity <= 0 || q.Period <= 0 {
+		return true
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buckets) >= bucketPruneThreshold {
+		l.pruneLocked(now)
+	}
+	key := bucketKey{pid, proto}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: q.Capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(q.Capacity, b.tokens+now.Sub(b.updated).Seconds()*q.Capacity/q.Period.Seconds())
+	b.updated = now
+	if float64(cost) > b.tokens {
+		return false
+	}
+	b.tokens -= float64(cost)
+	return true
+}
+
+// acquireStream reserves one of pid's concurrent streams.
+func (l *RateLimiter) acquireStream(pid peer.ID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.MaxStreamsPerPeer > 0 && l.streams[pid] >= l.MaxStreamsPerPeer {
+		return false
+	}
+	l.streams[pid]++
+	return true
+}
+
+func (l *RateLimiter) releaseStream(pid peer.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.streams[pid]--; l.streams[pid] <= 0 {
+		delete(l.streams, pid)
+	}
+}
+
+// pruneLocked drops buckets that have refilled completely; a new bucket
+// starts full, so forgetting them changes nothing.
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	for key, b := range l.buckets {
+		q := l.quotas[key.protocol]
+		if now.Sub(b.updated) >= q.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// serveLimited runs serve for a stream unless the peer already has too
+// many streams open.
+func serveLimited(s network.Stream, handler *ReqRespHandler, proto string, serve func(network.Stream, *ReqRespHandler)) {
+	if l := handler.RateLimiter; l != nil {
+		pid := s.Conn().RemotePeer()
+		if !l.acquireStream(pid) {
+			throttle(s, proto, "streams")
+			return
+		}
+		defer l.releaseStream(pid)
+	}
+	serve(s, handler)
+}
+
+// allow charges a request of cost units to the stream's peer. Requests over
+// the limit get ResponseResourceUnavailable.
+func allow(s network.Stream, handler *ReqRespHandler, proto string, cost uint64) bool {
+	if handler.RateLimiter == nil || handler.RateLimiter.Allow(s.Conn().RemotePeer(), proto, cost) {
+		return true
+	}
+	throttle(s, proto, "rate")
+	return false
+}
+
+func throttle(s network.Stream, proto, reason string) {
+	metrics.ReqRespThrottled.WithLabelValues(protocolName(proto), reason).Inc()
+	if proto != GoodbyeProtocol {
+		writeErrorResponse(s, ResponseResourceUnavailable, "rate limited")
+	}
+}
+
+// protocolName returns the message name of a protocol ID, e.g. "status"
+// for StatusProtocol.
+func protocolName(proto string) string {
+	parts := strings.Split(proto, "/")
+	if len(parts) > 3 {
+		return parts[3]
+	}
+	return proto
+}
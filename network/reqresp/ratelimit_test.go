@@ -0,0 +1,134 @@
+package reqresp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/types"
+)
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(map[string]Quota{PingProtocol: {Capacity: 2, Period: 10 * time.Second}})
+	l.now = func() time.Time { return now }
+	a, b := peer.ID("a"), peer.ID("b")
+
+	if !l.Allow(a, PingProtocol, 1) || !l.Allow(a, PingProtocol, 1) {
+		t.Fatal("burst up to capacity should be allowed")
+	}
+	if l.Allow(a, PingProtocol, 1) {
+		t.Fatal("request over capacity should be throttled")
+	}
+	if !l.Allow(b, PingProtocol, 1) {
+		t.Fatal("peers should have separate buckets")
+	}
+
+	now = now.Add(5 * time.Second)
+	if !l.Allow(a, PingProtocol, 1) {
+		t.Fatal("half a period should refill one token")
+	}
+	if l.Allow(a, PingProtocol, 1) {
+		t.Fatal("bucket should be empty again")
+	}
+
+	now = now.Add(time.Hour)
+	if l.Allow(a, PingProtocol, 3) {
+		t.Fatal("cost above capacity should never be allowed")
+	}
+	if !l.Allow(a, StatusProtocol, 100) {
+		t.Fatal("protocol without quota should not be limited")
+	}
+}
+
+func TestDefaultQuotasAllowLargestRequests(t *testing.T) {
+	l := NewRateLimiter(DefaultQuotas())
+	for _, proto := range []string{BlocksByRootProtocol, BlocksByRangeProtocol} {
+		if !l.Allow(peer.ID("a"), proto, types.MaxRequestBlocks) {
+			t.Fatalf("%s: request for %d blocks throttled", proto, types.MaxRequestBlocks)
+		}
+	}
+}
+
+func TestRateLimitedRequests(t *testing.T) {
+	limiter := NewRateLimiter(map[string]Quota{
+		PingProtocol:          {Capacity: 1, Period: time.Hour},
+		BlocksByRangeProtocol: {Capacity: 10, Period: time.Hour},
+	})
+	handler := chainHandler(100, nil)
+	handler.OnMetadata = func() Metadata { return Metadata{} }
+	handler.RateLimiter = limiter
+	server, client := newTestHosts(t, handler)
+	ctx := context.Background()
+
+	if _, err := RequestPing(ctx, client, server.ID(), 0); err != nil {
+		t.Fatalf("first ping: %v", err)
+	}
+	if _, err := RequestPing(ctx, client, server.ID(), 0); !errors.Is(err, ErrResourceUnavailable) {
+		t.Fatalf("second ping err = %v, want %v", err, ErrResourceUnavailable)
+	}
+
+	req := BlocksByRangeRequest{StartSlot: 1, Count: 8, Step: 1}
+	if blocks, err := RequestBlocksByRange(ctx, client, server.ID(), req); err != nil || len(blocks) != 8 {
+		t.Fatalf("first range = %d blocks, %v; want 8 blocks", len(blocks), err)
+	}
+	if _, err := RequestBlocksByRange(ctx, client, server.ID(), req); !errors.Is(err, ErrResourceUnavailable) {
+		t.Fatalf("second range err = %v, want %v", err, ErrResourceUnavailable)
+	}
+}
+
+func TestStreamsPerPeerCapped(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	limiter := NewRateLimiter(nil)
+	limiter.MaxStreamsPerPeer = 1
+	server, client := newTestHosts(t, &ReqRespHandler{
+		OnBlocksByRange: func(BlocksByRangeRequest) []*types.SignedBlockWithAttestation {
+			close(entered)
+			<-release
+			return nil
+		},
+		OnMetadata:  func() Metadata { return Metadata{} },
+		RateLimiter: limiter,
+	})
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := RequestBlocksByRange(ctx, client, server.ID(), BlocksByRangeRequest{Count: 1, Step: 1})
+		done <- err
+	}()
+	<-entered
+	if _, err := RequestMetadata(ctx, client, server.ID()); !errors.Is(err, ErrResourceUnavailable) {
+		t.Fatalf("metadata err = %v, want %v", err, ErrResourceUnavailable)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	if _, err := RequestMetadata(ctx, client, server.ID()); err != nil {
+		t.Fatalf("metadata after stream closed: %v", err)
+	}
+}
+
+func TestRequestSizeLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSnappyFrame(&buf, make([]byte, (types.MaxRequestBlocks+1)*32)); err != nil {
+		t.Fatalf("writeSnappyFrame: %v", err)
+	}
+	if _, err := readBlocksByRootRequest(&buf); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("err = %v, want message too large", err)
+	}
+
+	buf.Reset()
+	if err := writeSnappyFrame(&buf, make([]byte, 16)); err != nil {
+		t.Fatalf("writeSnappyFrame: %v", err)
+	}
+	if _, err := readUint64(&buf); err == nil {
+		t.Fatal("expected oversized uint64 to be rejected")
+	}
+}
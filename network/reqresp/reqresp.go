@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -33,28 +34,76 @@ const (
 
 const reqRespTimeout = 10 * time.Second
 
+// maxPayloadSize bounds the uncompressed size of a response chunk. Requests
+// are bounded by their own SSZ sizes.
+const maxPayloadSize = 10 * 1024 * 1024
+
+// ErrResourceUnavailable is returned when a peer answers with
+// ResponseResourceUnavailable, typically because we are over its rate
+// limit. It says nothing bad about the peer.
+var ErrResourceUnavailable = errors.New("peer resource unavailable")
+
 // Status is the status message exchanged between peers.
 type Status struct {
-	Finalized *types.Checkpoint
-	Head      *types.Checkpoint
+	ForkDigest types.ForkDigest
+	Finalized  *types.Checkpoint
+	Head       *types.Checkpoint
 }
 
+// statusLen is the SSZ size of Status: fork digest, then the finalized and
+// head checkpoints.
+const statusLen = 84
+
 // ReqRespHandler processes incoming request/response messages.
 type ReqRespHandler struct {
-	OnStatus       func(Status) Status
+	// OnStatus receives the status a peer sent us and returns ours.
+	OnStatus       func(peer.ID, Status) Status
 	OnBlocksByRoot func([][32]byte) []*types.SignedBlockWithAttestation
+	// OnBlocksByRange returns canonical blocks for the request's slots in
+	// ascending order.
+	OnBlocksByRange func(BlocksByRangeRequest) []*types.SignedBlockWithAttestation
+	// OnMetadata returns our metadata. It also answers pings with our
+	// sequence number.
+	OnMetadata func() Metadata
+	// OnPing is told the metadata sequence number a peer pinged us with.
+	OnPing func(peer.ID, uint64)
+	// OnGoodbye is told that a peer is disconnecting and why.
+	OnGoodbye func(peer.ID, GoodbyeReason)
+	// RateLimiter limits inbound requests per peer. Nil serves everyone
+	// without limit.
+	RateLimiter *RateLimiter
 }
 
 // RegisterReqResp registers request/response protocol handlers.
 func RegisterReqResp(h host.Host, handler *ReqRespHandler) {
 	h.SetStreamHandler(StatusProtocol, func(s network.Stream) {
 		defer s.Close()
-		handleStatus(s, handler)
+		serveLimited(s, handler, StatusProtocol, handleStatus)
 	})
 
 	h.SetStreamHandler(BlocksByRootProtocol, func(s network.Stream) {
 		defer s.Close()
-		handleBlocksByRoot(s, handler)
+		serveLimited(s, handler, BlocksByRootProtocol, handleBlocksByRoot)
+	})
+
+	h.SetStreamHandler(BlocksByRangeProtocol, func(s network.Stream) {
+		defer s.Close()
+		serveLimited(s, handler, BlocksByRangeProtocol, handleBlocksByRange)
+	})
+
+	h.SetStreamHandler(PingProtocol, func(s network.Stream) {
+		defer s.Close()
+		serveLimited(s, handler, PingProtocol, handlePing)
+	})
+
+	h.SetStreamHandler(MetadataProtocol, func(s network.Stream) {
+		defer s.Close()
+		serveLimited(s, handler, MetadataProtocol, handleMetadata)
+	})
+
+	h.SetStreamHandler(GoodbyeProtocol, func(s network.Stream) {
+		defer s.Close()
+		serveLimited(s, handler, GoodbyeProtocol, handleGoodbye)
 	})
 }
 
@@ -66,7 +115,10 @@ func handleStatus(s network.Stream, handler *ReqRespHandler) {
 	if err != nil {
 		return
 	}
-	resp := handler.OnStatus(req)
+	if !allow(s, handler, StatusProtocol, 1) {
+		return
+	}
+	resp := handler.OnStatus(s.Conn().RemotePeer(), req)
 	if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
 		return
 	}
@@ -83,6 +135,9 @@ func handleBlocksByRoot(s network.Stream, handler *ReqRespHandler) {
 	if err != nil {
 		return
 	}
+	if !allow(s, handler, BlocksByRootProtocol, uint64(len(roots))) {
+		return
+	}
 	blocks := handler.OnBlocksByRoot(roots)
 	for _, block := range blocks {
 		if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
@@ -117,7 +172,8 @@ func RequestStatus(ctx context.Context, h host.Host, pid peer.ID, status Status)
 		return nil, fmt.Errorf("read response code: %w", err)
 	}
 	if code != ResponseSuccess {
-		return nil, fmt.Errorf("peer returned error code %d", code)
+		msg, _ := readSnappyFrame(s)
+		return nil, responseError(code, msg)
 	}
 
 	resp, err := readStatus(s)
@@ -160,6 +216,10 @@ func RequestBlocksByRoot(ctx context.Context, h host.Host, pid peer.ID, roots []
 			}
 			return blocks, fmt.Errorf("read response code: %w", err)
 		}
+		if code == ResponseResourceUnavailable {
+			msg, _ := readSnappyFrame(s)
+			return blocks, responseError(code, msg)
+		}
 		if code != ResponseSuccess {
 			break
 		}
@@ -177,26 +237,29 @@ func RequestBlocksByRoot(ctx context.Context, h host.Host, pid peer.ID, roots []
 }
 
 func readStatus(r io.Reader) (Status, error) {
-	data, err := readSnappyFrame(r)
+	data, err := readSnappyFrameLimit(r, statusLen)
 	if err != nil {
 		return Status{}, err
 	}
-	if len(data) != 80 {
+	if len(data) != statusLen {
 		return Status{}, fmt.Errorf("invalid status length: %d", len(data))
 	}
-	finalized := &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[32:40])}
-	copy(finalized.Root[:], data[0:32])
-	head := &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[72:80])}
-	copy(head.Root[:], data[40:72])
-	return Status{Finalized: finalized, Head: head}, nil
+	var status Status
+	copy(status.ForkDigest[:], data[0:4])
+	status.Finalized = &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[36:44])}
+	copy(status.Finalized.Root[:], data[4:36])
+	status.Head = &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[76:84])}
+	copy(status.Head.Root[:], data[44:76])
+	return status, nil
 }
 
 func writeStatus(w io.Writer, status Status) error {
-	var buf [80]byte
-	copy(buf[0:32], status.Finalized.Root[:])
-	binary.LittleEndian.PutUint64(buf[32:40], status.Finalized.Slot)
-	copy(buf[40:72], status.Head.Root[:])
-	binary.LittleEndian.PutUint64(buf[72:80], status.Head.Slot)
+	var buf [statusLen]byte
+	copy(buf[0:4], status.ForkDigest[:])
+	copy(buf[4:36], status.Finalized.Root[:])
+	binary.LittleEndian.PutUint64(buf[36:44], status.Finalized.Slot)
+	copy(buf[44:76], status.Head.Root[:])
+	binary.LittleEndian.PutUint64(buf[76:84], status.Head.Slot)
 	return writeSnappyFrame(w, buf[:])
 }
 
@@ -209,7 +272,7 @@ func writeSignedBlock(w io.Writer, block *types.SignedBlockWithAttestation) erro
 }
 
 func readBlocksByRootRequest(r io.Reader) ([][32]byte, error) {
-	data, err := readSnappyFrame(r)
+	data, err := readSnappyFrameLimit(r, types.MaxRequestBlocks*32)
 	if err != nil {
 		return nil, err
 	}
@@ -234,15 +297,30 @@ func readResponseCode(r io.Reader) (byte, error) {
 	return buf[0], err
 }
 
+// responseError describes a non-success response code and the message that
+// came with it.
+func responseError(code byte, msg []byte) error {
+	if code == ResponseResourceUnavailable {
+		return fmt.Errorf("%w: %s", ErrResourceUnavailable, msg)
+	}
+	return fmt.Errorf("peer returned error code %d: %s", code, msg)
+}
+
 // readSnappyFrame reads a varint-length-prefixed snappy frame encoded message.
 // Wire format: varint(uncompressed_len) + snappy_frame(data)
 func readSnappyFrame(r io.Reader) ([]byte, error) {
+	return readSnappyFrameLimit(r, maxPayloadSize)
+}
+
+// readSnappyFrameLimit reads a snappy frame, rejecting messages longer than
+// maxLen before allocating for them.
+func readSnappyFrameLimit(r io.Reader, maxLen uint64) ([]byte, error) {
 	// Read varint: the uncompressed SSZ length.
 	length, err := binary.ReadUvarint(byteReader{r})
 	if err != nil {
 		return nil, err
 	}
-	if length > 10*1024*1024 {
+	if length > maxLen {
 		return nil, fmt.Errorf("message too large: %d", length)
 	}
 	// Decompress through snappy frame reader.
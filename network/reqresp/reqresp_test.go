@@ -15,8 +15,9 @@ func TestStatusSSZRoundTrip(t *testing.T) {
 	}
 
 	in := Status{
-		Finalized: &types.Checkpoint{Root: finalizedRoot, Slot: 3},
-		Head:      &types.Checkpoint{Root: headRoot, Slot: 7},
+		ForkDigest: types.ForkDigest{0x01, 0x02, 0x03, 0x04},
+		Finalized:  &types.Checkpoint{Root: finalizedRoot, Slot: 3},
+		Head:       &types.Checkpoint{Root: headRoot, Slot: 7},
 	}
 
 	var buf bytes.Buffer
@@ -29,6 +30,9 @@ func TestStatusSSZRoundTrip(t *testing.T) {
 		t.Fatalf("readStatus: %v", err)
 	}
 
+	if out.ForkDigest != in.ForkDigest {
+		t.Fatalf("fork digest = %s, want %s", out.ForkDigest, in.ForkDigest)
+	}
 	if out.Finalized.Slot != in.Finalized.Slot || out.Finalized.Root != in.Finalized.Root {
 		t.Fatalf("finalized mismatch: got (%d,%x), want (%d,%x)",
 			out.Finalized.Slot, out.Finalized.Root, in.Finalized.Slot, in.Finalized.Root)
@@ -83,7 +87,7 @@ func TestResponseCodeError(t *testing.T) {
 }
 
 func TestReadStatusRejectsInvalidLength(t *testing.T) {
-	for _, n := range []int{79, 81} {
+	for _, n := range []int{80, statusLen - 1, statusLen + 1} {
 		var buf bytes.Buffer
 		payload := make([]byte, n)
 		if err := writeSnappyFrame(&buf, payload); err != nil {
@@ -0,0 +1,158 @@
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// Checkpoint sync download limits.
+const (
+	checkpointFetchTimeout = 2 * time.Minute
+	maxCheckpointObject    = 512 << 20
+)
+
+// CheckpointSource describes where to load a checkpoint sync anchor from.
+// Either URL is set, pointing at the HTTP API of a synced node, or both State
+// and Block are set, each a local SSZ file or an http(s) URL serving SSZ.
+type CheckpointSource struct {
+	URL         string
+	State       string
+	Block       string
+	TrustedRoot string // optional 0x-prefixed block root
+}
+
+// Enabled reports whether any checkpoint source is configured.
+func (s CheckpointSource) Enabled() bool {
+	return s.URL != "" || s.State != "" || s.Block != ""
+}
+
+// CheckpointAnchor is a verified checkpoint sync anchor.
+type CheckpointAnchor struct {
+	State *types.State
+	Block *types.Block
+	// Signed is the block's signed envelope. It is nil when the block was
+	// loaded from a bare SSZ block, which is then not served to peers.
+	Signed *types.SignedBlockWithAttestation
+}
+
+// LoadCheckpointAnchor fetches and verifies an anchor state and block. The
+// block's state root must match the state, and its root must match the
+// trusted root when one is given.
+func LoadCheckpointAnchor(ctx context.Context, src CheckpointSource) (*CheckpointAnchor, error) {
+	anchor := new(CheckpointAnchor)
+	var stateData []byte
+	switch {
+	case src.URL != "":
+		base := strings.TrimSuffix(src.URL, "/")
+		signedData, err := readSSZ(ctx, base+"/lean/v0/blocks/finalized/signed")
+		if err != nil {
+			return nil, fmt.Errorf("fetch anchor block: %w", err)
+		}
+		signed := new(types.SignedBlockWithAttestation)
+		if err := signed.UnmarshalSSZ(signedData); err != nil {
+			return nil, fmt.Errorf("decode anchor block: %w", err)
+		}
+		anchor.Signed, anchor.Block = signed, signed.Message.Block
+		// Fetch the state by root so that it cannot race a finalization.
+		root, err := anchor.Block.HashTreeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("hash anchor block: %w", err)
+		}
+		stateData, err = readSSZ(ctx, base+"/lean/v0/states/0x"+hex.EncodeToString(root[:]))
+		if err != nil {
+			return nil, fmt.Errorf("fetch anchor state: %w", err)
+		}
+	case src.State != "" && src.Block != "":
+		blockData, err := readSSZ(ctx, src.Block)
+		if err != nil {
+			return nil, fmt.Errorf("read anchor block: %w", err)
+		}
+		anchor.Block = new(types.Block)
+		if err := anchor.Block.UnmarshalSSZ(blockData); err != nil {
+			return nil, fmt.Errorf("decode anchor block: %w", err)
+		}
+		if stateData, err = readSSZ(ctx, src.State); err != nil {
+			return nil, fmt.Errorf("read anchor state: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("checkpoint sync needs a URL or both a state and a block")
+	}
+
+	anchor.State = new(types.State)
+	if err := anchor.State.UnmarshalSSZ(stateData); err != nil {
+		return nil, fmt.Errorf("decode anchor state: %w", err)
+	}
+	if err := verifyAnchor(anchor.State, anchor.Block, src.TrustedRoot); err != nil {
+		return nil, err
+	}
+	return anchor, nil
+}
+
+// verifyAnchor checks that state is the post-state of block and that block
+// matches the trusted root, if any.
+func verifyAnchor(state *types.State, block *types.Block, trustedRoot string) error {
+	stateRoot, err := state.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash anchor state: %w", err)
+	}
+	if block.StateRoot != stateRoot {
+		return fmt.Errorf("anchor state root mismatch: block=%x state=%x", block.StateRoot, stateRoot)
+	}
+	if state.Slot != block.Slot {
+		return fmt.Errorf("anchor slot mismatch: block=%d state=%d", block.Slot, state.Slot)
+	}
+	if trustedRoot == "" {
+		return nil
+	}
+	want, err := hex.DecodeString(strings.TrimPrefix(trustedRoot, "0x"))
+	if err != nil || len(want) != 32 {
+		return fmt.Errorf("invalid trusted root %q", trustedRoot)
+	}
+	blockRoot, err := block.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash anchor block: %w", err)
+	}
+	if blockRoot != [32]byte(want) {
+		return fmt.Errorf("anchor block root %x does not match trusted root %x", blockRoot, want)
+	}
+	return nil
+}
+
+// readSSZ reads raw SSZ from a local file or an http(s) URL.
+func readSSZ(ctx context.Context, src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkpointFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", src, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCheckpointObject+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCheckpointObject {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", src, maxCheckpointObject)
+	}
+	return data, nil
+}
@@ -2,55 +2,70 @@ package node
 
 import (
 	"fmt"
+	"slices"
+
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/network/reqresp"
 	"github.com/geanlabs/gean/observability/logging"
-	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
 )
 
 // registerHandlers wires up gossip subscriptions and req/resp protocol handlers.
-func registerHandlers(n *Node, store *memory.Store, fc *forkchoice.Store) error {
+func registerHandlers(n *Node, store storage.Store, fc *forkchoice.Store) error {
 	gossipLog := logging.NewComponentLogger(logging.CompGossip)
-	reqrespLog := logging.NewComponentLogger(logging.CompReqResp)
 
 	// Register req/resp handlers.
 	reqresp.RegisterReqResp(n.Host.P2P, &reqresp.ReqRespHandler{
-		OnStatus: func(req reqresp.Status) reqresp.Status {
-			headSlot := uint64(0)
-			if hb, ok := store.GetBlock(fc.Head); ok {
-				headSlot = hb.Slot
-			}
-			return reqresp.Status{
-				Finalized: fc.LatestFinalized,
-				Head:      &types.Checkpoint{Root: fc.Head, Slot: headSlot},
-			}
+		OnStatus: func(pid peer.ID, req reqresp.Status) reqresp.Status {
+			n.onPeerStatus(pid, req)
+			return n.ourStatus()
 		},
 		OnBlocksByRoot: func(roots [][32]byte) []*types.SignedBlockWithAttestation {
 			var blocks []*types.SignedBlockWithAttestation
 			for _, root := range roots {
 				if sb, ok := store.GetSignedBlock(root); ok {
 					blocks = append(blocks, sb)
-				} else if b, ok := store.GetBlock(root); ok {
-					// TODO: remove fallback once all stored blocks have signed envelopes.
-					reqrespLog.Warn("serving bare block without signed envelope",
-						"root", logging.ShortHash(root),
-						"slot", b.Slot,
-					)
-					blocks = append(blocks, &types.SignedBlockWithAttestation{
-						Message: &types.BlockWithAttestation{Block: b},
-					})
 				}
 			}
 			return blocks
 		},
+		OnBlocksByRange: func(req reqresp.BlocksByRangeRequest) []*types.SignedBlockWithAttestation {
+			head, _, _ := fc.Checkpoints()
+			return canonicalBlocksAt(store, head, req.Slots())
+		},
+		OnMetadata:  n.ourMetadata,
+		OnGoodbye:   n.onGoodbye,
+		RateLimiter: reqresp.NewRateLimiter(reqresp.DefaultQuotas()),
 	})
 
-	// Subscribe to gossip.
-	if err := gossipsub.SubscribeTopics(n.Host.Ctx, n.Topics, &gossipsub.GossipHandler{
-		OnBlock: func(sb *types.SignedBlockWithAttestation) {
+	// Validate gossip before it is forwarded.
+	validator := gossipsub.NewMessageValidator(gossipChain{n}, n.Host.P2P.ID())
+	n.Gossip = validator
+	validator.OnUnknownParent = func(sb *types.SignedBlockWithAttestation, from peer.ID) {
+		go n.importBlock(n.Host.Ctx, sb, from)
+	}
+	processAttestation := func(sa *types.SignedAttestation) {
+		if err := fc.ProcessAttestation(sa); err != nil {
+			gossipLog.Debug("rejected attestation",
+				"validator", sa.Message.ValidatorID,
+				"slot", sa.Message.Data.Slot,
+				"err", err,
+			)
+		}
+	}
+	validator.OnUnknownBlock = processAttestation
+	validator.OnReject = func(from peer.ID, topic, reason string) {
+		n.Host.Peers.ReportPeer(from, network.PeerActionLowToleranceError, "invalid "+topic+": "+reason)
+	}
+
+	// Join the gossip topics of the current fork.
+	if err := n.Topics.Start(n.Host.Ctx, n.Clock.CurrentSlot(), validator, &gossipsub.GossipHandler{
+		OnBlock: func(sb *types.SignedBlockWithAttestation, from peer.ID) {
 			block := sb.Message.Block
 			blockRoot, _ := block.HashTreeRoot()
 			gossipLog.Info("received block via gossip",
@@ -58,19 +73,67 @@ func registerHandlers(n *Node, store *memory.Store, fc *forkchoice.Store) error
 				"proposer", block.ProposerIndex,
 				"block_root", logging.ShortHash(blockRoot),
 			)
-			if err := fc.ProcessBlock(sb); err != nil {
-				gossipLog.Warn("rejected gossip block",
-					"slot", block.Slot,
-					"err", err,
-				)
-			}
-		},
-		OnAttestation: func(sa *types.SignedAttestation) {
-			fc.ProcessAttestation(sa)
+			n.importBlock(n.Host.Ctx, sb, from)
 		},
+		OnAttestation: processAttestation,
 	}); err != nil {
-		return fmt.Errorf("subscribe topics: %w", err)
+		return fmt.Errorf("start gossip topics: %w", err)
 	}
 
 	return nil
 }
+
+// gossipChain adapts the node to gossipsub.ChainView.
+type gossipChain struct {
+	n *Node
+}
+
+func (c gossipChain) CurrentSlot() uint64 {
+	return c.n.Clock.CurrentSlot()
+}
+
+func (c gossipChain) FinalizedSlot() uint64 {
+	_, _, finalized := c.n.FC.Checkpoints()
+	return finalized.Slot
+}
+
+func (c gossipChain) NumValidators() uint64 {
+	return c.n.FC.NumValidators
+}
+
+func (c gossipChain) HasBlock(root [32]byte) bool {
+	_, ok := c.n.FC.Storage.GetBlock(root)
+	return ok
+}
+
+// canonicalBlocksAt returns the signed blocks on the chain ending at head
+// whose slots are in slots, in ascending slot order. slots must be
+// ascending; slots without a canonical block are skipped.
+func canonicalBlocksAt(store storage.Store, head [32]byte, slots []uint64) []*types.SignedBlockWithAttestation {
+	if len(slots) == 0 {
+		return nil
+	}
+	var blocks []*types.SignedBlockWithAttestation
+	i := len(slots) - 1
+	for root := head; i >= 0; {
+		b, ok := store.GetBlock(root)
+		if !ok {
+			break
+		}
+		for i >= 0 && slots[i] > b.Slot {
+			i--
+		}
+		if i >= 0 && slots[i] == b.Slot {
+			if sb, ok := store.GetSignedBlock(root); ok {
+				blocks = append(blocks, sb)
+			}
+			i--
+		}
+		if b.Slot == 0 {
+			break
+		}
+		root = b.ParentRoot
+	}
+	slices.Reverse(blocks)
+	return blocks
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	lpnet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// statusIntervalSlots is how often peer statuses are refreshed after the
+// connection handshake.
+const statusIntervalSlots = 8
+
+// ErrIncompatibleChain is returned for peers whose finalized checkpoint
+// conflicts with our chain.
+var ErrIncompatibleChain = errors.New("incompatible chain")
+
+// ErrHeadAhead is returned for peers whose head is further ahead of our
+// clock than the allowed disparity. Either clock may be the wrong one, so
+// such peers are disconnected rather than banned.
+var ErrHeadAhead = errors.New("head ahead of our clock")
+
+// CheckPeerStatus verifies that a peer's status is consistent with our
+// chain at currentSlot. The fork digest must be ours, allowing for clock
+// disparity around a fork, and the head may not be ahead of currentSlot by
+// more than maxHeadDisparity slots. A finalized checkpoint at or before ours must
+// match our canonical block at that slot; one beyond ours cannot be checked
+// yet and is accepted, as is one whose slot we have pruned.
+func CheckPeerStatus(fc *forkchoice.Store, forks *types.ForkSchedule, currentSlot, maxHeadDisparity uint64, status reqresp.Status) error {
+	if !digestNear(forks, currentSlot, status.ForkDigest) {
+		return fmt.Errorf("%w: fork digest %s, ours is %s", ErrIncompatibleChain,
+			status.ForkDigest, forks.DigestAt(currentSlot))
+	}
+
+	if status.Head.Slot > currentSlot+maxHeadDisparity {
+		return fmt.Errorf("%w: head slot %d, current slot %d", ErrHeadAhead, status.Head.Slot, currentSlot)
+	}
+	peerFinalized := status.Finalized
+	if status.Head.Slot < peerFinalized.Slot {
+		return fmt.Errorf("head slot %d before finalized slot %d", status.Head.Slot, peerFinalized.Slot)
+	}
+	if peerFinalized.Root == types.ZeroHash {
+		// The genesis checkpoint carries a zero root until the first
+		// block is processed.
+		if peerFinalized.Slot == 0 {
+			return nil
+		}
+		return fmt.Errorf("zero finalized root at slot %d", peerFinalized.Slot)
+	}
+
+	head, _, finalized := fc.Checkpoints()
+	if peerFinalized.Slot > finalized.Slot {
+		return nil
+	}
+	root, found, known := canonicalRootAt(fc.Storage, head, peerFinalized.Slot)
+	switch {
+	case !known:
+		return nil
+	case !found:
+		return fmt.Errorf("%w: peer finalized slot %d is empty on our chain", ErrIncompatibleChain, peerFinalized.Slot)
+	case root != peerFinalized.Root:
+		return fmt.Errorf("%w: peer finalized %s at slot %d, ours is %s", ErrIncompatibleChain,
+			logging.ShortHash(peerFinalized.Root), peerFinalized.Slot, logging.ShortHash(root))
+	}
+	return nil
+}
+
+// digestNear reports whether digest is active within the clock disparity
+// of slot.
+func digestNear(forks *types.ForkSchedule, slot uint64, digest types.ForkDigest) bool {
+	first := slot - min(slot, gossipsub.MaxClockDisparitySlots)
+	for s := first; s <= slot+gossipsub.MaxClockDisparitySlots; s++ {
+		if forks.DigestAt(s) == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalRootAt walks back from head to the block at slot. found is
+// false when slot is skipped on our chain; known is false when the walk
+// ran into pruned history before reaching slot.
+func canonicalRootAt(store storage.Store, head [32]byte, slot uint64) (root [32]byte, found, known bool) {
+	for root = head; ; {
+		b, ok := store.GetBlock(root)
+		if !ok {
+			return root, false, false
+		}
+		if b.Slot == slot {
+			return root, true, true
+		}
+		if b.Slot < slot {
+			return root, false, true
+		}
+		root = b.ParentRoot
+	}
+}
+
+// watchConnections runs the status handshake with every newly connected
+// peer and forgets the status of peers that disconnect.
+func (n *Node) watchConnections() {
+	n.Host.P2P.Network().Notify(&lpnet.NotifyBundle{
+		ConnectedF: func(net lpnet.Network, c lpnet.Conn) {
+			pid := c.RemotePeer()
+			if len(net.ConnsToPeer(pid)) == 1 {
+				go n.handshake(n.Host.Ctx, pid)
+			}
+		},
+		DisconnectedF: func(net lpnet.Network, c lpnet.Conn) {
+			pid := c.RemotePeer()
+			if net.Connectedness(pid) != lpnet.Connected {
+				n.Sync.RemovePeer(pid)
+			}
+		},
+	})
+}
+
+// handshake exchanges status with a newly connected peer. Peers that fail
+// the exchange are disconnected, unless they only rate limited us.
+func (n *Node) handshake(ctx context.Context, pid peer.ID) {
+	err := n.refreshPeerStatus(ctx, pid)
+	if err != nil && !errors.Is(err, reqresp.ErrResourceUnavailable) && !n.Host.Peers.IsBanned(pid) {
+		n.Host.Peers.Disconnect(pid, reqresp.GoodbyeFaultError)
+	}
+}
+
+// onPeerStatus checks a status received from pid and records it for the
+// syncer. Peers on an incompatible chain or sending a malformed status are
+// banned; peers whose head is ahead of our clock are downscored and
+// disconnected.
+func (n *Node) onPeerStatus(pid peer.ID, status reqresp.Status) {
+	err := CheckPeerStatus(n.FC, n.Forks, n.Clock.CurrentSlot(), n.statusClockDisparity, status)
+	if errors.Is(err, ErrHeadAhead) {
+		metrics.PeerStatusChecks.WithLabelValues("head_ahead").Inc()
+		n.Sync.RemovePeer(pid)
+		n.Host.Peers.ReportPeer(pid, network.PeerActionHighToleranceError, err.Error())
+		if !n.Host.Peers.IsBanned(pid) {
+			n.Host.Peers.Disconnect(pid, reqresp.GoodbyeFaultError)
+		}
+		return
+	}
+	if err != nil {
+		metrics.PeerStatusChecks.WithLabelValues("incompatible").Inc()
+		n.Sync.RemovePeer(pid)
+		reason := reqresp.GoodbyeFaultError
+		if errors.Is(err, ErrIncompatibleChain) {
+			reason = reqresp.GoodbyeIrrelevantNetwork
+		}
+		n.Host.Peers.Ban(pid, reason, err.Error())
+		return
+	}
+	metrics.PeerStatusChecks.WithLabelValues("compatible").Inc()
+	n.Sync.UpdatePeer(pid, status)
+}
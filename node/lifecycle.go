@@ -1,16 +1,24 @@
 package node
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
+	"github.com/geanlabs/gean/api"
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/chain/statetransition"
 	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/signer"
+	"github.com/geanlabs/gean/slashing"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/disk"
 	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/syncer"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -18,34 +26,124 @@ import (
 func New(cfg Config) (*Node, error) {
 	log := logging.NewComponentLogger(logging.CompNode)
 
-	// Generate genesis.
-	genesisState := statetransition.GenerateGenesis(cfg.GenesisTime, cfg.Validators)
-	emptyBody := &types.BlockBody{Attestations: []*types.Attestation{}}
+	// Initialize storage and fork choice.
+	var store storage.Store = memory.New()
+	if cfg.DataDir != "" {
+		diskStore, err := disk.New(cfg.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("open data dir: %w", err)
+		}
+		store = diskStore
+	}
 
-	genesisBlock := &types.Block{
-		Slot:          0,
-		ProposerIndex: 0,
-		ParentRoot:    types.ZeroHash,
-		StateRoot:     types.ZeroHash,
-		Body:          emptyBody,
+	var fc *forkchoice.Store
+	if _, ok := store.GetCheckpoints(); ok {
+		resumed, err := forkchoice.NewStoreFromStorage(store)
+		if err != nil {
+			return nil, fmt.Errorf("resume fork choice: %w", err)
+		}
+		headState, ok := store.GetState(resumed.Head)
+		if !ok {
+			return nil, fmt.Errorf("resume fork choice: head state missing")
+		}
+		if err := checkGenesis(headState, cfg); err != nil {
+			return nil, fmt.Errorf("data dir: %w", err)
+		}
+		fc = resumed
+		log.Info("fork choice resumed from storage",
+			"head", logging.ShortHash(fc.Head),
+			"justified_slot", fc.LatestJustified.Slot,
+			"finalized_slot", fc.LatestFinalized.Slot,
+		)
+		if cfg.CheckpointSync.Enabled() {
+			log.Warn("data dir already initialized, ignoring checkpoint sync source")
+		}
+	} else if cfg.CheckpointSync.Enabled() {
+		anchor, err := LoadCheckpointAnchor(context.Background(), cfg.CheckpointSync)
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint sync: %w", err)
+		}
+		if err := checkGenesis(anchor.State, cfg); err != nil {
+			return nil, fmt.Errorf("checkpoint sync anchor: %w", err)
+		}
+		anchorRoot, _ := anchor.Block.HashTreeRoot()
+		log.Info("checkpoint sync anchor loaded",
+			"slot", anchor.Block.Slot,
+			"block_root", logging.ShortHash(anchorRoot),
+			"state_root", logging.ShortHash(anchor.Block.StateRoot),
+			"signed", anchor.Signed != nil,
+		)
+		fc = forkchoice.NewStore(anchor.State, anchor.Block, store)
+		if anchor.Signed != nil {
+			store.PutSignedBlock(anchorRoot, anchor.Signed)
+		}
+	} else {
+		genesisState, genesisBlock := buildGenesis(cfg)
+		genesisRoot, _ := genesisBlock.HashTreeRoot()
+		log.Info("genesis state initialized",
+			"state_root", logging.ShortHash(genesisBlock.StateRoot),
+			"block_root", logging.ShortHash(genesisRoot),
+		)
+		fc = forkchoice.NewStore(genesisState, genesisBlock, store)
+	}
+	fc.PruneFinalizedBlocks = cfg.PruneBlocks
+	if cfg.PendingAttestationSlots > 0 {
+		fc.PendingAttestationSlots = cfg.PendingAttestationSlots
+	}
+	if cfg.SkipSignatureVerification {
+		fc.Verifier = forkchoice.NoopVerifier{}
+		log.Warn("signature verification disabled")
 	}
 
-	// Compute genesis state root and set it on the block.
-	stateRoot, _ := genesisState.HashTreeRoot()
-	genesisBlock.StateRoot = stateRoot
+	validatorSigner, err := newValidatorSigner(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("validator signer: %w", err)
+	}
+	if validatorSigner == nil && len(cfg.ValidatorIDs) > 0 {
+		log.Warn("no validator key directory or remote signer, blocks and attestations will be unsigned")
+	}
+	if remote, ok := validatorSigner.(*signer.Remote); ok && len(cfg.ValidatorIDs) > 0 {
+		// The signer may come up later, so a failed check is not fatal.
+		missing, err := remote.Missing(context.Background(), cfg.ValidatorIDs)
+		switch {
+		case err != nil:
+			log.Warn("remote signer unreachable", "url", cfg.RemoteSignerURL, "err", err)
+		case len(missing) > 0:
+			log.Warn("remote signer lacks validator keys", "url", cfg.RemoteSignerURL, "validators", missing)
+		default:
+			log.Info("remote signer holds all validator keys", "url", cfg.RemoteSignerURL)
+		}
+	}
 
-	genesisRoot, _ := genesisBlock.HashTreeRoot()
-	log.Info("genesis state initialized",
-		"state_root", logging.ShortHash(stateRoot),
-		"block_root", logging.ShortHash(genesisRoot),
-	)
+	protectionDir := cfg.SlashingProtectionDir
+	if protectionDir == "" && cfg.DataDir != "" {
+		protectionDir = filepath.Join(cfg.DataDir, "slashing_protection")
+	}
+	protection, err := slashing.Open(protectionDir)
+	if err != nil {
+		return nil, fmt.Errorf("open slashing protection: %w", err)
+	}
+	if protectionDir == "" && len(cfg.ValidatorIDs) > 0 {
+		log.Warn("no data directory, slashing protection will not survive a restart")
+	}
 
-	// Initialize storage and fork choice.
-	store := memory.New()
-	fc := forkchoice.NewStore(genesisState, genesisBlock, store)
+	forks, err := types.NewForkSchedule(cfg.GenesisTime, cfg.Validators, cfg.Forks)
+	if err != nil {
+		return nil, fmt.Errorf("fork schedule: %w", err)
+	}
+	log.Info("fork schedule loaded",
+		"genesis_root", logging.ShortHash(forks.GenesisRoot),
+		"forks", len(forks.Forks),
+		"fork_digest", forks.DigestAt(0).String(),
+	)
 
 	// Create network host.
-	host, err := network.NewHost(cfg.ListenAddr, cfg.NodeKeyPath, cfg.Bootnodes)
+	scoring := cfg.GossipScoring
+	if scoring == nil {
+		defaults := gossipsub.DefaultScoringConfig(len(cfg.Validators))
+		scoring = &defaults
+	}
+	host, err := network.NewHost(cfg.ListenAddr, cfg.NodeKeyPath, cfg.Bootnodes, scoring)
 	if err != nil {
 		return nil, fmt.Errorf("create host: %w", err)
 	}
@@ -56,37 +154,53 @@ func New(cfg Config) (*Node, error) {
 		"addr", cfg.ListenAddr,
 	)
 
-	// Join gossip topics.
-	devnetID := cfg.DevnetID
-	if devnetID == "" {
-		devnetID = "devnet0"
-	}
-	topics, err := gossipsub.JoinTopics(host.PubSub, devnetID)
-	if err != nil {
-		host.Close()
-		return nil, fmt.Errorf("join topics: %w", err)
-	}
+	// Gossip topics are namespaced by the fork digest; they are joined
+	// once the handlers are registered.
+	topics := gossipsub.NewForkTopics(host.PubSub, forks, scoring)
 
 	gossipLog := logging.NewComponentLogger(logging.CompGossip)
-	gossipLog.Info("gossipsub topics joined", "devnet", devnetID)
+	if scoring.Disabled {
+		gossipLog.Warn("gossipsub peer scoring disabled")
+	} else {
+		gossipLog.Info("gossipsub peer scoring enabled",
+			"gossip_threshold", scoring.GossipThreshold,
+			"publish_threshold", scoring.PublishThreshold,
+			"graylist_threshold", scoring.GraylistThreshold,
+			"expected_attestations_per_slot", scoring.ExpectedAttestationsPerSlot,
+			"ip_colocation_threshold", scoring.IPColocationThreshold,
+		)
+	}
 
 	clock := NewClock(cfg.GenesisTime)
 
 	validator := &ValidatorDuties{
-		Indices: cfg.ValidatorIDs,
-		FC:      fc,
-		Topics:  topics,
-		log:     logging.NewComponentLogger(logging.CompValidator),
+		Indices:  cfg.ValidatorIDs,
+		FC:       fc,
+		Topics:   topics,
+		Signer:   validatorSigner,
+		Slashing: protection,
+		log:      logging.NewComponentLogger(logging.CompValidator),
 	}
 
 	n := &Node{
 		FC:        fc,
 		Host:      host,
 		Topics:    topics,
+		Forks:     forks,
 		Clock:     clock,
 		Validator: validator,
-		log:       log,
+		Pending:   NewPendingBlocks(pendingBlocksLimit, pendingPerPeerLimit, pendingBlockTTL),
+
+		statusClockDisparity: gossipsub.MaxClockDisparitySlots,
+
+		log: log,
 	}
+	if cfg.StatusClockDisparitySlots > 0 {
+		n.statusClockDisparity = cfg.StatusClockDisparitySlots
+	}
+	n.Sync = syncer.New(syncChain{n}, n.fetchBlocksByRange)
+	n.Sync.ReportPeer = host.Peers.ReportPeer
+	host.Peers.Goodbye = n.sendGoodbye
 
 	// Register gossip and req/resp handlers.
 	if err := registerHandlers(n, store, fc); err != nil {
@@ -94,10 +208,20 @@ func New(cfg Config) (*Node, error) {
 		return nil, err
 	}
 
-	// Connect to bootnodes.
-	if len(cfg.Bootnodes) > 0 {
-		network.ConnectBootnodes(host.Ctx, host.P2P, cfg.Bootnodes)
+	n.watchConnections()
+
+	// Connect to bootnodes and keep the peer count in range.
+	if cfg.TargetPeers > 0 && cfg.MaxPeers > 0 && cfg.TargetPeers > cfg.MaxPeers {
+		host.Close()
+		return nil, fmt.Errorf("target peers %d exceeds max peers %d", cfg.TargetPeers, cfg.MaxPeers)
+	}
+	if cfg.TargetPeers > 0 {
+		host.Peers.TargetPeers = cfg.TargetPeers
 	}
+	if cfg.MaxPeers > 0 {
+		host.Peers.MaxPeers = cfg.MaxPeers
+	}
+	host.Peers.Start(host.Ctx)
 
 	// Start metrics.
 	if cfg.MetricsPort > 0 {
@@ -108,5 +232,50 @@ func New(cfg Config) (*Node, error) {
 		log.Info("metrics server started", "port", cfg.MetricsPort)
 	}
 
+	// Start HTTP API.
+	if cfg.APIPort > 0 {
+		n.API = api.New(fc, store)
+		n.API.Sync = n.Sync
+		if err := n.API.Serve(cfg.APIPort); err != nil {
+			host.Close()
+			return nil, fmt.Errorf("start api server: %w", err)
+		}
+		log.Info("api server started", "port", cfg.APIPort)
+	}
+
 	return n, nil
 }
+
+// checkGenesis checks that state belongs to the chain of cfg: the same
+// genesis time and validator set, which the fork digest commits to.
+func checkGenesis(state *types.State, cfg Config) error {
+	if state.Config.GenesisTime != cfg.GenesisTime {
+		return fmt.Errorf("genesis time %d does not match config %d", state.Config.GenesisTime, cfg.GenesisTime)
+	}
+	got := types.ComputeGenesisRoot(state.Config.GenesisTime, state.Validators)
+	want := types.ComputeGenesisRoot(cfg.GenesisTime, cfg.Validators)
+	if got != want {
+		return fmt.Errorf("genesis validators (root %s) do not match config (root %s)", logging.ShortHash(got), logging.ShortHash(want))
+	}
+	return nil
+}
+
+// buildGenesis generates the genesis state and block from config.
+func buildGenesis(cfg Config) (*types.State, *types.Block) {
+	genesisState := statetransition.GenerateGenesis(cfg.GenesisTime, cfg.Validators)
+	emptyBody := &types.BlockBody{Attestations: []*types.Attestation{}}
+
+	genesisBlock := &types.Block{
+		Slot:          0,
+		ProposerIndex: 0,
+		ParentRoot:    types.ZeroHash,
+		StateRoot:     types.ZeroHash,
+		Body:          emptyBody,
+	}
+
+	// Compute genesis state root and set it on the block.
+	stateRoot, _ := genesisState.HashTreeRoot()
+	genesisBlock.StateRoot = stateRoot
+
+	return genesisState, genesisBlock
+}
@@ -2,10 +2,13 @@ package node
 
 import (
 	"log/slog"
+	"time"
 
+	"github.com/geanlabs/gean/api"
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/syncer"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -15,20 +18,60 @@ const version = "v0.1.0"
 type Node struct {
 	FC        *forkchoice.Store
 	Host      *network.Host
-	Topics    *gossipsub.Topics
+	Topics    *gossipsub.ForkTopics
+	Gossip    *gossipsub.MessageValidator
+	Forks     *types.ForkSchedule
 	Clock     *Clock
 	Validator *ValidatorDuties
-	log       *slog.Logger
+	Pending   *PendingBlocks
+	Sync      *syncer.Syncer
+	API       *api.Server
+
+	// statusClockDisparity is how many slots a peer's head may be ahead
+	// of our clock.
+	statusClockDisparity uint64
+
+	log *slog.Logger
 }
 
 // Config holds node configuration.
 type Config struct {
-	GenesisTime  uint64
-	Validators   []*types.Validator
-	ListenAddr   string
-	NodeKeyPath  string
-	Bootnodes    []string
-	ValidatorIDs []uint64
-	MetricsPort  int
-	DevnetID     string
+	GenesisTime uint64
+	Validators  []*types.Validator
+	// Forks are the forks scheduled after genesis.
+	Forks                   []types.Fork
+	ListenAddr              string
+	NodeKeyPath             string
+	Bootnodes               []string
+	TargetPeers             int
+	MaxPeers                int
+	ValidatorIDs            []uint64
+	MetricsPort             int
+	APIPort                 int
+	DataDir                 string
+	PruneBlocks             bool
+	PendingAttestationSlots uint64
+	// StatusClockDisparitySlots is how many slots a peer's status head may
+	// be ahead of our clock before the peer is disconnected. Zero means
+	// gossipsub.MaxClockDisparitySlots.
+	StatusClockDisparitySlots uint64
+	CheckpointSync            CheckpointSource
+	// GossipScoring tunes gossipsub peer scoring. Nil uses the defaults for
+	// the genesis validator count.
+	GossipScoring *gossipsub.ScoringConfig
+	// SkipSignatureVerification accepts blocks and attestations without
+	// checking their signatures. It is for tests and devnets only.
+	SkipSignatureVerification bool
+	// KeyDir holds the secret keys of ValidatorIDs. Empty leaves validator
+	// messages unsigned.
+	KeyDir string
+	// RemoteSignerURL is a remote signer signing for ValidatorIDs instead
+	// of keys in KeyDir.
+	RemoteSignerURL string
+	// RemoteSignerTimeout bounds each remote signer request. Zero uses
+	// the signer package default.
+	RemoteSignerTimeout time.Duration
+	// SlashingProtectionDir holds the slashing protection database. Empty
+	// uses a directory under DataDir, or memory without a DataDir.
+	SlashingProtectionDir string
 }
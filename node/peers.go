@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// pingIntervalSlots is how often connected peers are pinged.
+const pingIntervalSlots = 4
+
+// peerMetadataKey is the peerstore key holding a peer's reqresp.Metadata.
+const peerMetadataKey = "lean/metadata"
+
+// ourMetadata returns the metadata we serve. Nothing in it changes while
+// the node runs, so the sequence number stays at zero.
+func (n *Node) ourMetadata() reqresp.Metadata {
+	return reqresp.Metadata{
+		Client: fmt.Sprintf("gean/%s/%s-%s", version, runtime.GOOS, runtime.GOARCH),
+	}
+}
+
+// PeerMetadata returns the last metadata fetched from pid.
+func (n *Node) PeerMetadata(pid peer.ID) (reqresp.Metadata, bool) {
+	v, err := n.Host.P2P.Peerstore().Get(pid, peerMetadataKey)
+	if err != nil {
+		return reqresp.Metadata{}, false
+	}
+	md, ok := v.(reqresp.Metadata)
+	return md, ok
+}
+
+// pingPeer checks that pid is alive, records the round trip and refreshes
+// its metadata when the sequence number moved.
+func (n *Node) pingPeer(ctx context.Context, pid peer.ID) {
+	start := time.Now()
+	seq, err := reqresp.RequestPing(ctx, n.Host.P2P, pid, n.ourMetadata().SeqNumber)
+	if err != nil {
+		n.log.Debug("ping failed", "peer", pid.String()[:16], "err", err)
+		if !errors.Is(err, reqresp.ErrResourceUnavailable) {
+			n.Host.Peers.ReportPeer(pid, network.PeerActionMidToleranceError, "ping failed")
+		}
+		return
+	}
+	rtt := time.Since(start)
+	metrics.PeerPingRTT.Observe(rtt.Seconds())
+	n.Host.P2P.Peerstore().RecordLatency(pid, rtt)
+
+	if md, ok := n.PeerMetadata(pid); ok && md.SeqNumber >= seq {
+		return
+	}
+	md, err := reqresp.RequestMetadata(ctx, n.Host.P2P, pid)
+	if err != nil {
+		n.log.Debug("metadata request failed", "peer", pid.String()[:16], "err", err)
+		return
+	}
+	if err := n.Host.P2P.Peerstore().Put(pid, peerMetadataKey, *md); err != nil {
+		return
+	}
+	n.log.Debug("peer metadata",
+		"peer", pid.String()[:16],
+		"client", md.Client,
+		"seq", md.SeqNumber,
+		"rtt", rtt,
+	)
+}
+
+// pingPeers pings every connected peer.
+func (n *Node) pingPeers(ctx context.Context) {
+	for _, pid := range n.Host.P2P.Network().Peers() {
+		go n.pingPeer(ctx, pid)
+	}
+}
+
+// sendGoodbye is the peer manager's goodbye hook. It runs on shutdown,
+// after the node context is cancelled, so it does not take one.
+func (n *Node) sendGoodbye(pid peer.ID, reason reqresp.GoodbyeReason) {
+	metrics.Goodbyes.WithLabelValues("sent", reason.String()).Inc()
+	if err := reqresp.SendGoodbye(context.Background(), n.Host.P2P, pid, reason); err != nil {
+		n.log.Debug("goodbye failed", "peer", pid.String()[:16], "reason", reason.String(), "err", err)
+	}
+}
+
+// onGoodbye handles a peer saying goodbye: it is dropped from sync and
+// disconnected.
+func (n *Node) onGoodbye(pid peer.ID, reason reqresp.GoodbyeReason) {
+	metrics.Goodbyes.WithLabelValues("received", reason.String()).Inc()
+	n.log.Info("peer said goodbye", "peer", pid.String()[:16], "reason", reason.String())
+	n.Sync.RemovePeer(pid)
+	// Close after the goodbye stream is done so the peer sees it handled.
+	go n.Host.P2P.Network().ClosePeer(pid)
+}
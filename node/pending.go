@@ -0,0 +1,274 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Pending block pool limits.
+const (
+	pendingBlocksLimit  = 512
+	pendingPerPeerLimit = 64
+	pendingBlockTTL     = 16 * types.SecondsPerSlot * time.Second
+)
+
+// PendingBlocks holds blocks whose parent is not known yet, keyed by the
+// missing parent root. Entries expire after a TTL and the pool is bounded
+// both in total and per sending peer.
+type PendingBlocks struct {
+	mu        sync.Mutex
+	byParent  map[[32]byte][]*pendingBlock
+	roots     map[[32]byte]struct{}
+	perPeer   map[peer.ID]int
+	limit     int
+	peerLimit int
+	ttl       time.Duration
+}
+
+type pendingBlock struct {
+	root     [32]byte
+	block    *types.SignedBlockWithAttestation
+	from     peer.ID
+	received time.Time
+}
+
+// NewPendingBlocks creates a pool holding at most limit blocks, at most
+// peerLimit of them from any single peer, each for at most ttl.
+func NewPendingBlocks(limit, peerLimit int, ttl time.Duration) *PendingBlocks {
+	return &PendingBlocks{
+		byParent:  make(map[[32]byte][]*pendingBlock),
+		roots:     make(map[[32]byte]struct{}),
+		perPeer:   make(map[peer.ID]int),
+		limit:     limit,
+		peerLimit: peerLimit,
+		ttl:       ttl,
+	}
+}
+
+// Add queues a block under its parent root. added is false if the block is
+// already queued or a limit is reached; firstChild reports whether no other
+// block was waiting on the same parent.
+func (p *PendingBlocks) Add(root [32]byte, sb *types.SignedBlockWithAttestation, from peer.ID, now time.Time) (added, firstChild bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.roots[root]; ok {
+		return false, false
+	}
+	if len(p.roots) >= p.limit || p.perPeer[from] >= p.peerLimit {
+		return false, false
+	}
+
+	parent := sb.Message.Block.ParentRoot
+	firstChild = len(p.byParent[parent]) == 0
+	p.byParent[parent] = append(p.byParent[parent], &pendingBlock{
+		root:     root,
+		block:    sb,
+		from:     from,
+		received: now,
+	})
+	p.roots[root] = struct{}{}
+	p.perPeer[from]++
+	metrics.PendingBlocks.Set(float64(len(p.roots)))
+	return true, firstChild
+}
+
+// Contains reports whether a block with the given root is queued.
+func (p *PendingBlocks) Contains(root [32]byte) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.roots[root]
+	return ok
+}
+
+// TakeChildren removes and returns all blocks waiting on parent.
+func (p *PendingBlocks) TakeChildren(parent [32]byte) []*types.SignedBlockWithAttestation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := p.byParent[parent]
+	if len(entries) == 0 {
+		return nil
+	}
+	delete(p.byParent, parent)
+
+	blocks := make([]*types.SignedBlockWithAttestation, len(entries))
+	for i, e := range entries {
+		p.removeLocked(e)
+		blocks[i] = e.block
+	}
+	metrics.PendingBlocks.Set(float64(len(p.roots)))
+	return blocks
+}
+
+// MissingParents returns each missing parent root that is not itself queued,
+// along with a peer that sent one of its children.
+func (p *PendingBlocks) MissingParents() map[[32]byte]peer.ID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	missing := make(map[[32]byte]peer.ID)
+	for parent, entries := range p.byParent {
+		if _, queued := p.roots[parent]; queued {
+			continue
+		}
+		missing[parent] = entries[0].from
+	}
+	return missing
+}
+
+// Prune drops entries older than the TTL and returns how many were dropped.
+func (p *PendingBlocks) Prune(now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dropped := 0
+	for parent, entries := range p.byParent {
+		kept := entries[:0]
+		for _, e := range entries {
+			if now.Sub(e.received) > p.ttl {
+				p.removeLocked(e)
+				dropped++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 {
+			delete(p.byParent, parent)
+		} else {
+			p.byParent[parent] = kept
+		}
+	}
+	metrics.PendingBlocks.Set(float64(len(p.roots)))
+	return dropped
+}
+
+// Len returns the number of queued blocks.
+func (p *PendingBlocks) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.roots)
+}
+
+func (p *PendingBlocks) removeLocked(e *pendingBlock) {
+	delete(p.roots, e.root)
+	p.perPeer[e.from]--
+	if p.perPeer[e.from] <= 0 {
+		delete(p.perPeer, e.from)
+	}
+}
+
+// importBlock processes a block and then any queued descendants. A block
+// with an unknown parent is queued and the parent is requested from the
+// peer that sent it.
+func (n *Node) importBlock(ctx context.Context, sb *types.SignedBlockWithAttestation, from peer.ID) {
+	block := sb.Message.Block
+	blockRoot, _ := block.HashTreeRoot()
+
+	err := n.FC.ProcessBlock(sb)
+	if errors.Is(err, forkchoice.ErrUnknownParent) {
+		if _, _, finalized := n.FC.Checkpoints(); block.Slot <= finalized.Slot {
+			return
+		}
+		added, firstChild := n.Pending.Add(blockRoot, sb, from, time.Now())
+		if !added {
+			return
+		}
+		n.log.Debug("queued block with unknown parent",
+			"slot", block.Slot,
+			"block_root", logging.ShortHash(blockRoot),
+			"parent_root", logging.ShortHash(block.ParentRoot),
+			"pending", n.Pending.Len(),
+		)
+		// Only the first child of a missing parent triggers a request, and
+		// a parent that is itself queued is already waiting on its own.
+		if firstChild && !n.Pending.Contains(block.ParentRoot) {
+			go n.fetchBlock(ctx, block.ParentRoot, from)
+		}
+		return
+	}
+	if err != nil {
+		n.log.Warn("rejected block",
+			"slot", block.Slot,
+			"block_root", logging.ShortHash(blockRoot),
+			"err", err,
+		)
+		n.Host.Peers.ReportPeer(from, network.PeerActionLowToleranceError, "invalid block")
+		return
+	}
+
+	n.importPendingChildren(blockRoot)
+}
+
+// importPendingChildren imports queued blocks that descend from root,
+// parents before children.
+func (n *Node) importPendingChildren(root [32]byte) {
+	queue := [][32]byte{root}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, child := range n.Pending.TakeChildren(parent) {
+			childRoot, _ := child.Message.Block.HashTreeRoot()
+			if err := n.FC.ProcessBlock(child); err != nil {
+				n.log.Warn("rejected pending block",
+					"slot", child.Message.Block.Slot,
+					"block_root", logging.ShortHash(childRoot),
+					"err", err,
+				)
+				continue
+			}
+			n.log.Info("imported pending block",
+				"slot", child.Message.Block.Slot,
+				"block_root", logging.ShortHash(childRoot),
+			)
+			queue = append(queue, childRoot)
+		}
+	}
+}
+
+// fetchBlock requests a single block by root from pid and imports it.
+func (n *Node) fetchBlock(ctx context.Context, root [32]byte, pid peer.ID) {
+	blocks, err := reqresp.RequestBlocksByRoot(ctx, n.Host.P2P, pid, [][32]byte{root})
+	if err != nil || len(blocks) == 0 {
+		n.log.Debug("parent block request failed",
+			"root", logging.ShortHash(root),
+			"peer", pid.String()[:16],
+			"err", err,
+		)
+		switch {
+		case errors.Is(err, reqresp.ErrResourceUnavailable):
+			// The peer is rate limiting us; ask again on the next retry.
+		case err != nil:
+			n.Host.Peers.ReportPeer(pid, network.PeerActionMidToleranceError, "blocks by root request failed")
+		default:
+			// The peer sent us the child, so it should have the parent.
+			n.Host.Peers.ReportPeer(pid, network.PeerActionHighToleranceError, "parent block not served")
+		}
+		return
+	}
+	for _, sb := range blocks {
+		n.importBlock(ctx, sb, pid)
+	}
+}
+
+// retryPendingParents expires stale pending blocks and re-requests parents
+// that are still missing.
+func (n *Node) retryPendingParents(ctx context.Context) {
+	if dropped := n.Pending.Prune(time.Now()); dropped > 0 {
+		n.log.Debug("expired pending blocks", "count", dropped)
+	}
+	for root, pid := range n.Pending.MissingParents() {
+		go n.fetchBlock(ctx, root, pid)
+	}
+}
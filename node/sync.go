@@ -2,82 +2,88 @@ package node
 
 import (
 	"context"
+	"errors"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/metrics"
 	"github.com/geanlabs/gean/types"
 )
 
-// syncWithPeer exchanges status and fetches missing blocks from a single peer.
-// It walks backwards from the peer's head to find blocks we're missing, then
-// processes them in forward order.
-func (n *Node) syncWithPeer(ctx context.Context, pid peer.ID) bool {
-	headBlock, ok := n.FC.Storage.GetBlock(n.FC.Head)
-	headSlot := uint64(0)
-	if ok {
-		headSlot = headBlock.Slot
+// syncChain adapts the node's fork choice to syncer.Chain.
+type syncChain struct {
+	n *Node
+}
+
+func (c syncChain) CurrentSlot() uint64 {
+	return c.n.Clock.CurrentSlot()
+}
+
+func (c syncChain) HeadSlot() uint64 {
+	return c.n.FC.HeadSlot()
+}
+
+func (c syncChain) FinalizedSlot() uint64 {
+	_, _, finalized := c.n.FC.Checkpoints()
+	return finalized.Slot
+}
+
+// ProcessBlock imports a synced block and any queued blocks waiting on it.
+func (c syncChain) ProcessBlock(sb *types.SignedBlockWithAttestation) error {
+	if err := c.n.FC.ProcessBlock(sb); err != nil {
+		return err
 	}
-	ourStatus := reqresp.Status{
-		Finalized: n.FC.LatestFinalized,
-		Head:      &types.Checkpoint{Root: n.FC.Head, Slot: headSlot},
+	root, _ := sb.Message.Block.HashTreeRoot()
+	c.n.importPendingChildren(root)
+	return nil
+}
+
+// fetchBlocksByRange is the syncer's block fetcher.
+func (n *Node) fetchBlocksByRange(ctx context.Context, pid peer.ID, req reqresp.BlocksByRangeRequest) ([]*types.SignedBlockWithAttestation, error) {
+	return reqresp.RequestBlocksByRange(ctx, n.Host.P2P, pid, req)
+}
+
+// ourStatus returns the status message describing our chain.
+func (n *Node) ourStatus() reqresp.Status {
+	head, _, finalized := n.FC.Checkpoints()
+	return reqresp.Status{
+		ForkDigest: n.Forks.DigestAt(n.Clock.CurrentSlot()),
+		Finalized:  finalized,
+		Head:       &types.Checkpoint{Root: head, Slot: n.FC.HeadSlot()},
 	}
+}
 
-	peerStatus, err := reqresp.RequestStatus(ctx, n.Host.P2P, pid, ourStatus)
+// refreshPeerStatus exchanges status with pid and records the result for
+// the syncer. Peers that do not answer are forgotten; peers rate limiting
+// us keep their last status.
+func (n *Node) refreshPeerStatus(ctx context.Context, pid peer.ID) error {
+	peerStatus, err := reqresp.RequestStatus(ctx, n.Host.P2P, pid, n.ourStatus())
+	if errors.Is(err, reqresp.ErrResourceUnavailable) {
+		metrics.PeerStatusChecks.WithLabelValues("throttled").Inc()
+		n.log.Debug("status exchange throttled", "peer", pid.String()[:16])
+		return err
+	}
 	if err != nil {
+		metrics.PeerStatusChecks.WithLabelValues("failed").Inc()
 		n.log.Debug("status exchange failed", "peer", pid.String()[:16], "err", err)
-		return false
+		n.Host.Peers.ReportPeer(pid, network.PeerActionMidToleranceError, "status request failed")
+		n.Sync.RemovePeer(pid)
+		return err
 	}
-	n.log.Info("status exchanged",
+	n.log.Debug("status exchanged",
 		"peer", pid.String()[:16],
 		"peer_head_slot", peerStatus.Head.Slot,
 		"peer_finalized_slot", peerStatus.Finalized.Slot,
 	)
-
-	if peerStatus.Head.Slot <= headSlot {
-		return false
-	}
-
-	// Walk backwards: request blocks we don't have, collecting roots to fetch.
-	var pending []*types.SignedBlockWithAttestation
-	nextRoot := peerStatus.Head.Root
-	const maxSyncDepth = 64
-
-	for i := 0; i < maxSyncDepth; i++ {
-		if _, ok := n.FC.Storage.GetBlock(nextRoot); ok {
-			break // We have this block, chain is connected.
-		}
-
-		blocks, err := reqresp.RequestBlocksByRoot(ctx, n.Host.P2P, pid, [][32]byte{nextRoot})
-		if err != nil || len(blocks) == 0 {
-			n.log.Debug("blocks_by_root failed during sync walk", "peer", pid.String()[:16], "err", err)
-			break
-		}
-
-		sb := blocks[0]
-		pending = append(pending, sb)
-		nextRoot = sb.Message.Block.ParentRoot
-	}
-
-	// Process in forward order (oldest first).
-	synced := 0
-	for i := len(pending) - 1; i >= 0; i-- {
-		sb := pending[i]
-		if err := n.FC.ProcessBlock(sb); err != nil {
-			n.log.Debug("sync block rejected", "slot", sb.Message.Block.Slot, "err", err)
-		} else {
-			n.log.Info("synced block", "slot", sb.Message.Block.Slot)
-			synced++
-		}
-	}
-	return synced > 0
+	n.onPeerStatus(pid, *peerStatus)
+	return nil
 }
 
-// initialSync exchanges status with connected peers and requests any blocks
-// we're missing. This allows a node that restarts mid-devnet to catch up.
-func (n *Node) initialSync(ctx context.Context) {
-	peers := n.Host.P2P.Network().Peers()
-	for _, pid := range peers {
-		n.syncWithPeer(ctx, pid)
+// refreshPeerStatuses exchanges status with every connected peer.
+func (n *Node) refreshPeerStatuses(ctx context.Context) {
+	for _, pid := range n.Host.P2P.Network().Peers() {
+		n.refreshPeerStatus(ctx, pid)
 	}
 }
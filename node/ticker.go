@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/geanlabs/gean/network/reqresp"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/syncer"
 )
 
 // Run starts the main event loop.
@@ -16,8 +18,10 @@ func (n *Node) Run(ctx context.Context) error {
 		"peers", len(n.Host.P2P.Network().Peers()),
 	)
 
-	// Attempt initial sync with connected peers.
-	n.initialSync(ctx)
+	// Learn where connected peers are; the syncer catches up in the
+	// background.
+	go n.Sync.Run(ctx)
+	n.refreshPeerStatuses(ctx)
 
 	ticker := n.Clock.SlotTicker()
 	var lastSlot uint64
@@ -26,53 +30,80 @@ func (n *Node) Run(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			n.log.Info("node shutting down")
+			n.Host.Peers.DisconnectAll(reqresp.GoodbyeClientShutdown)
+			if n.API != nil {
+				if err := n.API.Close(); err != nil {
+					n.log.Warn("api server close error", "err", err)
+				}
+			}
 			if err := n.Host.Close(); err != nil {
 				n.log.Warn("host close error", "err", err)
 			}
 			return nil
 		case <-ticker:
 			if n.Clock.IsBeforeGenesis() {
+				n.Sync.UpdateState(0, true)
 				continue
 			}
 			slot := n.Clock.CurrentSlot()
 			interval := n.Clock.CurrentInterval()
-			hasProposal := interval == 0 && n.Validator.HasProposal(slot)
+			syncing := n.Sync.UpdateState(slot, false).State == syncer.StateSyncing
+			hasProposal := !syncing && interval == 0 && n.Validator.HasProposal(slot)
+
+			// Move to the next fork's topics before duties publish on them.
+			if slot != lastSlot {
+				if err := n.Topics.Update(slot); err != nil {
+					n.log.Warn("failed to update gossip topics", "slot", slot, "err", err)
+				}
+			}
 
 			// Advance fork choice time.
 			n.FC.AdvanceTime(n.Clock.CurrentTime(), hasProposal)
 
-			// Execute validator duties.
-			n.Validator.OnInterval(ctx, slot, interval)
+			// Execute validator duties. Blocks and votes built on a stale
+			// head while syncing would only be orphaned.
+			if syncing {
+				if interval == 0 {
+					n.log.Debug("skipping validator duties while syncing", "slot", slot)
+				}
+			} else {
+				n.Validator.StartInterval(ctx, slot, interval)
+			}
 
 			// Update metrics and log on slot boundary.
 			if slot != lastSlot {
 				start := time.Now()
 				metrics.CurrentSlot.Set(float64(slot))
-				headSlot := uint64(0)
-				if headBlock, ok := n.FC.Storage.GetBlock(n.FC.Head); ok {
-					headSlot = headBlock.Slot
-					metrics.HeadSlot.Set(float64(headBlock.Slot))
-				}
-				metrics.LatestFinalizedSlot.Set(float64(n.FC.LatestFinalized.Slot))
-				metrics.LatestJustifiedSlot.Set(float64(n.FC.LatestJustified.Slot))
+				headSlot := n.FC.HeadSlot()
+				_, justified, finalized := n.FC.Checkpoints()
+				metrics.HeadSlot.Set(float64(headSlot))
+				metrics.LatestFinalizedSlot.Set(float64(finalized.Slot))
+				metrics.LatestJustifiedSlot.Set(float64(justified.Slot))
 				peerCount := len(n.Host.P2P.Network().Peers())
 				metrics.ConnectedPeers.Set(float64(peerCount))
 
-				// Periodic sync: if head is behind, try catching up.
-				if slot > headSlot+2 {
-					for _, pid := range n.Host.P2P.Network().Peers() {
-						if n.syncWithPeer(ctx, pid) {
-							break
-						}
-					}
+				if slot%pingIntervalSlots == 0 {
+					n.pingPeers(ctx)
+				}
+
+				// Expire and retry blocks waiting on missing parents.
+				n.retryPendingParents(ctx)
+				n.Gossip.Prune(slot)
+
+				// Refresh peer statuses periodically, and at once if the
+				// head is behind so the syncer sees how far it has to go.
+				behind := slot > headSlot+2 && !n.Sync.Progress().Syncing
+				if behind || slot%statusIntervalSlots == 0 {
+					go n.refreshPeerStatuses(ctx)
 				}
 
 				n.log.Info("slot",
 					"slot", slot,
 					"head", headSlot,
-					"finalized", n.FC.LatestFinalized.Slot,
-					"justified", n.FC.LatestJustified.Slot,
+					"finalized", finalized.Slot,
+					"justified", justified.Slot,
 					"peers", peerCount,
+					"sync", n.Sync.Status().State.String(),
 					"elapsed", logging.TimeSince(start),
 				)
 				lastSlot = slot
@@ -2,20 +2,89 @@ package node
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/keymanager"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/signer"
+	"github.com/geanlabs/gean/slashing"
+	"github.com/geanlabs/gean/types"
 )
 
 // ValidatorDuties handles proposer and attester duties.
 type ValidatorDuties struct {
 	Indices []uint64
 	FC      *forkchoice.Store
-	Topics  *gossipsub.Topics
-	log     *slog.Logger
+	Topics  *gossipsub.ForkTopics
+	// Signer signs for Indices. Nil publishes unsigned messages.
+	Signer signer.Signer
+	// Slashing refuses messages that conflict with earlier ones. Nil
+	// signs anything.
+	Slashing *slashing.DB
+	log      *slog.Logger
+}
+
+// newValidatorSigner returns the signer for cfg.ValidatorIDs: a remote
+// signer at cfg.RemoteSignerURL, or the keys in cfg.KeyDir checked against
+// the genesis validators. It returns nil if neither is configured.
+func newValidatorSigner(cfg Config) (signer.Signer, error) {
+	if cfg.KeyDir == "" && cfg.RemoteSignerURL == "" {
+		return nil, nil
+	}
+	if cfg.KeyDir != "" && cfg.RemoteSignerURL != "" {
+		return nil, fmt.Errorf("key directory and remote signer are mutually exclusive")
+	}
+	for _, idx := range cfg.ValidatorIDs {
+		if idx >= uint64(len(cfg.Validators)) {
+			return nil, fmt.Errorf("validator %d not in genesis", idx)
+		}
+	}
+	if cfg.RemoteSignerURL != "" {
+		remote := signer.NewRemote(cfg.RemoteSignerURL, cfg.Validators)
+		if cfg.RemoteSignerTimeout > 0 {
+			remote.Timeout = cfg.RemoteSignerTimeout
+		}
+		return remote, nil
+	}
+	keys, err := keymanager.Load(cfg.KeyDir, keymanager.XMSS{}, cfg.ValidatorIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range cfg.ValidatorIDs {
+		if pk, _ := keys.PublicKey(idx); pk != cfg.Validators[idx].Pubkey {
+			return nil, fmt.Errorf("key of validator %d does not match its genesis pubkey", idx)
+		}
+	}
+	return signer.Local{Keys: keys}, nil
+}
+
+// sign signs att for its validator once slashing protection allows it.
+// Without a signer the signature is zero.
+func (v *ValidatorDuties) sign(ctx context.Context, typ signer.Type, att *types.Attestation) ([3116]byte, error) {
+	root, err := forkchoice.AttestationSigningRoot(att)
+	if err != nil {
+		return [3116]byte{}, err
+	}
+	if v.Slashing != nil {
+		data := att.Data
+		if err := v.Slashing.CheckAttestation(att.ValidatorID, data.Slot, data.Source.Slot, data.Target.Slot, root); err != nil {
+			return [3116]byte{}, err
+		}
+	}
+	if v.Signer == nil {
+		return [3116]byte{}, nil
+	}
+	return v.Signer.Sign(ctx, &signer.Request{
+		Validator:   att.ValidatorID,
+		Type:        typ,
+		Slot:        att.Data.Slot,
+		SigningRoot: root,
+	})
 }
 
 // HasProposal reports whether this node has a proposer for the slot.
@@ -28,6 +97,19 @@ func (v *ValidatorDuties) HasProposal(slot uint64) bool {
 	return false
 }
 
+// StartInterval runs the validator duties of the interval in the
+// background, so that a slow signer cannot hold up the event loop. The
+// duties are cancelled at the end of the interval, when their messages
+// would be late; signers fit their requests to the time left.
+func (v *ValidatorDuties) StartInterval(ctx context.Context, slot, interval uint64) {
+	end := v.FC.GenesisTime + slot*types.SecondsPerSlot + (interval+1)*types.SecondsPerInterval
+	go func() {
+		ctx, cancel := context.WithDeadline(ctx, time.Unix(int64(end), 0))
+		defer cancel()
+		v.OnInterval(ctx, slot, interval)
+	}()
+}
+
 // OnInterval executes validator duties for the current interval.
 func (v *ValidatorDuties) OnInterval(ctx context.Context, slot, interval uint64) {
 	switch interval {
@@ -43,7 +125,7 @@ func (v *ValidatorDuties) tryPropose(ctx context.Context, slot uint64) {
 		if !statetransition.IsProposer(idx, slot, v.FC.NumValidators) {
 			continue
 		}
-		envelope, err := v.FC.ProduceBlock(slot, idx)
+		envelope, err := v.ProposeBlock(ctx, slot, idx)
 		if err != nil {
 			v.log.Error("block proposal failed",
 				"slot", slot,
@@ -53,7 +135,7 @@ func (v *ValidatorDuties) tryPropose(ctx context.Context, slot uint64) {
 			continue
 		}
 		blockRoot, _ := envelope.Message.Block.HashTreeRoot()
-		if err := gossipsub.PublishBlock(ctx, v.Topics.Block, envelope); err != nil {
+		if err := gossipsub.PublishBlock(ctx, v.Topics.Current().Block, envelope); err != nil {
 			v.log.Error("failed to publish block",
 				"slot", slot,
 				"proposer", idx,
@@ -69,6 +151,34 @@ func (v *ValidatorDuties) tryPropose(ctx context.Context, slot uint64) {
 	}
 }
 
+// ProposeBlock builds the block of proposer idx for slot, signs it and
+// imports it, returning the envelope to publish. Slashing protection is
+// consulted before the block is signed or enters fork choice, so a refused
+// block leaves no trace.
+func (v *ValidatorDuties) ProposeBlock(ctx context.Context, slot, idx uint64) (*types.SignedBlockWithAttestation, error) {
+	envelope, err := v.FC.ProduceBlock(slot, idx)
+	if err != nil {
+		return nil, err
+	}
+	blockRoot, _ := envelope.Message.Block.HashTreeRoot()
+	if v.Slashing != nil {
+		if err := v.Slashing.CheckBlock(idx, slot, blockRoot); err != nil {
+			return nil, fmt.Errorf("slashing protection: %w", err)
+		}
+	}
+	sig, err := v.sign(ctx, signer.TypeBlock, envelope.Message.ProposerAttestation)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	envelope.Signature[len(envelope.Signature)-1] = sig
+	// Import only the signed block, so that what becomes our head is what
+	// peers receive.
+	if err := v.FC.ProcessBlock(envelope); err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	return envelope, nil
+}
+
 func (v *ValidatorDuties) tryAttest(ctx context.Context, slot uint64) {
 	for _, idx := range v.Indices {
 		// Skip if this validator is the proposer for this slot.
@@ -77,7 +187,17 @@ func (v *ValidatorDuties) tryAttest(ctx context.Context, slot uint64) {
 			continue
 		}
 		sa := v.FC.ProduceAttestation(slot, idx)
-		if err := gossipsub.PublishAttestation(ctx, v.Topics.Attestation, sa); err != nil {
+		sig, err := v.sign(ctx, signer.TypeAttestation, sa.Message)
+		if err != nil {
+			v.log.Error("failed to sign attestation",
+				"slot", slot,
+				"validator", idx,
+				"err", err,
+			)
+			continue
+		}
+		sa.Signature = sig
+		if err := gossipsub.PublishAttestation(ctx, v.Topics.Current().Attestation, sa); err != nil {
 			v.log.Error("failed to publish attestation",
 				"slot", slot,
 				"validator", idx,
@@ -20,6 +20,9 @@ const (
 	CompGossip     = "gossip"
 	CompReqResp    = "reqresp"
 	CompMetrics    = "metrics"
+	CompStorage    = "storage"
+	CompAPI        = "api"
+	CompSync       = "sync"
 )
 
 // ANSI color codes.
@@ -60,12 +60,69 @@ var AttestationsInvalid = prometheus.NewCounterVec(prometheus.CounterOpts{
 	Help: "Total number of invalid attestations",
 }, []string{"source"})
 
+var AttestationsHeld = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_attestations_held_total",
+	Help: "Total number of attestations held while waiting for an unknown block",
+})
+
+var AttestationsReplayed = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_attestations_replayed_total",
+	Help: "Total number of held attestations replayed after their block arrived",
+})
+
+var AttestationsExpired = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_attestations_expired_total",
+	Help: "Total number of held attestations dropped before their block arrived",
+})
+
+var AttestationsPending = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_attestations_pending",
+	Help: "Number of attestations currently held for unknown blocks",
+})
+
 var AttestationValidationTime = prometheus.NewHistogram(prometheus.HistogramOpts{
 	Name:    "lean_attestation_validation_time_seconds",
 	Help:    "Time taken to validate attestation",
 	Buckets: fastBuckets,
 })
 
+var ForkChoiceReorgs = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_fork_choice_reorgs_total",
+	Help: "Total number of fork choice reorgs",
+})
+
+var ForkChoiceReorgDepth = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lean_fork_choice_reorg_depth",
+	Help:    "Depth in slots of fork choice reorgs",
+	Buckets: []float64{1, 2, 3, 5, 7, 10, 20, 30, 50, 100},
+})
+
+var Equivocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_equivocations_total",
+	Help: "Total number of detected equivocations",
+}, []string{"kind"})
+
+var EquivocationEvidenceDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_equivocation_evidence_dropped_total",
+	Help: "Total number of equivocation evidence entries evicted to make room for newer ones",
+}, []string{"kind"})
+
+var PendingBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_pending_blocks",
+	Help: "Number of blocks waiting for an unknown parent",
+})
+
+var SignatureVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_signature_verifications_total",
+	Help: "Attestation signature checks by source (block, proposer, gossip) and result",
+}, []string{"source", "result"})
+
+var SignatureVerificationTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lean_signature_verification_time_seconds",
+	Help:    "Time taken to verify one signature",
+	Buckets: fastBuckets,
+})
+
 // --- State Transition ---
 
 var LatestJustifiedSlot = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -119,6 +176,27 @@ var ValidatorsCount = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help: "Number of validators managed by a node",
 })
 
+var ValidatorSignaturesRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_validator_signatures_remaining",
+	Help: "Epochs a validator's one-time keys can still sign",
+}, []string{"validator"})
+
+var SlashingProtectionRefusals = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_validator_slashing_protection_refusals_total",
+	Help: "Signatures refused by slashing protection, by message kind",
+}, []string{"kind"})
+
+var RemoteSignerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_validator_remote_signer_requests_total",
+	Help: "Remote signer sign requests, by result (success, retry, failure)",
+}, []string{"result"})
+
+var RemoteSignerRequestTime = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lean_validator_remote_signer_request_time_seconds",
+	Help:    "Time taken to get a signature from the remote signer, including retries",
+	Buckets: fastBuckets,
+})
+
 // --- Network ---
 
 var ConnectedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -126,6 +204,74 @@ var ConnectedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help: "Number of connected peers",
 })
 
+var KnownPeers = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_peer_manager_known_peers",
+	Help: "Peers tracked by the peer manager",
+})
+
+var BannedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_peer_manager_banned_peers",
+	Help: "Peers currently banned",
+})
+
+var PeerReports = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_manager_reports_total",
+	Help: "Faults reported against peers by tolerance",
+}, []string{"action"})
+
+var PeerDials = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_manager_dials_total",
+	Help: "Outbound peer dials by result",
+}, []string{"result"})
+
+var PeerPingRTT = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lean_peer_ping_rtt_seconds",
+	Help:    "Round-trip time of ping requests to peers",
+	Buckets: fastBuckets,
+})
+
+var Goodbyes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_goodbyes_total",
+	Help: "Goodbye messages by direction and reason",
+}, []string{"direction", "reason"})
+
+var PeerStatusChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_status_checks_total",
+	Help: "Peer status exchanges by result",
+}, []string{"result"})
+
+var ReqRespThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_reqresp_throttled_total",
+	Help: "Inbound req/resp requests refused by protocol and limit (rate or streams)",
+}, []string{"protocol", "reason"})
+
+var GossipValidation = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_gossip_validation_total",
+	Help: "Gossip messages by topic and validation result",
+}, []string{"topic", "result"})
+
+var GossipPeerScore = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_gossip_peer_score",
+	Help: "Gossipsub peer score distribution across connected peers",
+}, []string{"stat"})
+
+var GossipPeersBelowThreshold = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_gossip_peers_below_threshold",
+	Help: "Peers whose gossipsub score is below a score threshold",
+}, []string{"threshold"})
+
+// --- Sync ---
+
+var SyncState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_sync_state",
+	Help: "Current sync state of the node (1 for the active state)",
+}, []string{"state"})
+
+var SyncDistance = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_sync_distance_slots",
+	Help: "Slots between our head and the best head reported by peers",
+})
+
 func init() {
 	prometheus.MustRegister(
 		// Node info
@@ -138,7 +284,18 @@ func init() {
 		ForkChoiceBlockProcessingTime,
 		AttestationsValid,
 		AttestationsInvalid,
+		AttestationsHeld,
+		AttestationsReplayed,
+		AttestationsExpired,
+		AttestationsPending,
 		AttestationValidationTime,
+		ForkChoiceReorgs,
+		ForkChoiceReorgDepth,
+		Equivocations,
+		EquivocationEvidenceDropped,
+		PendingBlocks,
+		SignatureVerifications,
+		SignatureVerificationTime,
 		// State transition
 		LatestJustifiedSlot,
 		LatestFinalizedSlot,
@@ -150,8 +307,26 @@ func init() {
 		STFAttestationsProcessingTime,
 		// Validator
 		ValidatorsCount,
+		ValidatorSignaturesRemaining,
+		SlashingProtectionRefusals,
+		RemoteSignerRequests,
+		RemoteSignerRequestTime,
 		// Network
 		ConnectedPeers,
+		KnownPeers,
+		BannedPeers,
+		PeerReports,
+		PeerDials,
+		PeerPingRTT,
+		Goodbyes,
+		PeerStatusChecks,
+		ReqRespThrottled,
+		GossipValidation,
+		GossipPeerScore,
+		GossipPeersBelowThreshold,
+		// Sync
+		SyncState,
+		SyncDistance,
 	)
 }
 
@@ -0,0 +1,290 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss"
+)
+
+// API paths, modeled on Web3Signer's eth2 API.
+const (
+	signPath       = "/api/v1/lean/sign/"
+	publicKeysPath = "/api/v1/lean/publicKeys"
+	upcheckPath    = "/upcheck"
+)
+
+// Remote request defaults. A signature is needed within the one-second
+// interval it is produced in, so attempts are short. The caller's deadline
+// shortens the last attempt, and retries that could not get a full attempt
+// before it are not made.
+const (
+	DefaultTimeout    = 400 * time.Millisecond
+	DefaultRetries    = 2
+	DefaultRetryDelay = 100 * time.Millisecond
+
+	maxResponseSize = 64 << 10
+)
+
+var (
+	// ErrUnknownKey is returned when the remote signer holds no key for
+	// the validator.
+	ErrUnknownKey = errors.New("remote signer has no key for validator")
+	// ErrRefused is returned when the remote signer refuses to sign, for
+	// instance because the key already signed in that slot.
+	ErrRefused = errors.New("remote signer refused to sign")
+	// ErrInvalidSignature is returned when the remote signer answers with
+	// a signature that does not verify against the validator's pubkey.
+	ErrInvalidSignature = errors.New("remote signer returned an invalid signature")
+
+	// errUnavailable marks failures worth retrying: the signer could not be
+	// reached or answered that it is temporarily unavailable. Any other
+	// answer, such as a refusal or a failure of its key state, is final.
+	errUnavailable = errors.New("remote signer unavailable")
+)
+
+// signRequestJSON is the body of a sign request. Following Web3Signer,
+// only the signing root and object type are sent, plus the slot that
+// selects the one-time key.
+type signRequestJSON struct {
+	Type        Type   `json:"type"`
+	Slot        string `json:"slot"`
+	SigningRoot string `json:"signingRoot"`
+}
+
+type signResponseJSON struct {
+	Signature string `json:"signature"`
+}
+
+// Remote signs by calling a remote signer over HTTP. Keys are addressed by
+// public key, looked up from the validator index.
+type Remote struct {
+	URL string
+	// Timeout bounds each attempt. An attempt also ends at the deadline of
+	// the caller's context.
+	Timeout time.Duration
+	// Retries is how many times a request is retried after a network
+	// error or while the signer is unavailable (HTTP 502, 503 or 504).
+	// Refusals and other server errors are not retried.
+	Retries    int
+	RetryDelay time.Duration
+
+	client  *http.Client
+	pubkeys [][52]byte
+	log     *slog.Logger
+}
+
+// NewRemote returns a client for the remote signer at url signing for
+// the given genesis validators.
+func NewRemote(url string, validators []*types.Validator) *Remote {
+	pubkeys := make([][52]byte, len(validators))
+	for i, v := range validators {
+		pubkeys[i] = v.Pubkey
+	}
+	return &Remote{
+		URL:        strings.TrimSuffix(url, "/"),
+		Timeout:    DefaultTimeout,
+		Retries:    DefaultRetries,
+		RetryDelay: DefaultRetryDelay,
+		client:     &http.Client{},
+		pubkeys:    pubkeys,
+		log:        logging.NewComponentLogger(logging.CompValidator),
+	}
+}
+
+func (r *Remote) Sign(ctx context.Context, req *Request) ([3116]byte, error) {
+	var sig [3116]byte
+	if req.Validator >= uint64(len(r.pubkeys)) {
+		return sig, fmt.Errorf("validator %d not in genesis", req.Validator)
+	}
+	body, err := json.Marshal(signRequestJSON{
+		Type:        req.Type,
+		Slot:        strconv.FormatUint(req.Slot, 10),
+		SigningRoot: "0x" + hex.EncodeToString(req.SigningRoot[:]),
+	})
+	if err != nil {
+		return sig, err
+	}
+	url := r.URL + signPath + "0x" + hex.EncodeToString(r.pubkeys[req.Validator][:])
+
+	start := time.Now()
+	delay := r.RetryDelay
+	for attempt := 0; ; attempt++ {
+		sig, err = r.sign(ctx, url, body)
+		if err == nil {
+			if xmss.Verify(r.pubkeys[req.Validator], req.Slot, req.SigningRoot, &sig) != nil {
+				metrics.RemoteSignerRequests.WithLabelValues("failure").Inc()
+				return sig, ErrInvalidSignature
+			}
+			metrics.RemoteSignerRequests.WithLabelValues("success").Inc()
+			metrics.RemoteSignerRequestTime.Observe(time.Since(start).Seconds())
+			return sig, nil
+		}
+		if attempt >= r.Retries || !errors.Is(err, errUnavailable) || ctx.Err() != nil {
+			metrics.RemoteSignerRequests.WithLabelValues("failure").Inc()
+			return sig, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay+r.Timeout {
+			metrics.RemoteSignerRequests.WithLabelValues("failure").Inc()
+			r.log.Debug("no time left to retry remote signer request",
+				"validator", req.Validator,
+				"slot", req.Slot,
+				"attempt", attempt+1,
+				"err", err,
+			)
+			return sig, err
+		}
+		metrics.RemoteSignerRequests.WithLabelValues("retry").Inc()
+		r.log.Debug("retrying remote signer request",
+			"validator", req.Validator,
+			"slot", req.Slot,
+			"attempt", attempt+1,
+			"err", err,
+		)
+		select {
+		case <-ctx.Done():
+			return sig, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// sign makes one sign request, given Timeout or the time left before the
+// deadline of ctx, whichever is shorter.
+func (r *Remote) sign(ctx context.Context, url string, body []byte) ([3116]byte, error) {
+	var sig [3116]byte
+	timeout := r.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return sig, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return sig, fmt.Errorf("%w: %w", errUnavailable, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return sig, fmt.Errorf("%w: %w", errUnavailable, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return sig, ErrUnknownKey
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return sig, fmt.Errorf("%w: %s", ErrRefused, errorMessage(data))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return sig, fmt.Errorf("%w: %s: %s", ErrRefused, resp.Status, errorMessage(data))
+	case resp.StatusCode == http.StatusBadGateway, resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return sig, fmt.Errorf("%w: %s: %s", errUnavailable, resp.Status, errorMessage(data))
+	default:
+		return sig, fmt.Errorf("remote signer: %s: %s", resp.Status, errorMessage(data))
+	}
+
+	var out signResponseJSON
+	if err := json.Unmarshal(data, &out); err != nil {
+		return sig, fmt.Errorf("decode signature: %w", err)
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(out.Signature, "0x"))
+	if err != nil || len(raw) != len(sig) {
+		return sig, fmt.Errorf("invalid signature from remote signer")
+	}
+	copy(sig[:], raw)
+	return sig, nil
+}
+
+// PublicKeys lists the public keys the remote signer holds.
+func (r *Remote) PublicKeys(ctx context.Context) ([][52]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL+publicKeysPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer: %s", resp.Status)
+	}
+	var list []string
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("decode public keys: %w", err)
+	}
+	keys := make([][52]byte, 0, len(list))
+	for _, s := range list {
+		pk, err := parsePubkey(s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, pk)
+	}
+	return keys, nil
+}
+
+// Missing returns the validators among indices whose keys the remote
+// signer does not hold.
+func (r *Remote) Missing(ctx context.Context, indices []uint64) ([]uint64, error) {
+	keys, err := r.PublicKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[[52]byte]bool, len(keys))
+	for _, pk := range keys {
+		held[pk] = true
+	}
+	var missing []uint64
+	for _, idx := range indices {
+		if idx >= uint64(len(r.pubkeys)) || !held[r.pubkeys[idx]] {
+			missing = append(missing, idx)
+		}
+	}
+	return missing, nil
+}
+
+func parsePubkey(s string) ([52]byte, error) {
+	var pk [52]byte
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(raw) != len(pk) {
+		return pk, fmt.Errorf("invalid public key %q", s)
+	}
+	copy(pk[:], raw)
+	return pk, nil
+}
+
+// errorMessage extracts the message of a JSON error body, falling back to
+// the raw body.
+func errorMessage(data []byte) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		return body.Message
+	}
+	return strings.TrimSpace(string(data))
+}
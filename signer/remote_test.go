@@ -0,0 +1,223 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/geanlabs/gean/keymanager"
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss"
+)
+
+// newTestServer serves keys for validators 0 and 1 of a three-validator
+// genesis. Requests pass through wrap, if set, before reaching the server.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) (*httptest.Server, []*types.Validator) {
+	t.Helper()
+	dir := t.TempDir()
+	validators := make([]*types.Validator, 3)
+	for idx := range validators {
+		key, err := xmss.NewKey([xmss.SeedSize]byte{byte(idx)}, 0, 8)
+		if err != nil {
+			t.Fatalf("NewKey: %v", err)
+		}
+		validators[idx] = &types.Validator{Pubkey: key.PublicKey(), Index: uint64(idx)}
+		if idx < 2 {
+			if err := keymanager.Create(dir, keymanager.XMSS{}, uint64(idx), key); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+	}
+	keys, err := keymanager.Load(dir, keymanager.XMSS{}, []uint64{0, 1})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var h http.Handler = NewServer(Local{Keys: keys}, validators, []uint64{0, 1}).Handler()
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, validators
+}
+
+// countRequests counts requests and fails the first failures of them
+// with 503.
+func countRequests(count *atomic.Int32, failures int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) <= failures {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRemoteSign(t *testing.T) {
+	var count atomic.Int32
+	srv, validators := newTestServer(t, countRequests(&count, 0))
+	remote := NewRemote(srv.URL, validators)
+	ctx := context.Background()
+
+	req := &Request{Validator: 1, Type: TypeAttestation, Slot: 2, SigningRoot: [32]byte{7}}
+	sig, err := remote.Sign(ctx, req)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := xmss.Verify(validators[1].Pubkey, 2, req.SigningRoot, &sig); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// A repeated request, as after a lost response, gets the same signature.
+	again, err := remote.Sign(ctx, req)
+	if err != nil {
+		t.Fatalf("repeated Sign: %v", err)
+	}
+	if again != sig {
+		t.Fatal("repeated request returned a different signature")
+	}
+
+	count.Store(0)
+	other := &Request{Validator: 1, Type: TypeAttestation, Slot: 2, SigningRoot: [32]byte{8}}
+	if _, err := remote.Sign(ctx, other); !errors.Is(err, ErrRefused) {
+		t.Fatalf("err = %v, want %v", err, ErrRefused)
+	}
+	if n := count.Load(); n != 1 {
+		t.Fatalf("refused request sent %d times, want 1", n)
+	}
+
+	if _, err := remote.Sign(ctx, &Request{Validator: 2, Type: TypeBlock, Slot: 3}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownKey)
+	}
+	if _, err := remote.Sign(ctx, &Request{Validator: 0, Type: "AGGREGATE", Slot: 3}); !errors.Is(err, ErrRefused) {
+		t.Fatalf("unknown type err = %v, want %v", err, ErrRefused)
+	}
+
+	missing, err := remote.Missing(ctx, []uint64{0, 1, 2})
+	if err != nil {
+		t.Fatalf("Missing: %v", err)
+	}
+	if len(missing) != 1 || missing[0] != 2 {
+		t.Fatalf("missing = %v, want [2]", missing)
+	}
+}
+
+func TestRemoteRetries(t *testing.T) {
+	var count atomic.Int32
+	srv, validators := newTestServer(t, countRequests(&count, 2))
+	remote := NewRemote(srv.URL, validators)
+	remote.RetryDelay = time.Millisecond
+
+	req := &Request{Validator: 0, Type: TypeBlock, Slot: 1, SigningRoot: [32]byte{1}}
+	if _, err := remote.Sign(context.Background(), req); err != nil {
+		t.Fatalf("Sign with %d retries: %v", remote.Retries, err)
+	}
+	if n := count.Load(); n != 3 {
+		t.Fatalf("requests = %d, want 3", n)
+	}
+
+	count.Store(0)
+	remote.Retries = 1
+	req.Slot = 2
+	if _, err := remote.Sign(context.Background(), req); err == nil {
+		t.Fatal("Sign succeeded after retries ran out")
+	}
+	if n := count.Load(); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+}
+
+func TestRemoteDoesNotRetryServerErrors(t *testing.T) {
+	var count atomic.Int32
+	srv, validators := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			writeJSONError(w, http.StatusInternalServerError, "key state rolled back")
+		})
+	})
+	remote := NewRemote(srv.URL, validators)
+	remote.RetryDelay = time.Millisecond
+
+	if _, err := remote.Sign(context.Background(), &Request{Validator: 0, Type: TypeBlock, Slot: 1}); err == nil {
+		t.Fatal("Sign succeeded against a failing signer")
+	}
+	if n := count.Load(); n != 1 {
+		t.Fatalf("requests = %d, want 1", n)
+	}
+}
+
+func TestRemoteVerifiesSignature(t *testing.T) {
+	var other string
+	srv, validators := newTestServer(t, func(next http.Handler) http.Handler {
+		// Misconfigured signer: every request is signed with validator 1's key.
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Path = signPath + other
+			next.ServeHTTP(w, r)
+		})
+	})
+	other = "0x" + hex.EncodeToString(validators[1].Pubkey[:])
+	remote := NewRemote(srv.URL, validators)
+
+	req := &Request{Validator: 0, Type: TypeAttestation, Slot: 1, SigningRoot: [32]byte{1}}
+	if _, err := remote.Sign(context.Background(), req); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestRemoteTimeout(t *testing.T) {
+	var count atomic.Int32
+	srv, validators := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+		})
+	})
+	remote := NewRemote(srv.URL, validators)
+	remote.Timeout = 20 * time.Millisecond
+	remote.RetryDelay = time.Millisecond
+
+	start := time.Now()
+	if _, err := remote.Sign(context.Background(), &Request{Validator: 0, Type: TypeAttestation, Slot: 1}); err == nil {
+		t.Fatal("Sign succeeded against a stalled signer")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Sign took %v, want attempts bounded by the timeout", elapsed)
+	}
+	if n := count.Load(); n != int32(remote.Retries+1) {
+		t.Fatalf("requests = %d, want %d", n, remote.Retries+1)
+	}
+}
+
+func TestRemoteRetriesFitDeadline(t *testing.T) {
+	var count atomic.Int32
+	srv, validators := newTestServer(t, countRequests(&count, 100))
+	remote := NewRemote(srv.URL, validators)
+	remote.Timeout = 200 * time.Millisecond
+	remote.RetryDelay = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := remote.Sign(ctx, &Request{Validator: 0, Type: TypeAttestation, Slot: 1})
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the signer's error before the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("Sign took %v, want no retry that cannot finish by the deadline", elapsed)
+	}
+	// The first retry fits: 300ms left is more than the 50ms delay plus a
+	// 200ms attempt. The second, after a 100ms delay, does not.
+	if n := count.Load(); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+}
@@ -0,0 +1,160 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/geanlabs/gean/keymanager"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// maxRequestSize bounds the body of a sign request.
+const maxRequestSize = 4 << 10
+
+// Server is a reference remote signer serving the API Remote calls. It
+// signs with keys held by a local Signer.
+//
+// It does not see the messages it signs, only their roots, so it cannot
+// enforce slashing protection; that is left to the node's database. It
+// remembers the last signature of each validator and returns it again for
+// a repeated request, so that clients can retry requests whose response
+// was lost.
+type Server struct {
+	signer  Signer
+	indices map[[52]byte]uint64
+	pubkeys []string
+
+	mu   sync.Mutex
+	last map[uint64]lastSignature
+	log  *slog.Logger
+}
+
+type lastSignature struct {
+	slot uint64
+	root [32]byte
+	sig  [3116]byte
+}
+
+// NewServer returns a server signing with s for the given indices of the
+// genesis validators.
+func NewServer(s Signer, validators []*types.Validator, indices []uint64) *Server {
+	srv := &Server{
+		signer:  s,
+		indices: make(map[[52]byte]uint64, len(indices)),
+		pubkeys: make([]string, 0, len(indices)),
+		last:    make(map[uint64]lastSignature),
+		log:     logging.NewComponentLogger(logging.CompValidator),
+	}
+	for _, idx := range indices {
+		pk := validators[idx].Pubkey
+		srv.indices[pk] = idx
+		srv.pubkeys = append(srv.pubkeys, "0x"+hex.EncodeToString(pk[:]))
+	}
+	return srv
+}
+
+// Handler returns the router with all endpoints registered.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+upcheckPath, s.handleUpcheck)
+	mux.HandleFunc("GET "+publicKeysPath, s.handlePublicKeys)
+	mux.HandleFunc("POST "+signPath+"{identifier}", s.handleSign)
+	return mux
+}
+
+func (s *Server) handleUpcheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("OK"))
+}
+
+func (s *Server) handlePublicKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.pubkeys)
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	pk, err := parsePubkey(r.PathValue("identifier"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := s.indices[pk]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "no key for "+r.PathValue("identifier"))
+		return
+	}
+	var body signRequestJSON
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	req := &Request{Validator: idx, Type: body.Type}
+	if !req.Type.valid() {
+		writeJSONError(w, http.StatusBadRequest, "unknown signing type "+strconv.Quote(string(body.Type)))
+		return
+	}
+	if req.Slot, err = strconv.ParseUint(body.Slot, 10, 64); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid slot")
+		return
+	}
+	root, err := hex.DecodeString(strings.TrimPrefix(body.SigningRoot, "0x"))
+	if err != nil || len(root) != 32 {
+		writeJSONError(w, http.StatusBadRequest, "invalid signing root")
+		return
+	}
+	req.SigningRoot = [32]byte(root)
+
+	sig, err := s.sign(r, req)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, signResponseJSON{Signature: "0x" + hex.EncodeToString(sig[:])})
+	case errors.Is(err, keymanager.ErrEpochUsed), errors.Is(err, keymanager.ErrKeyExhausted),
+		errors.Is(err, keymanager.ErrStateRolledBack), errors.Is(err, keymanager.ErrStateMissing):
+		// The key state forbids signing; asking again will not help.
+		writeJSONError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		s.log.Error("signing failed",
+			"validator", idx,
+			"slot", req.Slot,
+			"err", err,
+		)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// sign signs req, or returns the last signature if req repeats the last
+// request of its validator.
+func (s *Server) sign(r *http.Request, req *Request) ([3116]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.last[req.Validator]; ok && last.slot == req.Slot && last.root == req.SigningRoot {
+		return last.sig, nil
+	}
+	sig, err := s.signer.Sign(r.Context(), req)
+	if err != nil {
+		return sig, err
+	}
+	s.last[req.Validator] = lastSignature{slot: req.Slot, root: req.SigningRoot, sig: sig}
+	s.log.Debug("signed",
+		"validator", req.Validator,
+		"type", req.Type,
+		"slot", req.Slot,
+	)
+	return sig, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]any{"code": code, "message": msg})
+}
@@ -0,0 +1,51 @@
+// Package signer signs validator messages, either with keys held by the
+// node or by asking a remote signer over HTTP, so that validator secrets
+// can be kept off the consensus node.
+package signer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/geanlabs/gean/keymanager"
+)
+
+// Type is the kind of object a signature is requested for.
+type Type string
+
+const (
+	// TypeAttestation is a validator's vote in a slot.
+	TypeAttestation Type = "ATTESTATION"
+	// TypeBlock is the proposer attestation that signs a proposed block.
+	TypeBlock Type = "BLOCK"
+)
+
+func (t Type) valid() bool {
+	return t == TypeAttestation || t == TypeBlock
+}
+
+// Request asks for a signature of Validator over SigningRoot in Slot, the
+// one-time key epoch.
+type Request struct {
+	Validator   uint64
+	Type        Type
+	Slot        uint64
+	SigningRoot [32]byte
+}
+
+// Signer signs requests for the validators it holds keys for.
+type Signer interface {
+	Sign(ctx context.Context, req *Request) ([3116]byte, error)
+}
+
+// Local signs with keys loaded from a key directory.
+type Local struct {
+	Keys *keymanager.Manager
+}
+
+func (l Local) Sign(ctx context.Context, req *Request) ([3116]byte, error) {
+	if !req.Type.valid() {
+		return [3116]byte{}, fmt.Errorf("unknown signing type %q", req.Type)
+	}
+	return l.Keys.Sign(req.Validator, req.Slot, req.SigningRoot)
+}
@@ -0,0 +1,160 @@
+package slashing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// interchangeVersion is the version of the interchange format written by
+// Export. The format follows EIP-3076, with validators identified by index
+// instead of pubkey, slots in place of epochs and an attestation slot.
+const interchangeVersion = "5"
+
+// interchange is a slashing protection history that can be moved between
+// machines.
+type interchange struct {
+	Metadata interchangeMetadata `json:"metadata"`
+	Data     []interchangeEntry  `json:"data"`
+}
+
+type interchangeMetadata struct {
+	Version     string `json:"interchange_format_version"`
+	GenesisRoot root   `json:"genesis_root"`
+}
+
+// interchangeEntry is the history of one validator.
+type interchangeEntry struct {
+	ValidatorIndex     uint64                   `json:"validator_index,string"`
+	SignedBlocks       []interchangeBlock       `json:"signed_blocks"`
+	SignedAttestations []interchangeAttestation `json:"signed_attestations"`
+}
+
+type interchangeBlock struct {
+	Slot        uint64 `json:"slot,string"`
+	SigningRoot *root  `json:"signing_root,omitempty"`
+}
+
+type interchangeAttestation struct {
+	Slot        uint64 `json:"slot,string"`
+	SourceSlot  uint64 `json:"source_slot,string"`
+	TargetSlot  uint64 `json:"target_slot,string"`
+	SigningRoot *root  `json:"signing_root,omitempty"`
+}
+
+// Export writes the history of every validator in the database as
+// interchange JSON for the chain with genesisRoot. Lower bounds are written
+// as records without a signing root.
+func (db *DB) Export(w io.Writer, genesisRoot [32]byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ic := interchange{
+		Metadata: interchangeMetadata{Version: interchangeVersion, GenesisRoot: genesisRoot},
+		Data:     make([]interchangeEntry, 0, len(db.validators)),
+	}
+	for index, h := range db.validators {
+		entry := interchangeEntry{
+			ValidatorIndex:     index,
+			SignedBlocks:       make([]interchangeBlock, 0, len(h.Blocks)+1),
+			SignedAttestations: make([]interchangeAttestation, 0, len(h.Attestations)+1),
+		}
+		if h.Bounds.BlockSlot > 0 {
+			entry.SignedBlocks = append(entry.SignedBlocks, interchangeBlock{Slot: h.Bounds.BlockSlot - 1})
+		}
+		for _, b := range h.Blocks {
+			entry.SignedBlocks = append(entry.SignedBlocks, interchangeBlock{Slot: b.Slot, SigningRoot: &b.SigningRoot})
+		}
+		if h.Bounds.AttestationSlot > 0 {
+			entry.SignedAttestations = append(entry.SignedAttestations, interchangeAttestation{
+				Slot:       h.Bounds.AttestationSlot - 1,
+				SourceSlot: h.Bounds.Source,
+				TargetSlot: h.Bounds.Target,
+			})
+		}
+		for _, a := range h.Attestations {
+			entry.SignedAttestations = append(entry.SignedAttestations, interchangeAttestation{
+				Slot:        a.Slot,
+				SourceSlot:  a.Source,
+				TargetSlot:  a.Target,
+				SigningRoot: &a.SigningRoot,
+			})
+		}
+		ic.Data = append(ic.Data, entry)
+	}
+	sort.Slice(ic.Data, func(i, j int) bool { return ic.Data[i].ValidatorIndex < ic.Data[j].ValidatorIndex })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&ic)
+}
+
+// Import merges interchange JSON for the chain with genesisRoot into the
+// database. The imported history may be incomplete, so it only raises the
+// lower bounds: afterwards nothing is signed at or below its latest slots,
+// or with a source or target below the highest it contains.
+func (db *DB) Import(r io.Reader, genesisRoot [32]byte) error {
+	var ic interchange
+	if err := json.NewDecoder(r).Decode(&ic); err != nil {
+		return fmt.Errorf("decode interchange: %w", err)
+	}
+	if ic.Metadata.Version != interchangeVersion {
+		return fmt.Errorf("unsupported interchange version %q", ic.Metadata.Version)
+	}
+	if ic.Metadata.GenesisRoot != genesisRoot {
+		return fmt.Errorf("interchange is for genesis root %s, not %s", ic.Metadata.GenesisRoot, root(genesisRoot))
+	}
+
+	for _, entry := range ic.Data {
+		for _, a := range entry.SignedAttestations {
+			if a.SourceSlot > a.TargetSlot {
+				return fmt.Errorf("validator %d: attestation at slot %d has source %d after target %d",
+					entry.ValidatorIndex, a.Slot, a.SourceSlot, a.TargetSlot)
+			}
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, entry := range ic.Data {
+		next := db.history(entry.ValidatorIndex).clone()
+		for _, b := range entry.SignedBlocks {
+			next.Bounds.foldBlock(b.Slot)
+		}
+		for _, a := range entry.SignedAttestations {
+			next.Bounds.foldAttestation(a.Slot, a.SourceSlot, a.TargetSlot)
+		}
+		if err := db.commit(entry.ValidatorIndex, next); err != nil {
+			return err
+		}
+		db.log.Info("imported slashing protection",
+			"validator", entry.ValidatorIndex,
+			"blocks", len(entry.SignedBlocks),
+			"attestations", len(entry.SignedAttestations),
+		)
+	}
+	return nil
+}
+
+// root is a 32-byte root encoded as 0x-prefixed hex in JSON.
+type root [32]byte
+
+func (r root) String() string {
+	return "0x" + hex.EncodeToString(r[:])
+}
+
+func (r root) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *root) UnmarshalText(text []byte) error {
+	s, ok := strings.CutPrefix(string(text), "0x")
+	if !ok || hex.DecodedLen(len(s)) != len(r) {
+		return fmt.Errorf("invalid root %q", text)
+	}
+	_, err := hex.Decode(r[:], []byte(s))
+	return err
+}
@@ -0,0 +1,292 @@
+// Package slashing keeps validators from signing messages that conflict
+// with what they signed before.
+//
+// The database records, per validator index, the slots of the blocks it
+// proposed and the slot, source and target of every attestation it signed.
+// A validator may sign at most one block and one attestation per slot, and
+// no attestation whose source and target surround, or are surrounded by,
+// those of an earlier one. Every record is synced to disk before the caller
+// signs, so the protection survives restarts.
+//
+// To keep the history bounded, old records are dropped and folded into
+// per-validator lower bounds: nothing is signed at or below the slot of a
+// dropped record, and no vote goes below the highest source or target a
+// dropped record had. Imported histories are folded into lower bounds the
+// same way.
+package slashing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// historyLimit is how many blocks and attestations are kept per validator
+// before the oldest are folded into the lower bounds.
+const historyLimit = 1024
+
+var (
+	ErrDoubleProposal = errors.New("double proposal")
+	ErrDoubleVote     = errors.New("double vote")
+	ErrSurroundVote   = errors.New("surround vote")
+)
+
+// DB is a slashing protection database.
+type DB struct {
+	mu         sync.Mutex
+	dir        string
+	validators map[uint64]*history
+	log        *slog.Logger
+}
+
+// history is what a validator has signed. It is also the content of its
+// file in the database directory.
+type history struct {
+	Blocks       []blockRecord       `json:"signed_blocks"`
+	Attestations []attestationRecord `json:"signed_attestations"`
+	Bounds       bounds              `json:"lower_bounds"`
+}
+
+type blockRecord struct {
+	Slot        uint64 `json:"slot"`
+	SigningRoot root   `json:"signing_root"`
+}
+
+type attestationRecord struct {
+	Slot        uint64 `json:"slot"`
+	Source      uint64 `json:"source_slot"`
+	Target      uint64 `json:"target_slot"`
+	SigningRoot root   `json:"signing_root"`
+}
+
+// bounds are the lowest values a validator may still sign. Zero values
+// impose no bound.
+type bounds struct {
+	BlockSlot       uint64 `json:"block_slot"`
+	AttestationSlot uint64 `json:"attestation_slot"`
+	Source          uint64 `json:"source_slot"`
+	Target          uint64 `json:"target_slot"`
+}
+
+// Open opens the database in dir, creating the directory if needed. An
+// empty dir keeps the database in memory only.
+func Open(dir string) (*DB, error) {
+	db := &DB{
+		dir:        dir,
+		validators: make(map[uint64]*history),
+		log:        logging.NewComponentLogger(logging.CompValidator),
+	}
+	if dir == "" {
+		return db, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		index, ok := parseFileName(e.Name())
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		h := new(history)
+		if err := json.Unmarshal(data, h); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", e.Name(), err)
+		}
+		db.validators[index] = h
+	}
+	return db, nil
+}
+
+// CheckBlock records that proposer signs the block with root at slot, or
+// returns an error if that could be slashable. Signing the same block again
+// is allowed.
+func (db *DB) CheckBlock(proposer, slot uint64, signingRoot [32]byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	h := db.history(proposer)
+
+	if slot < h.Bounds.BlockSlot {
+		return db.refuse("block", fmt.Errorf("%w: slot %d below lower bound %d", ErrDoubleProposal, slot, h.Bounds.BlockSlot))
+	}
+	for _, b := range h.Blocks {
+		if b.Slot != slot {
+			continue
+		}
+		if b.SigningRoot == signingRoot {
+			return nil
+		}
+		return db.refuse("block", fmt.Errorf("%w: already signed a different block at slot %d", ErrDoubleProposal, slot))
+	}
+
+	next := h.clone()
+	next.Blocks = append(next.Blocks, blockRecord{Slot: slot, SigningRoot: signingRoot})
+	if len(next.Blocks) > historyLimit {
+		sort.Slice(next.Blocks, func(i, j int) bool { return next.Blocks[i].Slot < next.Blocks[j].Slot })
+		dropped := len(next.Blocks) - historyLimit
+		for _, b := range next.Blocks[:dropped] {
+			next.Bounds.foldBlock(b.Slot)
+		}
+		next.Blocks = next.Blocks[dropped:]
+	}
+	return db.commit(proposer, next)
+}
+
+// CheckAttestation records that validator signs the attestation with
+// signing root at slot voting from source to target, or returns an error
+// if that could be slashable. Signing the same attestation again is
+// allowed.
+func (db *DB) CheckAttestation(validator, slot, source, target uint64, signingRoot [32]byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	h := db.history(validator)
+
+	switch {
+	case slot < h.Bounds.AttestationSlot:
+		return db.refuse("attestation", fmt.Errorf("%w: slot %d below lower bound %d", ErrDoubleVote, slot, h.Bounds.AttestationSlot))
+	case source < h.Bounds.Source:
+		return db.refuse("attestation", fmt.Errorf("%w: source %d below lower bound %d", ErrSurroundVote, source, h.Bounds.Source))
+	case target < h.Bounds.Target:
+		return db.refuse("attestation", fmt.Errorf("%w: target %d below lower bound %d", ErrSurroundVote, target, h.Bounds.Target))
+	}
+	for _, a := range h.Attestations {
+		if a.Slot == slot {
+			if a.SigningRoot == signingRoot {
+				return nil
+			}
+			return db.refuse("attestation", fmt.Errorf("%w: already signed a different attestation at slot %d", ErrDoubleVote, slot))
+		}
+		if source < a.Source && target > a.Target {
+			return db.refuse("attestation", fmt.Errorf("%w: %d->%d surrounds %d->%d signed at slot %d", ErrSurroundVote, source, target, a.Source, a.Target, a.Slot))
+		}
+		if source > a.Source && target < a.Target {
+			return db.refuse("attestation", fmt.Errorf("%w: %d->%d is surrounded by %d->%d signed at slot %d", ErrSurroundVote, source, target, a.Source, a.Target, a.Slot))
+		}
+	}
+
+	next := h.clone()
+	next.Attestations = append(next.Attestations, attestationRecord{Slot: slot, Source: source, Target: target, SigningRoot: signingRoot})
+	if len(next.Attestations) > historyLimit {
+		sort.Slice(next.Attestations, func(i, j int) bool { return next.Attestations[i].Slot < next.Attestations[j].Slot })
+		dropped := len(next.Attestations) - historyLimit
+		for _, a := range next.Attestations[:dropped] {
+			next.Bounds.foldAttestation(a.Slot, a.Source, a.Target)
+		}
+		next.Attestations = next.Attestations[dropped:]
+	}
+	return db.commit(validator, next)
+}
+
+// foldBlock raises the bounds so that nothing conflicting with a block at
+// slot can be signed without its record.
+func (b *bounds) foldBlock(slot uint64) {
+	b.BlockSlot = max(b.BlockSlot, slot+1)
+}
+
+// foldAttestation raises the bounds so that nothing conflicting with an
+// attestation at slot from source to target can be signed without its
+// record.
+func (b *bounds) foldAttestation(slot, source, target uint64) {
+	b.AttestationSlot = max(b.AttestationSlot, slot+1)
+	b.Source = max(b.Source, source)
+	b.Target = max(b.Target, target)
+}
+
+func (h *history) clone() *history {
+	return &history{
+		Blocks:       append([]blockRecord(nil), h.Blocks...),
+		Attestations: append([]attestationRecord(nil), h.Attestations...),
+		Bounds:       h.Bounds,
+	}
+}
+
+func (db *DB) history(index uint64) *history {
+	if h, ok := db.validators[index]; ok {
+		return h
+	}
+	return new(history)
+}
+
+func (db *DB) refuse(kind string, err error) error {
+	metrics.SlashingProtectionRefusals.WithLabelValues(kind).Inc()
+	return err
+}
+
+// commit writes h to the file of validator index and then makes it the
+// validator's history, so a record is never trusted before it is on disk.
+func (db *DB) commit(index uint64, h *history) error {
+	if db.dir != "" {
+		data, err := json.Marshal(h)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(db.dir, fileName(index), data); err != nil {
+			return fmt.Errorf("persist slashing protection: %w", err)
+		}
+	}
+	db.validators[index] = h
+	return nil
+}
+
+func fileName(index uint64) string {
+	return "validator_" + strconv.FormatUint(index, 10) + ".json"
+}
+
+func parseFileName(name string) (uint64, bool) {
+	s, ok := strings.CutPrefix(name, "validator_")
+	if !ok {
+		return 0, false
+	}
+	s, ok = strings.CutSuffix(s, ".json")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.ParseUint(s, 10, 64)
+	return index, err == nil
+}
+
+// writeFileAtomic replaces dir/name with data through a synced temp file,
+// then syncs dir so the rename survives a crash.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,160 @@
+package slashing
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func open(t *testing.T, dir string) *DB {
+	t.Helper()
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return db
+}
+
+func TestBlocks(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+
+	if err := db.CheckBlock(1, 10, [32]byte{1}); err != nil {
+		t.Fatalf("first block: %v", err)
+	}
+	if err := db.CheckBlock(1, 10, [32]byte{1}); err != nil {
+		t.Fatalf("same block again: %v", err)
+	}
+	if err := db.CheckBlock(2, 10, [32]byte{2}); err != nil {
+		t.Fatalf("other proposer: %v", err)
+	}
+	if err := db.CheckBlock(1, 11, [32]byte{3}); err != nil {
+		t.Fatalf("next slot: %v", err)
+	}
+
+	// The history survives a restart.
+	db = open(t, dir)
+	if err := db.CheckBlock(1, 10, [32]byte{4}); !errors.Is(err, ErrDoubleProposal) {
+		t.Fatalf("err = %v, want %v", err, ErrDoubleProposal)
+	}
+	if err := db.CheckBlock(1, 10, [32]byte{1}); err != nil {
+		t.Fatalf("same block after restart: %v", err)
+	}
+}
+
+func TestAttestations(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+
+	// Consecutive slots may vote for the same source and target.
+	for slot := uint64(5); slot < 8; slot++ {
+		if err := db.CheckAttestation(1, slot, 2, 4, [32]byte{byte(slot)}); err != nil {
+			t.Fatalf("slot %d: %v", slot, err)
+		}
+	}
+	if err := db.CheckAttestation(1, 6, 2, 4, [32]byte{6}); err != nil {
+		t.Fatalf("same attestation again: %v", err)
+	}
+
+	db = open(t, dir)
+	tests := []struct {
+		name                            string
+		validator, slot, source, target uint64
+		want                            error
+	}{
+		{"double vote", 1, 6, 2, 4, ErrDoubleVote},
+		{"surrounding", 1, 8, 1, 5, ErrSurroundVote},
+		{"surrounded", 1, 8, 3, 3, ErrSurroundVote},
+		{"new source and target", 1, 8, 4, 6, nil},
+		{"other validator", 2, 6, 0, 9, nil},
+	}
+	for _, tt := range tests {
+		err := db.CheckAttestation(tt.validator, tt.slot, tt.source, tt.target, [32]byte{0xff})
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	db := open(t, "")
+	for slot := uint64(1); slot <= historyLimit+1; slot++ {
+		if err := db.CheckAttestation(1, slot, slot/2, slot, [32]byte{1}); err != nil {
+			t.Fatalf("slot %d: %v", slot, err)
+		}
+		if err := db.CheckBlock(1, slot, [32]byte{1}); err != nil {
+			t.Fatalf("block at slot %d: %v", slot, err)
+		}
+	}
+	h := db.validators[1]
+	if len(h.Attestations) != historyLimit || len(h.Blocks) != historyLimit {
+		t.Fatalf("history = %d attestations, %d blocks, want %d", len(h.Attestations), len(h.Blocks), historyLimit)
+	}
+
+	// Slot 1 was dropped, so even the identical messages are refused.
+	if err := db.CheckAttestation(1, 1, 0, 1, [32]byte{1}); !errors.Is(err, ErrDoubleVote) {
+		t.Fatalf("err = %v, want %v", err, ErrDoubleVote)
+	}
+	if err := db.CheckBlock(1, 1, [32]byte{1}); !errors.Is(err, ErrDoubleProposal) {
+		t.Fatalf("err = %v, want %v", err, ErrDoubleProposal)
+	}
+}
+
+func TestInterchange(t *testing.T) {
+	genesisRoot := [32]byte{0xaa}
+	src := open(t, t.TempDir())
+	if err := src.CheckBlock(3, 20, [32]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	for slot := uint64(20); slot < 23; slot++ {
+		if err := src.CheckAttestation(3, slot, 16, 20, [32]byte{2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := src.Export(&buf, genesisRoot); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"validator_index": "3"`) {
+		t.Fatalf("export does not name validator 3:\n%s", buf.String())
+	}
+
+	if err := open(t, t.TempDir()).Import(bytes.NewReader(buf.Bytes()), [32]byte{0xbb}); err == nil {
+		t.Fatal("imported interchange for another genesis")
+	}
+
+	dir := t.TempDir()
+	if err := open(t, dir).Import(bytes.NewReader(buf.Bytes()), genesisRoot); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	dst := open(t, dir)
+	if err := dst.CheckBlock(3, 20, [32]byte{9}); !errors.Is(err, ErrDoubleProposal) {
+		t.Fatalf("block err = %v, want %v", err, ErrDoubleProposal)
+	}
+	if err := dst.CheckAttestation(3, 22, 16, 20, [32]byte{9}); !errors.Is(err, ErrDoubleVote) {
+		t.Fatalf("attestation err = %v, want %v", err, ErrDoubleVote)
+	}
+	if err := dst.CheckAttestation(3, 23, 12, 24, [32]byte{9}); !errors.Is(err, ErrSurroundVote) {
+		t.Fatalf("surround err = %v, want %v", err, ErrSurroundVote)
+	}
+	if err := dst.CheckBlock(3, 21, [32]byte{9}); err != nil {
+		t.Fatalf("later block: %v", err)
+	}
+	if err := dst.CheckAttestation(3, 23, 16, 20, [32]byte{9}); err != nil {
+		t.Fatalf("later attestation: %v", err)
+	}
+
+	// Exporting the imported lower bounds carries them on.
+	buf.Reset()
+	if err := dst.Export(&buf, genesisRoot); err != nil {
+		t.Fatal(err)
+	}
+	again := open(t, "")
+	if err := again.Import(&buf, genesisRoot); err != nil {
+		t.Fatal(err)
+	}
+	if err := again.CheckAttestation(3, 22, 16, 20, [32]byte{2}); !errors.Is(err, ErrDoubleVote) {
+		t.Fatalf("re-exported err = %v, want %v", err, ErrDoubleVote)
+	}
+}
@@ -0,0 +1,361 @@
+package disk
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// Subdirectories and files under the data directory.
+const (
+	blocksDir       = "blocks"
+	signedBlocksDir = "signed_blocks"
+	statesDir       = "states"
+	checkpointsFile = "checkpoints"
+	fileExt         = ".ssz"
+	tmpExt          = ".tmp"
+)
+
+// checkpointsSize is head root + justified (root, slot) + finalized (root, slot).
+const checkpointsSize = 32 + 40 + 40
+
+// Store is a file-backed implementation of storage.Store.
+//
+// Every block, signed envelope and state is written as SSZ to its own file
+// named by root. Writes go to a temp file that is fsynced and then renamed
+// into place, so a crash leaves either the previous file or the complete new
+// one, never a partial write. All objects are cached in memory and the
+// directory is only read when the store is opened.
+//
+// An object that fails to persist is not cached either, and checkpoints
+// referencing it are not written, so the checkpoints file never points at
+// an object missing from disk.
+type Store struct {
+	mu           sync.RWMutex
+	dir          string
+	blocks       map[[32]byte]*types.Block
+	signedBlocks map[[32]byte]*types.SignedBlockWithAttestation
+	states       map[[32]byte]*types.State
+	checkpoints  *storage.Checkpoints
+	// unpersisted holds the roots of objects that failed to persist.
+	unpersisted map[[32]byte]struct{}
+	log         *slog.Logger
+}
+
+// New opens the store rooted at dir, creating it if needed, and loads all
+// previously persisted objects.
+func New(dir string) (*Store, error) {
+	for _, sub := range []string{blocksDir, signedBlocksDir, statesDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create %s: %w", sub, err)
+		}
+	}
+
+	s := &Store{
+		dir:          dir,
+		blocks:       make(map[[32]byte]*types.Block),
+		signedBlocks: make(map[[32]byte]*types.SignedBlockWithAttestation),
+		states:       make(map[[32]byte]*types.State),
+		unpersisted:  make(map[[32]byte]struct{}),
+		log:          logging.NewComponentLogger(logging.CompStorage),
+	}
+
+	if err := loadDir(filepath.Join(dir, blocksDir), func(root [32]byte, data []byte) error {
+		b := new(types.Block)
+		if err := b.UnmarshalSSZ(data); err != nil {
+			return err
+		}
+		s.blocks[root] = b
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("load blocks: %w", err)
+	}
+
+	if err := loadDir(filepath.Join(dir, signedBlocksDir), func(root [32]byte, data []byte) error {
+		sb := new(types.SignedBlockWithAttestation)
+		if err := sb.UnmarshalSSZ(data); err != nil {
+			return err
+		}
+		s.signedBlocks[root] = sb
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("load signed blocks: %w", err)
+	}
+
+	if err := loadDir(filepath.Join(dir, statesDir), func(root [32]byte, data []byte) error {
+		st := new(types.State)
+		if err := st.UnmarshalSSZ(data); err != nil {
+			return err
+		}
+		s.states[root] = st
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("load states: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, checkpointsFile))
+	switch {
+	case err == nil:
+		cp, err := decodeCheckpoints(data)
+		if err != nil {
+			return nil, fmt.Errorf("load checkpoints: %w", err)
+		}
+		s.checkpoints = cp
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("read checkpoints: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *Store) GetBlock(root [32]byte) (*types.Block, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blocks[root]
+	return b, ok
+}
+
+func (s *Store) PutBlock(root [32]byte, block *types.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := block.MarshalSSZ()
+	if err != nil {
+		s.log.Error("failed to encode block", "root", logging.ShortHash(root), "err", err)
+		return
+	}
+	if err := writeFileAtomic(s.objectPath(blocksDir, root), data); err != nil {
+		s.log.Error("failed to persist block", "root", logging.ShortHash(root), "err", err)
+		s.unpersisted[root] = struct{}{}
+		return
+	}
+	s.blocks[root] = block
+}
+
+func (s *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sb, ok := s.signedBlocks[root]
+	return sb, ok
+}
+
+func (s *Store) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := sb.MarshalSSZ()
+	if err != nil {
+		s.log.Error("failed to encode signed block", "root", logging.ShortHash(root), "err", err)
+		return
+	}
+	if err := writeFileAtomic(s.objectPath(signedBlocksDir, root), data); err != nil {
+		s.log.Error("failed to persist signed block", "root", logging.ShortHash(root), "err", err)
+		s.unpersisted[root] = struct{}{}
+		return
+	}
+	s.signedBlocks[root] = sb
+}
+
+func (s *Store) GetState(root [32]byte) (*types.State, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.states[root]
+	return st, ok
+}
+
+func (s *Store) PutState(root [32]byte, state *types.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := state.MarshalSSZ()
+	if err != nil {
+		s.log.Error("failed to encode state", "root", logging.ShortHash(root), "err", err)
+		return
+	}
+	if err := writeFileAtomic(s.objectPath(statesDir, root), data); err != nil {
+		s.log.Error("failed to persist state", "root", logging.ShortHash(root), "err", err)
+		s.unpersisted[root] = struct{}{}
+		return
+	}
+	s.states[root] = state
+}
+
+func (s *Store) DeleteBlock(root [32]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeObject(blocksDir, root)
+	delete(s.blocks, root)
+}
+
+func (s *Store) DeleteSignedBlock(root [32]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeObject(signedBlocksDir, root)
+	delete(s.signedBlocks, root)
+}
+
+func (s *Store) DeleteState(root [32]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeObject(statesDir, root)
+	delete(s.states, root)
+}
+
+func (s *Store) GetAllBlocks() map[[32]byte]*types.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := make(map[[32]byte]*types.Block, len(s.blocks))
+	for k, v := range s.blocks {
+		cp[k] = v
+	}
+	return cp
+}
+
+func (s *Store) GetAllStates() map[[32]byte]*types.State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := make(map[[32]byte]*types.State, len(s.states))
+	for k, v := range s.states {
+		cp[k] = v
+	}
+	return cp
+}
+
+func (s *Store) GetCheckpoints() (*storage.Checkpoints, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkpoints, s.checkpoints != nil
+}
+
+// PutCheckpoints persists the fork choice position. Callers write the head
+// block and state before the checkpoints that reference them, so after a
+// crash the checkpoints file never points at an object that is missing.
+// Checkpoints referencing a root whose write failed are refused and the
+// previous ones kept.
+func (s *Store) PutCheckpoints(cp *storage.Checkpoints) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, root := range [][32]byte{cp.Head, cp.Justified.Root, cp.Finalized.Root} {
+		if _, ok := s.unpersisted[root]; ok {
+			s.log.Error("not persisting checkpoints referencing an unpersisted object", "root", logging.ShortHash(root))
+			return
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, checkpointsFile), encodeCheckpoints(cp)); err != nil {
+		s.log.Error("failed to persist checkpoints", "err", err)
+	}
+	s.checkpoints = cp
+}
+
+func (s *Store) objectPath(sub string, root [32]byte) string {
+	return filepath.Join(s.dir, sub, hex.EncodeToString(root[:])+fileExt)
+}
+
+func (s *Store) removeObject(sub string, root [32]byte) {
+	if err := os.Remove(s.objectPath(sub, root)); err != nil && !os.IsNotExist(err) {
+		s.log.Error("failed to remove "+sub+" file", "root", logging.ShortHash(root), "err", err)
+	}
+}
+
+// loadDir calls fn for every object file in dir. Leftover temp files from an
+// interrupted write are removed.
+func loadDir(dir string, fn func(root [32]byte, data []byte) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		path := filepath.Join(dir, name)
+		if strings.HasSuffix(name, tmpExt) {
+			os.Remove(path)
+			continue
+		}
+		if e.IsDir() || !strings.HasSuffix(name, fileExt) {
+			continue
+		}
+		raw, err := hex.DecodeString(strings.TrimSuffix(name, fileExt))
+		if err != nil || len(raw) != 32 {
+			return fmt.Errorf("invalid object file name %q", name)
+		}
+		var root [32]byte
+		copy(root[:], raw)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(root, data); err != nil {
+			return fmt.Errorf("decode %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path via a synced temp file and rename, then
+// syncs the parent directory so the rename itself survives a crash.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + tmpExt
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func encodeCheckpoints(cp *storage.Checkpoints) []byte {
+	buf := make([]byte, checkpointsSize)
+	copy(buf[0:32], cp.Head[:])
+	copy(buf[32:64], cp.Justified.Root[:])
+	binary.LittleEndian.PutUint64(buf[64:72], cp.Justified.Slot)
+	copy(buf[72:104], cp.Finalized.Root[:])
+	binary.LittleEndian.PutUint64(buf[104:112], cp.Finalized.Slot)
+	return buf
+}
+
+func decodeCheckpoints(data []byte) (*storage.Checkpoints, error) {
+	if len(data) != checkpointsSize {
+		return nil, fmt.Errorf("invalid checkpoints length: %d", len(data))
+	}
+	cp := &storage.Checkpoints{
+		Justified: &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[64:72])},
+		Finalized: &types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[104:112])},
+	}
+	copy(cp.Head[:], data[0:32])
+	copy(cp.Justified.Root[:], data[32:64])
+	copy(cp.Finalized.Root[:], data[72:104])
+	return cp, nil
+}
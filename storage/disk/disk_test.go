@@ -0,0 +1,218 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+func makeBlock(slot uint64) *types.Block {
+	return &types.Block{
+		Slot:       slot,
+		ParentRoot: [32]byte{0xaa},
+		StateRoot:  [32]byte{0xbb},
+		Body:       &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+}
+
+func makeState(slot uint64) *types.State {
+	return &types.State{
+		Config:                   &types.Config{GenesisTime: 1000},
+		Slot:                     slot,
+		LatestBlockHeader:        &types.BlockHeader{Slot: slot},
+		LatestJustified:          &types.Checkpoint{},
+		LatestFinalized:          &types.Checkpoint{},
+		HistoricalBlockHashes:    [][32]byte{},
+		JustifiedSlots:           []byte{0x01},
+		Validators:               []*types.Validator{{Index: 0}},
+		JustificationsRoots:      [][32]byte{},
+		JustificationsValidators: []byte{0x01},
+	}
+}
+
+func TestPutGetBlock(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	root := [32]byte{1}
+	s.PutBlock(root, makeBlock(5))
+
+	got, ok := s.GetBlock(root)
+	if !ok {
+		t.Fatal("expected block to be found")
+	}
+	if got.Slot != 5 {
+		t.Fatalf("block slot = %d, want 5", got.Slot)
+	}
+}
+
+func TestReopenLoadsPersistedObjects(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	root := [32]byte{1}
+	s.PutBlock(root, makeBlock(7))
+	s.PutSignedBlock(root, &types.SignedBlockWithAttestation{
+		Message:   &types.BlockWithAttestation{Block: makeBlock(7)},
+		Signature: [][3116]byte{{0x01}},
+	})
+	s.PutState(root, makeState(7))
+	s.PutCheckpoints(&storage.Checkpoints{
+		Head:      root,
+		Justified: &types.Checkpoint{Root: [32]byte{2}, Slot: 4},
+		Finalized: &types.Checkpoint{Root: [32]byte{3}, Slot: 2},
+	})
+
+	reopened, err := New(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	b, ok := reopened.GetBlock(root)
+	if !ok || b.Slot != 7 {
+		t.Fatalf("block not restored: ok=%v", ok)
+	}
+	sb, ok := reopened.GetSignedBlock(root)
+	if !ok || sb.Message.Block.Slot != 7 || sb.Signature[0][0] != 0x01 {
+		t.Fatalf("signed block not restored: ok=%v", ok)
+	}
+	st, ok := reopened.GetState(root)
+	if !ok || st.Slot != 7 {
+		t.Fatalf("state not restored: ok=%v", ok)
+	}
+	cp, ok := reopened.GetCheckpoints()
+	if !ok {
+		t.Fatal("checkpoints not restored")
+	}
+	if cp.Head != root || cp.Justified.Slot != 4 || cp.Finalized.Root != [32]byte{3} {
+		t.Fatalf("checkpoints mismatch: %+v", cp)
+	}
+}
+
+func TestOpenEmptyDirHasNoCheckpoints(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := s.GetCheckpoints(); ok {
+		t.Fatal("expected no checkpoints in empty store")
+	}
+}
+
+func TestOpenRemovesInterruptedWrites(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(dir); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Simulate a crash between temp-file write and rename.
+	tmp := filepath.Join(dir, blocksDir, "deadbeef"+fileExt+tmpExt)
+	if err := os.WriteFile(tmp, []byte{0x01, 0x02}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(s.GetAllBlocks()) != 0 {
+		t.Fatal("partial write should not be loaded")
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatal("expected temp file to be removed on open")
+	}
+}
+
+func TestOpenRejectsCorruptObject(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	root := [32]byte{1}
+	s.PutBlock(root, makeBlock(1))
+
+	if err := os.WriteFile(s.objectPath(blocksDir, root), []byte{0x01}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(dir); err == nil {
+		t.Fatal("expected error for corrupt block file")
+	}
+}
+
+func TestDeleteSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	root := [32]byte{1}
+	s.PutBlock(root, makeBlock(1))
+	s.PutState(root, makeState(1))
+
+	s.DeleteBlock(root)
+	s.DeleteState(root)
+	// Deleting an object that was never written is a no-op.
+	s.DeleteSignedBlock(root)
+
+	reopened, err := New(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if _, ok := reopened.GetBlock(root); ok {
+		t.Fatal("deleted block should not be restored")
+	}
+	if _, ok := reopened.GetState(root); ok {
+		t.Fatal("deleted state should not be restored")
+	}
+}
+
+func TestFailedWriteIsNotCachedOrCheckpointed(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	old := [32]byte{1}
+	s.PutBlock(old, makeBlock(1))
+	s.PutState(old, makeState(1))
+	s.PutCheckpoints(&storage.Checkpoints{Head: old, Justified: &types.Checkpoint{}, Finalized: &types.Checkpoint{}})
+
+	// Replacing the states directory with a file makes state writes fail.
+	statesPath := filepath.Join(dir, statesDir)
+	if err := os.RemoveAll(statesPath); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if err := os.WriteFile(statesPath, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	root := [32]byte{2}
+	s.PutBlock(root, makeBlock(2))
+	s.PutState(root, makeState(2))
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("state that failed to persist should not be cached")
+	}
+	s.PutCheckpoints(&storage.Checkpoints{Head: root, Justified: &types.Checkpoint{}, Finalized: &types.Checkpoint{}})
+
+	if cp, _ := s.GetCheckpoints(); cp.Head != old {
+		t.Fatalf("head = %x, want %x", cp.Head, old)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, checkpointsFile))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	cp, err := decodeCheckpoints(data)
+	if err != nil {
+		t.Fatalf("decodeCheckpoints: %v", err)
+	}
+	if cp.Head != old {
+		t.Fatalf("persisted head = %x, want %x", cp.Head, old)
+	}
+}
@@ -3,6 +3,7 @@ package memory
 import (
 	"sync"
 
+	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -12,6 +13,7 @@ type Store struct {
 	blocks       map[[32]byte]*types.Block
 	signedBlocks map[[32]byte]*types.SignedBlockWithAttestation
 	states       map[[32]byte]*types.State
+	checkpoints  *storage.Checkpoints
 }
 
 // New creates a new in-memory store.
@@ -62,6 +64,24 @@ func (m *Store) PutState(root [32]byte, state *types.State) {
 	m.states[root] = state
 }
 
+func (m *Store) DeleteBlock(root [32]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blocks, root)
+}
+
+func (m *Store) DeleteSignedBlock(root [32]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.signedBlocks, root)
+}
+
+func (m *Store) DeleteState(root [32]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, root)
+}
+
 func (m *Store) GetAllBlocks() map[[32]byte]*types.Block {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -81,3 +101,15 @@ func (m *Store) GetAllStates() map[[32]byte]*types.State {
 	}
 	return cp
 }
+
+func (m *Store) GetCheckpoints() (*storage.Checkpoints, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkpoints, m.checkpoints != nil
+}
+
+func (m *Store) PutCheckpoints(cp *storage.Checkpoints) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints = cp
+}
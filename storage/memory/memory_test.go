@@ -84,3 +84,25 @@ func TestGetAllStatesCopiesMap(t *testing.T) {
 		t.Fatal("deleting from GetAllStates result should not affect store")
 	}
 }
+
+func TestDeleteRemovesObjects(t *testing.T) {
+	s := New()
+	root := [32]byte{1}
+	s.PutBlock(root, &types.Block{Slot: 1})
+	s.PutSignedBlock(root, &types.SignedBlockWithAttestation{})
+	s.PutState(root, &types.State{Slot: 1})
+
+	s.DeleteBlock(root)
+	s.DeleteSignedBlock(root)
+	s.DeleteState(root)
+
+	if _, ok := s.GetBlock(root); ok {
+		t.Fatal("expected block to be deleted")
+	}
+	if _, ok := s.GetSignedBlock(root); ok {
+		t.Fatal("expected signed block to be deleted")
+	}
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("expected state to be deleted")
+	}
+}
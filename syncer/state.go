@@ -0,0 +1,106 @@
+package syncer
+
+import (
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// State is the node's sync state.
+type State int
+
+// Sync states.
+const (
+	// StatePreGenesis: the chain has not started yet.
+	StatePreGenesis State = iota
+	// StateSyncing: peers report a head more than SyncTolerance slots ahead
+	// of ours. Validator duties are suspended.
+	StateSyncing
+	// StateSynced: our head is within SyncTolerance slots of our peers.
+	StateSynced
+	// StateStalled: no peer is ahead of us, yet our head is more than
+	// StallSlots behind the wall clock, e.g. without peers or while the
+	// network is not producing blocks.
+	StateStalled
+)
+
+// Sync state thresholds, in slots.
+const (
+	SyncTolerance = 2
+	StallSlots    = 8
+)
+
+var stateNames = [...]string{"pre_genesis", "syncing", "synced", "stalled"}
+
+func (s State) String() string {
+	if int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return "unknown"
+}
+
+// Status is a snapshot of the sync state.
+type Status struct {
+	State        State
+	HeadSlot     uint64
+	CurrentSlot  uint64
+	TargetSlot   uint64
+	SyncDistance uint64
+}
+
+// UpdateState recomputes the sync state for the current wall-clock slot,
+// logging and exporting any transition.
+func (s *Syncer) UpdateState(currentSlot uint64, beforeGenesis bool) Status {
+	head := s.chain.HeadSlot()
+	target := s.targetSlot(currentSlot)
+	status := Status{HeadSlot: head, CurrentSlot: currentSlot, TargetSlot: target}
+	if target > head {
+		status.SyncDistance = target - head
+	}
+
+	switch {
+	case beforeGenesis:
+		status.State = StatePreGenesis
+	case status.SyncDistance > SyncTolerance:
+		status.State = StateSyncing
+	case currentSlot > head+StallSlots:
+		status.State = StateStalled
+	default:
+		status.State = StateSynced
+	}
+
+	s.mu.Lock()
+	prev, prevSet := s.status, s.stateSet
+	changed := !prevSet || prev.State != status.State
+	s.status, s.stateSet = status, true
+	s.mu.Unlock()
+
+	metrics.SyncDistance.Set(float64(status.SyncDistance))
+	if changed {
+		for i, name := range stateNames {
+			v := 0.0
+			if State(i) == status.State {
+				v = 1
+			}
+			metrics.SyncState.WithLabelValues(name).Set(v)
+		}
+		attrs := []any{
+			"state", status.State.String(),
+			"head_slot", head,
+			"current_slot", currentSlot,
+			"target_slot", target,
+			"distance", status.SyncDistance,
+		}
+		if prevSet {
+			s.log.Info("sync state changed", append(attrs, "from", prev.State.String())...)
+		} else {
+			s.log.Info("sync state", attrs...)
+		}
+	}
+	return status
+}
+
+// Status returns the sync state computed by the last UpdateState.
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
@@ -0,0 +1,80 @@
+package syncer
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestUpdateState(t *testing.T) {
+	canonical := testChain(20, 1)
+	chain := newFakeChain(canonical[:11])
+	s := New(chain, (&fakeNetwork{}).fetch)
+
+	if got := s.UpdateState(0, true).State; got != StatePreGenesis {
+		t.Fatalf("state = %s, want %s", got, StatePreGenesis)
+	}
+	// No peers and the head is current.
+	if got := s.UpdateState(12, false).State; got != StateSynced {
+		t.Fatalf("state = %s, want %s", got, StateSynced)
+	}
+	// No peers ahead of us, but the head lags the clock.
+	if got := s.UpdateState(10+StallSlots+1, false).State; got != StateStalled {
+		t.Fatalf("state = %s, want %s", got, StateStalled)
+	}
+
+	s.UpdatePeer("peer", headStatus(canonical))
+	status := s.UpdateState(20, false)
+	if status.State != StateSyncing {
+		t.Fatalf("state = %s, want %s", status.State, StateSyncing)
+	}
+	if status.SyncDistance != 10 {
+		t.Fatalf("sync distance = %d, want 10", status.SyncDistance)
+	}
+	if s.Status() != status {
+		t.Fatalf("Status() = %+v, want %+v", s.Status(), status)
+	}
+
+	// Within tolerance of the best peer counts as synced.
+	for _, sb := range canonical[11 : 21-SyncTolerance] {
+		if err := chain.ProcessBlock(sb); err != nil {
+			t.Fatalf("process block: %v", err)
+		}
+	}
+	if got := s.UpdateState(20, false).State; got != StateSynced {
+		t.Fatalf("state = %s, want %s", got, StateSynced)
+	}
+}
+
+func TestUpdateStateIgnoresFutureHeads(t *testing.T) {
+	canonical := testChain(20, 1)
+	chain := newFakeChain(canonical)
+	s := New(chain, (&fakeNetwork{}).fetch)
+
+	// A head beyond the wall clock cannot exist yet.
+	s.UpdatePeer("liar", reqresp.Status{
+		Finalized: &types.Checkpoint{},
+		Head:      &types.Checkpoint{Slot: 1000},
+	})
+	if got := s.UpdateState(20, false); got.State != StateSynced || got.TargetSlot != 0 {
+		t.Fatalf("status = %+v, want synced with no target", got)
+	}
+
+	// With several peers, one cannot set the target alone.
+	s.UpdatePeer("ahead", reqresp.Status{
+		Finalized: &types.Checkpoint{},
+		Head:      &types.Checkpoint{Slot: 25},
+	})
+	s.UpdatePeer("peer", headStatus(canonical))
+	if got := s.UpdateState(25, false); got.State != StateSynced || got.TargetSlot != 20 {
+		t.Fatalf("status = %+v, want synced to slot 20", got)
+	}
+	s.UpdatePeer("peer", reqresp.Status{
+		Finalized: &types.Checkpoint{},
+		Head:      &types.Checkpoint{Slot: 25},
+	})
+	if got := s.UpdateState(25, false); got.State != StateSyncing || got.TargetSlot != 25 {
+		t.Fatalf("status = %+v, want syncing to slot 25", got)
+	}
+}
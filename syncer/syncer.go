@@ -0,0 +1,437 @@
+// Package syncer catches a node up with its peers by downloading the missing
+// slot range in batches over BlocksByRange, from several peers in parallel,
+// and importing the batches in slot order.
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// Sync defaults.
+const (
+	DefaultBatchSize   = 64
+	DefaultMaxParallel = 8
+	// maxBatchAttempts is how many peers a batch is tried against before the
+	// sync round is abandoned.
+	maxBatchAttempts = 5
+)
+
+var errNoPeers = errors.New("no peer can serve the batch")
+
+// Chain is the part of the node the syncer reads progress from and imports
+// blocks into.
+type Chain interface {
+	// CurrentSlot is the wall-clock slot, which bounds the peer heads
+	// worth syncing to.
+	CurrentSlot() uint64
+	HeadSlot() uint64
+	FinalizedSlot() uint64
+	ProcessBlock(*types.SignedBlockWithAttestation) error
+}
+
+// BlockFetcher downloads a range of blocks from a peer.
+type BlockFetcher func(ctx context.Context, pid peer.ID, req reqresp.BlocksByRangeRequest) ([]*types.SignedBlockWithAttestation, error)
+
+// Progress is a snapshot of the syncer's state.
+type Progress struct {
+	Syncing    bool
+	StartSlot  uint64
+	HeadSlot   uint64
+	TargetSlot uint64
+	Peers      int
+}
+
+// Syncer tracks peer statuses and drives range sync towards the best head
+// they report.
+type Syncer struct {
+	// BatchSize is the number of slots requested per batch.
+	BatchSize uint64
+	// MaxParallel bounds concurrent batch downloads. At most twice as many
+	// batches are buffered ahead of the import.
+	MaxParallel int
+	// ReportPeer, if set, is told about peers that failed a download or
+	// served blocks that did not import.
+	ReportPeer func(pid peer.ID, action network.PeerAction, reason string)
+
+	chain Chain
+	fetch BlockFetcher
+
+	mu       sync.Mutex
+	peers    map[peer.ID]reqresp.Status
+	progress Progress
+	status   Status
+	// stateSet is false until the first UpdateState.
+	stateSet bool
+
+	trigger chan struct{}
+	log     *slog.Logger
+}
+
+// New creates a syncer importing into chain and downloading through fetch.
+func New(chain Chain, fetch BlockFetcher) *Syncer {
+	return &Syncer{
+		BatchSize:   DefaultBatchSize,
+		MaxParallel: DefaultMaxParallel,
+		chain:       chain,
+		fetch:       fetch,
+		peers:       make(map[peer.ID]reqresp.Status),
+		trigger:     make(chan struct{}, 1),
+		log:         logging.NewComponentLogger(logging.CompSync),
+	}
+}
+
+// UpdatePeer records the latest status of a peer and wakes the sync loop if
+// the peer is ahead of us.
+func (s *Syncer) UpdatePeer(pid peer.ID, status reqresp.Status) {
+	s.mu.Lock()
+	s.peers[pid] = status
+	s.progress.Peers = len(s.peers)
+	s.mu.Unlock()
+	if status.Head.Slot > s.chain.HeadSlot() {
+		s.Trigger()
+	}
+}
+
+// RemovePeer forgets a peer.
+func (s *Syncer) RemovePeer(pid peer.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.peers, pid)
+	s.progress.Peers = len(s.peers)
+}
+
+// PeerStatus returns the last status recorded for pid.
+func (s *Syncer) PeerStatus(pid peer.ID) (reqresp.Status, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.peers[pid]
+	return status, ok
+}
+
+// PeerStatuses returns the last status recorded for every peer.
+func (s *Syncer) PeerStatuses() map[peer.ID]reqresp.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.peers)
+}
+
+// Progress returns a snapshot of the current sync progress.
+func (s *Syncer) Progress() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.progress
+	p.HeadSlot = s.chain.HeadSlot()
+	return p
+}
+
+// TargetSlot returns the head slot to sync to for the current slot.
+func (s *Syncer) TargetSlot() uint64 {
+	return s.targetSlot(s.chain.CurrentSlot())
+}
+
+// targetSlot returns the highest head slot reported by at least two peers,
+// or by the only peer. Heads beyond currentSlot, allowing for clock
+// disparity, cannot exist yet and are ignored, so a single faulty peer
+// cannot hold the node in StateSyncing.
+func (s *Syncer) targetSlot(currentSlot uint64) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	heads := make([]uint64, 0, len(s.peers))
+	for _, status := range s.peers {
+		if status.Head.Slot <= currentSlot+gossipsub.MaxClockDisparitySlots {
+			heads = append(heads, status.Head.Slot)
+		}
+	}
+	if len(heads) == 0 {
+		return 0
+	}
+	slices.Sort(heads)
+	if len(heads) == 1 {
+		return heads[0]
+	}
+	return heads[len(heads)-2]
+}
+
+// Trigger asks the sync loop to run a sync round.
+func (s *Syncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run performs a sync round whenever triggered until ctx is done.
+func (s *Syncer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.trigger:
+			if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+				s.log.Warn("sync round failed", "head_slot", s.chain.HeadSlot(), "err", err)
+			}
+		}
+	}
+}
+
+// Sync downloads and imports blocks until the head reaches the best peer
+// head or no further progress can be made.
+func (s *Syncer) Sync(ctx context.Context) error {
+	defer s.setSyncing(false, 0, 0)
+	for {
+		head := s.chain.HeadSlot()
+		target := s.TargetSlot()
+		if target <= head {
+			return nil
+		}
+		start := head + 1
+		s.setSyncing(true, start, target)
+		s.log.Info("sync started", "head_slot", head, "target_slot", target, "peers", s.Progress().Peers)
+
+		err := s.syncRange(ctx, start, target)
+		if errors.Is(err, forkchoice.ErrUnknownParent) {
+			// Peers are on a fork that branches below our head. Restart from
+			// the finalized checkpoint, which every compatible peer shares.
+			start = s.chain.FinalizedSlot() + 1
+			s.log.Info("sync restarting from finalized checkpoint", "start_slot", start, "target_slot", target)
+			err = s.syncRange(ctx, start, target)
+		}
+		if err != nil {
+			return err
+		}
+		if s.chain.HeadSlot() <= head {
+			return nil
+		}
+		s.log.Info("sync round complete", "head_slot", s.chain.HeadSlot(), "target_slot", target)
+	}
+}
+
+func (s *Syncer) setSyncing(syncing bool, start, target uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.Syncing = syncing
+	s.progress.StartSlot = start
+	s.progress.TargetSlot = target
+}
+
+// batch is one BlocksByRange request and its download state.
+type batch struct {
+	req      reqresp.BlocksByRangeRequest
+	blocks   []*types.SignedBlockWithAttestation
+	from     peer.ID
+	ready    bool
+	inFlight bool
+	tried    map[peer.ID]bool
+	// unknownParent is set when the first batch of a range did not connect
+	// to our chain.
+	unknownParent bool
+}
+
+type batchResult struct {
+	b      *batch
+	pid    peer.ID
+	blocks []*types.SignedBlockWithAttestation
+	err    error
+}
+
+// syncRange downloads [start, target] in batches and imports them in order.
+func (s *Syncer) syncRange(ctx context.Context, start, target uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batchSize := max(1, min(s.BatchSize, types.MaxRequestBlocks))
+	parallel := max(1, s.MaxParallel)
+	results := make(chan batchResult, parallel)
+	busy := make(map[peer.ID]bool)
+	inFlight := 0
+	next := start
+	var window []*batch // batches in slot order, oldest first
+
+	for {
+		// Fill the download window with new batches.
+		for next <= target && len(window) < 2*parallel {
+			count := min(batchSize, target-next+1)
+			window = append(window, &batch{
+				req:   reqresp.BlocksByRangeRequest{StartSlot: next, Count: count, Step: 1},
+				tried: make(map[peer.ID]bool),
+			})
+			next += count
+		}
+
+		// Assign idle peers to batches still waiting for a download.
+		for _, b := range window {
+			if inFlight >= parallel {
+				break
+			}
+			if b.ready || b.inFlight {
+				continue
+			}
+			pid, ok := s.pickPeer(b, busy)
+			if !ok {
+				if !s.hasCandidate(b) {
+					return batchExhausted(b)
+				}
+				continue
+			}
+			b.inFlight = true
+			busy[pid] = true
+			inFlight++
+			go func(b *batch, pid peer.ID) {
+				blocks, err := s.fetch(ctx, pid, b.req)
+				results <- batchResult{b: b, pid: pid, blocks: blocks, err: err}
+			}(b, pid)
+		}
+
+		if len(window) == 0 {
+			return nil
+		}
+		if inFlight == 0 && !window[0].ready {
+			return batchExhausted(window[0])
+		}
+
+		// Wait for a download to finish.
+		if inFlight > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case r := <-results:
+				inFlight--
+				delete(busy, r.pid)
+				r.b.inFlight = false
+				if r.err != nil {
+					s.log.Debug("batch download failed",
+						"start_slot", r.b.req.StartSlot,
+						"count", r.b.req.Count,
+						"peer", r.pid.String(),
+						"err", r.err,
+					)
+					// A peer rate limiting us is behaving correctly.
+					if !errors.Is(r.err, reqresp.ErrResourceUnavailable) {
+						s.report(r.pid, network.PeerActionMidToleranceError, "batch download failed")
+					}
+					if err := s.retryBatch(r.b, r.pid); err != nil {
+						return err
+					}
+				} else {
+					r.b.blocks, r.b.from, r.b.ready = r.blocks, r.pid, true
+				}
+			}
+		}
+
+		// Import completed batches from the front of the window.
+		for len(window) > 0 && window[0].ready {
+			b := window[0]
+			if err := s.importBatch(b); err != nil {
+				switch {
+				case errors.Is(err, forkchoice.ErrUnknownParent):
+					// The peer may just follow another fork.
+					if b.req.StartSlot == start {
+						b.unknownParent = true
+					}
+					s.report(b.from, network.PeerActionHighToleranceError, "batch does not connect")
+				default:
+					s.report(b.from, network.PeerActionLowToleranceError, "invalid batch")
+				}
+				s.log.Debug("batch import failed",
+					"start_slot", b.req.StartSlot,
+					"peer", b.from.String(),
+					"err", err,
+				)
+				if err := s.retryBatch(b, b.from); err != nil {
+					return err
+				}
+				break
+			}
+			window = window[1:]
+			s.log.Info("sync progress",
+				"head_slot", s.chain.HeadSlot(),
+				"target_slot", target,
+				"imported_to", b.req.StartSlot+b.req.Count-1,
+			)
+		}
+	}
+}
+
+func (s *Syncer) report(pid peer.ID, action network.PeerAction, reason string) {
+	if s.ReportPeer != nil {
+		s.ReportPeer(pid, action, reason)
+	}
+}
+
+// retryBatch marks pid as having failed b and queues b for another peer.
+func (s *Syncer) retryBatch(b *batch, pid peer.ID) error {
+	b.tried[pid] = true
+	b.ready = false
+	b.blocks = nil
+	if len(b.tried) >= maxBatchAttempts {
+		return batchExhausted(b)
+	}
+	return nil
+}
+
+// batchExhausted is the error for a batch no remaining peer can serve. If the
+// first batch did not connect to our chain, the error wraps
+// forkchoice.ErrUnknownParent so the caller can sync from further back.
+func batchExhausted(b *batch) error {
+	if b.unknownParent {
+		return fmt.Errorf("batch at slot %d after %d peers: %w", b.req.StartSlot, len(b.tried), forkchoice.ErrUnknownParent)
+	}
+	return fmt.Errorf("%w at slot %d after %d peers", errNoPeers, b.req.StartSlot, len(b.tried))
+}
+
+// importBatch processes the blocks of b in order. Blocks already known are
+// skipped by ProcessBlock.
+func (s *Syncer) importBatch(b *batch) error {
+	for _, sb := range b.blocks {
+		if err := s.chain.ProcessBlock(sb); err != nil {
+			return fmt.Errorf("slot %d: %w", sb.Message.Block.Slot, err)
+		}
+	}
+	return nil
+}
+
+// pickPeer returns an idle peer that has not failed b and whose head covers
+// the start of b, preferring the highest head.
+func (s *Syncer) pickPeer(b *batch, busy map[peer.ID]bool) (peer.ID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best peer.ID
+	var bestSlot uint64
+	found := false
+	for pid, status := range s.peers {
+		if busy[pid] || b.tried[pid] || status.Head.Slot < b.req.StartSlot {
+			continue
+		}
+		if !found || status.Head.Slot > bestSlot {
+			best, bestSlot, found = pid, status.Head.Slot, true
+		}
+	}
+	return best, found
+}
+
+// hasCandidate reports whether any known peer could still serve b.
+func (s *Syncer) hasCandidate(b *batch) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for pid, status := range s.peers {
+		if !b.tried[pid] && status.Head.Slot >= b.req.StartSlot {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/types"
+)
+
+// testChain is a linear chain of blocks, one per slot. Chains with
+// different tags share no blocks.
+func testChain(length uint64, tag uint64) []*types.SignedBlockWithAttestation {
+	genesis := &types.Block{
+		ProposerIndex: tag,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	base := []*types.SignedBlockWithAttestation{{Message: &types.BlockWithAttestation{Block: genesis}}}
+	return extendChain(base, length, tag)
+}
+
+// extendChain appends blocks up to slot length on top of base, tagged so
+// that they differ from other branches.
+func extendChain(base []*types.SignedBlockWithAttestation, length uint64, tag uint64) []*types.SignedBlockWithAttestation {
+	blocks := append([]*types.SignedBlockWithAttestation(nil), base...)
+	parent, _ := base[len(base)-1].Message.Block.HashTreeRoot()
+	for slot := uint64(len(base)); slot <= length; slot++ {
+		block := &types.Block{
+			Slot:          slot,
+			ProposerIndex: tag,
+			ParentRoot:    parent,
+			Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+		}
+		parent, _ = block.HashTreeRoot()
+		blocks = append(blocks, &types.SignedBlockWithAttestation{Message: &types.BlockWithAttestation{Block: block}})
+	}
+	return blocks
+}
+
+// fakeChain accepts blocks whose parent it already has. Its clock is far
+// ahead of any test chain unless a test sets it.
+type fakeChain struct {
+	mu        sync.Mutex
+	known     map[[32]byte]uint64
+	head      uint64
+	finalized uint64
+	clock     uint64
+}
+
+func newFakeChain(blocks []*types.SignedBlockWithAttestation) *fakeChain {
+	c := &fakeChain{known: make(map[[32]byte]uint64), clock: 1 << 32}
+	for _, sb := range blocks {
+		root, _ := sb.Message.Block.HashTreeRoot()
+		c.known[root] = sb.Message.Block.Slot
+		c.head = max(c.head, sb.Message.Block.Slot)
+	}
+	return c
+}
+
+func (c *fakeChain) CurrentSlot() uint64 { return c.clock }
+
+func (c *fakeChain) HeadSlot() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head
+}
+
+func (c *fakeChain) FinalizedSlot() uint64 { return c.finalized }
+
+func (c *fakeChain) ProcessBlock(sb *types.SignedBlockWithAttestation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block := sb.Message.Block
+	root, _ := block.HashTreeRoot()
+	if _, ok := c.known[root]; ok {
+		return nil
+	}
+	if _, ok := c.known[block.ParentRoot]; !ok {
+		return fmt.Errorf("%w for %x", forkchoice.ErrUnknownParent, block.ParentRoot)
+	}
+	c.known[root] = block.Slot
+	c.head = max(c.head, block.Slot)
+	return nil
+}
+
+// fakeNetwork serves each peer's chain and records which peers served.
+type fakeNetwork struct {
+	chains   map[peer.ID][]*types.SignedBlockWithAttestation
+	failing  map[peer.ID]bool
+	served   sync.Map // peer.ID -> *atomic.Int64
+	delay    time.Duration
+	inFlight atomic.Int64
+	maxSeen  atomic.Int64
+}
+
+func (f *fakeNetwork) fetch(ctx context.Context, pid peer.ID, req reqresp.BlocksByRangeRequest) ([]*types.SignedBlockWithAttestation, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		seen := f.maxSeen.Load()
+		if n <= seen || f.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+	if f.failing[pid] {
+		return nil, errors.New("stream reset")
+	}
+	counter, _ := f.served.LoadOrStore(pid, new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+
+	chain := f.chains[pid]
+	var out []*types.SignedBlockWithAttestation
+	for _, slot := range req.Slots() {
+		if slot < uint64(len(chain)) {
+			out = append(out, chain[slot])
+		}
+	}
+	return out, nil
+}
+
+func headStatus(blocks []*types.SignedBlockWithAttestation) reqresp.Status {
+	last := blocks[len(blocks)-1].Message.Block
+	root, _ := last.HashTreeRoot()
+	return reqresp.Status{
+		Finalized: &types.Checkpoint{},
+		Head:      &types.Checkpoint{Root: root, Slot: last.Slot},
+	}
+}
+
+func TestSyncCatchesUpFromManyPeers(t *testing.T) {
+	canonical := testChain(10_000, 1)
+	chain := newFakeChain(canonical[:1])
+	net := &fakeNetwork{
+		chains: map[peer.ID][]*types.SignedBlockWithAttestation{
+			"peer-a": canonical,
+			"peer-b": canonical,
+			"peer-c": canonical,
+		},
+		delay: time.Millisecond,
+	}
+
+	s := New(chain, net.fetch)
+	for pid := range net.chains {
+		s.UpdatePeer(pid, headStatus(canonical))
+	}
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if chain.HeadSlot() != 10_000 {
+		t.Fatalf("head slot = %d, want 10000", chain.HeadSlot())
+	}
+	for pid := range net.chains {
+		if _, ok := net.served.Load(pid); !ok {
+			t.Fatalf("peer %s served no batches", pid)
+		}
+	}
+	if net.maxSeen.Load() < 2 {
+		t.Fatalf("max concurrent downloads = %d, want parallel downloads", net.maxSeen.Load())
+	}
+	if s.Progress().Syncing {
+		t.Fatal("progress should not report syncing after completion")
+	}
+}
+
+func TestSyncRetriesFailedBatchWithOtherPeer(t *testing.T) {
+	canonical := testChain(300, 1)
+	chain := newFakeChain(canonical[:1])
+	net := &fakeNetwork{
+		chains: map[peer.ID][]*types.SignedBlockWithAttestation{
+			"bad":  canonical,
+			"good": canonical,
+		},
+		failing: map[peer.ID]bool{"bad": true},
+	}
+
+	s := New(chain, net.fetch)
+	s.UpdatePeer("bad", headStatus(canonical))
+	s.UpdatePeer("good", headStatus(canonical))
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if chain.HeadSlot() != 300 {
+		t.Fatalf("head slot = %d, want 300", chain.HeadSlot())
+	}
+}
+
+func TestSyncRetriesBatchThatFailsImport(t *testing.T) {
+	canonical := testChain(200, 1)
+	// The liar serves blocks from an unrelated chain for the same slots.
+	fork := testChain(200, 2)
+	chain := newFakeChain(canonical[:1])
+	net := &fakeNetwork{chains: map[peer.ID][]*types.SignedBlockWithAttestation{
+		"liar":   fork,
+		"honest": canonical,
+	}}
+
+	s := New(chain, net.fetch)
+	s.UpdatePeer("liar", headStatus(canonical))
+	s.UpdatePeer("honest", headStatus(canonical))
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if chain.HeadSlot() != 200 {
+		t.Fatalf("head slot = %d, want 200", chain.HeadSlot())
+	}
+}
+
+func TestSyncRestartsFromFinalizedOnFork(t *testing.T) {
+	canonical := testChain(150, 1)
+	// We followed a fork that branched off after genesis.
+	chain := newFakeChain(extendChain(canonical[:1], 20, 2))
+	net := &fakeNetwork{chains: map[peer.ID][]*types.SignedBlockWithAttestation{"peer": canonical}}
+
+	s := New(chain, net.fetch)
+	s.UpdatePeer("peer", headStatus(canonical))
+	if err := s.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if chain.HeadSlot() != 150 {
+		t.Fatalf("head slot = %d, want 150", chain.HeadSlot())
+	}
+}
+
+func TestSyncFailsWhenNoPeerCanServe(t *testing.T) {
+	canonical := testChain(100, 1)
+	chain := newFakeChain(canonical[:1])
+	net := &fakeNetwork{
+		chains:  map[peer.ID][]*types.SignedBlockWithAttestation{"down": canonical},
+		failing: map[peer.ID]bool{"down": true},
+	}
+
+	s := New(chain, net.fetch)
+	s.UpdatePeer("down", headStatus(canonical))
+	err := s.Sync(context.Background())
+	if !errors.Is(err, errNoPeers) {
+		t.Fatalf("err = %v, want errNoPeers", err)
+	}
+	if chain.HeadSlot() != 0 {
+		t.Fatalf("head slot = %d, want 0", chain.HeadSlot())
+	}
+}
@@ -0,0 +1,294 @@
+package unit
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geanlabs/gean/api"
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/syncer"
+	"github.com/geanlabs/gean/types"
+)
+
+func newTestAPI(t *testing.T) (*httptest.Server, map[uint64][32]byte) {
+	t.Helper()
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	// One vote for the tip moves the head off genesis.
+	fc.LatestNewAttestations[0] = makeFCAttestation(0, 3,
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[0], Slot: 0},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+	)
+	fc.AcceptNewAttestations()
+	return newAPIServer(t, fc), hashes
+}
+
+func newAPIServer(t *testing.T, fc *forkchoice.Store) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(api.New(fc, fc.Storage).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getJSON(t *testing.T, url string, out any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func hexRoot(root [32]byte) string {
+	return "0x" + hex.EncodeToString(root[:])
+}
+
+func TestAPIBlockBySlotAndRoot(t *testing.T) {
+	srv, hashes := newTestAPI(t)
+
+	var bySlot struct {
+		Data struct {
+			Root  string `json:"root"`
+			Block struct {
+				Slot string `json:"slot"`
+			} `json:"block"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/blocks/2", &bySlot); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if bySlot.Data.Root != hexRoot(hashes[2]) {
+		t.Fatalf("root = %s, want %s", bySlot.Data.Root, hexRoot(hashes[2]))
+	}
+	if bySlot.Data.Block.Slot != "2" {
+		t.Fatalf("slot = %s, want 2", bySlot.Data.Block.Slot)
+	}
+
+	if code := getJSON(t, srv.URL+"/lean/v0/blocks/"+hexRoot(hashes[1]), nil); code != http.StatusOK {
+		t.Fatalf("by root status = %d, want 200", code)
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/blocks/"+hexRoot([32]byte{0xaa}), nil); code != http.StatusNotFound {
+		t.Fatalf("unknown root status = %d, want 404", code)
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/blocks/not-a-slot", nil); code != http.StatusBadRequest {
+		t.Fatalf("bad id status = %d, want 400", code)
+	}
+}
+
+func TestAPIHeadAndCheckpoints(t *testing.T) {
+	srv, hashes := newTestAPI(t)
+
+	var cps struct {
+		Data map[string]struct {
+			Root string `json:"root"`
+			Slot string `json:"slot"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/checkpoints", &cps); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if cps.Data["head"].Root != hexRoot(hashes[3]) || cps.Data["head"].Slot != "3" {
+		t.Fatalf("head = %+v, want slot 3 root %s", cps.Data["head"], hexRoot(hashes[3]))
+	}
+	for _, key := range []string{"justified", "finalized"} {
+		if _, ok := cps.Data[key]; !ok {
+			t.Fatalf("missing %s checkpoint", key)
+		}
+	}
+
+	if code := getJSON(t, srv.URL+"/lean/v0/headers/head", nil); code != http.StatusOK {
+		t.Fatalf("head header status = %d, want 200", code)
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/blocks/finalized", nil); code != http.StatusOK {
+		t.Fatalf("finalized block status = %d, want 200", code)
+	}
+}
+
+func TestAPIStateSSZ(t *testing.T) {
+	srv, hashes := newTestAPI(t)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/lean/v0/states/"+hexRoot(hashes[2]), nil)
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET state: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	var state types.State
+	if err := state.UnmarshalSSZ(data); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+	if state.Slot != 2 {
+		t.Fatalf("state slot = %d, want 2", state.Slot)
+	}
+}
+
+func TestAPIForkChoice(t *testing.T) {
+	srv, _ := newTestAPI(t)
+
+	var fcResp struct {
+		Data struct {
+			Nodes []struct {
+				Slot string `json:"slot"`
+			} `json:"nodes"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/fork_choice", &fcResp); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(fcResp.Data.Nodes) != 4 {
+		t.Fatalf("nodes = %d, want 4", len(fcResp.Data.Nodes))
+	}
+}
+
+// fcChain adapts a fork choice store to syncer.Chain.
+type fcChain struct{ fc *forkchoice.Store }
+
+func (c fcChain) CurrentSlot() uint64 { return c.fc.Time / types.IntervalsPerSlot }
+
+func (c fcChain) HeadSlot() uint64 { return c.fc.HeadSlot() }
+
+func (c fcChain) FinalizedSlot() uint64 {
+	_, _, finalized := c.fc.Checkpoints()
+	return finalized.Slot
+}
+
+func (c fcChain) ProcessBlock(sb *types.SignedBlockWithAttestation) error {
+	return c.fc.ProcessBlock(sb)
+}
+
+func TestAPIHealthReflectsSyncState(t *testing.T) {
+	fc, _ := buildForkChoiceWithBlocks(t, 5, 3)
+	sync := syncer.New(fcChain{fc}, nil)
+	server := api.New(fc, fc.Storage)
+	server.Sync = sync
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	sync.UpdateState(0, true)
+	if code := getJSON(t, srv.URL+"/lean/v0/health", nil); code != http.StatusServiceUnavailable {
+		t.Fatalf("pre-genesis health = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	sync.UpdatePeer("peer", reqresp.Status{
+		Finalized: &types.Checkpoint{},
+		Head:      &types.Checkpoint{Slot: 40},
+	})
+	sync.UpdateState(40, false)
+	if code := getJSON(t, srv.URL+"/lean/v0/health", nil); code != http.StatusPartialContent {
+		t.Fatalf("syncing health = %d, want %d", code, http.StatusPartialContent)
+	}
+	var resp struct {
+		Data struct {
+			State        string `json:"state"`
+			IsSyncing    bool   `json:"is_syncing"`
+			TargetSlot   string `json:"target_slot"`
+			SyncDistance string `json:"sync_distance"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/node/syncing", &resp); code != http.StatusOK {
+		t.Fatalf("syncing status = %d", code)
+	}
+	if resp.Data.State != "syncing" || !resp.Data.IsSyncing || resp.Data.TargetSlot != "40" || resp.Data.SyncDistance != "40" {
+		t.Fatalf("syncing = %+v, want syncing to slot 40 at distance 40", resp.Data)
+	}
+
+	sync.RemovePeer("peer")
+	sync.UpdateState(1, false)
+	if code := getJSON(t, srv.URL+"/lean/v0/health", nil); code != http.StatusOK {
+		t.Fatalf("synced health = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestAPIPeers(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	sync := syncer.New(fcChain{fc}, nil)
+	server := api.New(fc, fc.Storage)
+	server.Sync = sync
+	srv := httptest.NewServer(server.Handler())
+	t.Cleanup(srv.Close)
+
+	var resp struct {
+		Data []struct {
+			PeerID string `json:"peer_id"`
+			Head   struct {
+				Root string `json:"root"`
+				Slot string `json:"slot"`
+			} `json:"head"`
+			Finalized struct {
+				Slot string `json:"slot"`
+			} `json:"finalized"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/node/peers", &resp); code != http.StatusOK {
+		t.Fatalf("peers status = %d", code)
+	}
+	if len(resp.Data) != 0 {
+		t.Fatalf("peers = %d, want 0", len(resp.Data))
+	}
+
+	sync.UpdatePeer("b", reqresp.Status{Finalized: &types.Checkpoint{}, Head: &types.Checkpoint{Root: hashes[2], Slot: 2}})
+	sync.UpdatePeer("a", reqresp.Status{Finalized: &types.Checkpoint{Slot: 1}, Head: &types.Checkpoint{Root: hashes[3], Slot: 3}})
+	if code := getJSON(t, srv.URL+"/lean/v0/node/peers", &resp); code != http.StatusOK {
+		t.Fatalf("peers status = %d", code)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("peers = %d, want 2", len(resp.Data))
+	}
+	first := resp.Data[0]
+	if first.Head.Root != hexRoot(hashes[3]) || first.Head.Slot != "3" || first.Finalized.Slot != "1" {
+		t.Fatalf("first peer = %+v, want head 3 finalized 1", first)
+	}
+}
+
+func TestAPIEquivocations(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = acceptingVerifier{}
+	fc.Time = 10 * types.IntervalsPerSlot
+	for _, head := range []uint64{3, 2} {
+		fc.ProcessAttestation(makeFCAttestation(1, 3,
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+			&types.Checkpoint{Root: hashes[1], Slot: 1},
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+		))
+	}
+	srv := newAPIServer(t, fc)
+
+	var resp struct {
+		Data struct {
+			Attester []struct {
+				ValidatorID string `json:"validator_id"`
+				Slot        string `json:"slot"`
+			} `json:"attester"`
+			Proposer []json.RawMessage `json:"proposer"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/equivocations", &resp); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(resp.Data.Attester) != 1 || len(resp.Data.Proposer) != 0 {
+		t.Fatalf("evidence = %d attester, %d proposer, want 1, 0", len(resp.Data.Attester), len(resp.Data.Proposer))
+	}
+	if e := resp.Data.Attester[0]; e.ValidatorID != "1" || e.Slot != "3" {
+		t.Fatalf("evidence = validator %s slot %s, want 1 3", e.ValidatorID, e.Slot)
+	}
+}
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+// writeAnchor writes the block and state at root to SSZ files and returns
+// their paths.
+func writeAnchor(t *testing.T, fc *forkchoice.Store, root [32]byte) (statePath, blockPath string) {
+	t.Helper()
+	block, ok := fc.Storage.GetBlock(root)
+	if !ok {
+		t.Fatal("anchor block not found")
+	}
+	state, ok := fc.Storage.GetState(root)
+	if !ok {
+		t.Fatal("anchor state not found")
+	}
+	blockData, err := block.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateData, err := state.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	statePath = filepath.Join(dir, "state.ssz")
+	blockPath = filepath.Join(dir, "block.ssz")
+	if err := os.WriteFile(statePath, stateData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blockPath, blockData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return statePath, blockPath
+}
+
+func TestCheckpointAnchorFromFiles(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	statePath, blockPath := writeAnchor(t, fc, hashes[3])
+
+	anchor, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State:       statePath,
+		Block:       blockPath,
+		TrustedRoot: hexRoot(hashes[3]),
+	})
+	if err != nil {
+		t.Fatalf("load anchor: %v", err)
+	}
+	if anchor.Block.Slot != 3 || anchor.State.Slot != 3 {
+		t.Fatalf("anchor slot = %d/%d, want 3", anchor.Block.Slot, anchor.State.Slot)
+	}
+	if anchor.Signed != nil {
+		t.Fatal("a bare block file should not yield a signed envelope")
+	}
+}
+
+func TestCheckpointAnchorRejectsWrongTrustedRoot(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	statePath, blockPath := writeAnchor(t, fc, hashes[3])
+
+	_, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State:       statePath,
+		Block:       blockPath,
+		TrustedRoot: hexRoot(hashes[2]),
+	})
+	if err == nil || !strings.Contains(err.Error(), "trusted root") {
+		t.Fatalf("err = %v, want trusted root mismatch", err)
+	}
+}
+
+func TestCheckpointAnchorRejectsMismatchedState(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	statePath, _ := writeAnchor(t, fc, hashes[2])
+	_, blockPath := writeAnchor(t, fc, hashes[3])
+
+	_, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State: statePath,
+		Block: blockPath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "state root mismatch") {
+		t.Fatalf("err = %v, want state root mismatch", err)
+	}
+}
+
+func TestCheckpointAnchorFromURL(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	statePath, blockPath := writeAnchor(t, fc, hashes[3])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state.ssz", func(w http.ResponseWriter, r *http.Request) { http.ServeFile(w, r, statePath) })
+	mux.HandleFunc("/block.ssz", func(w http.ResponseWriter, r *http.Request) { http.ServeFile(w, r, blockPath) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	anchor, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State: srv.URL + "/state.ssz",
+		Block: srv.URL + "/block.ssz",
+	})
+	if err != nil {
+		t.Fatalf("load anchor: %v", err)
+	}
+	if anchor.Block.Slot != 3 {
+		t.Fatalf("anchor slot = %d, want 3", anchor.Block.Slot)
+	}
+
+	if _, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State: srv.URL + "/missing",
+		Block: srv.URL + "/block.ssz",
+	}); err == nil {
+		t.Fatal("expected error for missing state")
+	}
+}
+
+func TestCheckpointAnchorFromNodeAPI(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[2], Slot: 2}
+	srv := newAPIServer(t, fc)
+
+	anchor, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("load anchor: %v", err)
+	}
+	root, _ := anchor.Block.HashTreeRoot()
+	if root != hashes[2] {
+		t.Fatalf("anchor root = %x, want finalized %x", root, hashes[2])
+	}
+	stored, _ := fc.Storage.GetSignedBlock(hashes[2])
+	want, _ := stored.MarshalSSZ()
+	if anchor.Signed == nil {
+		t.Fatal("anchor from a node API should carry its signed envelope")
+	}
+	if got, _ := anchor.Signed.MarshalSSZ(); !bytes.Equal(got, want) {
+		t.Fatal("anchor envelope differs from the one the node stores")
+	}
+}
+
+func TestForkChoiceFromCheckpointAnchor(t *testing.T) {
+	source, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	statePath, blockPath := writeAnchor(t, source, hashes[3])
+	anchor, err := node.LoadCheckpointAnchor(context.Background(), node.CheckpointSource{
+		State: statePath,
+		Block: blockPath,
+	})
+	if err != nil {
+		t.Fatalf("load anchor: %v", err)
+	}
+
+	fc := forkchoice.NewStore(anchor.State, anchor.Block, memory.New())
+	if fc.Head != hashes[3] {
+		t.Fatalf("head = %x, want anchor %x", fc.Head, hashes[3])
+	}
+	if fc.LatestFinalized.Root != hashes[3] || fc.LatestJustified.Root != hashes[3] {
+		t.Fatal("anchor should be both justified and finalized")
+	}
+	if _, ok := fc.Storage.GetSignedBlock(hashes[3]); ok {
+		t.Fatal("anchor without a signed envelope should not get an unsigned one")
+	}
+
+	envelope, root := buildChildEnvelope(t, fc, hashes[3], 4)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("process block on anchor: %v", err)
+	}
+	voteFor(fc, 0, root, 4, hashes[3])
+	fc.AcceptNewAttestations()
+	if fc.Head != root {
+		t.Fatalf("head = %x, want child of anchor %x", fc.Head, root)
+	}
+	if fc.LatestFinalized.Root != hashes[3] {
+		t.Fatalf("finalized = %x, want anchor to be kept", fc.LatestFinalized.Root)
+	}
+}
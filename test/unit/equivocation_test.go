@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/types"
+)
+
+// acceptingVerifier accepts every signature but, unlike NoopVerifier,
+// counts as checking them, standing in for validly signed test messages.
+type acceptingVerifier struct{}
+
+func (acceptingVerifier) Verify([52]byte, uint64, [32]byte, *[3116]byte) error {
+	return nil
+}
+
+func TestDoubleVoteRecordsEvidence(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = acceptingVerifier{}
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	first := makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+	)
+	second := makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	)
+	fc.ProcessAttestation(first)
+	fc.ProcessAttestation(first) // duplicates are not equivocations
+	if n := len(fc.AttesterEquivocations()); n != 0 {
+		t.Fatalf("expected no evidence for duplicate vote, got %d", n)
+	}
+
+	fc.ProcessAttestation(second)
+	evidence := fc.AttesterEquivocations()
+	if len(evidence) != 1 {
+		t.Fatalf("expected 1 attester equivocation, got %d", len(evidence))
+	}
+	e := evidence[0]
+	if e.ValidatorID != 1 || e.Slot != 3 {
+		t.Fatalf("unexpected evidence: validator=%d slot=%d", e.ValidatorID, e.Slot)
+	}
+	if e.First.Message.Data.Head.Root != hashes[3] || e.Second.Message.Data.Head.Root != hashes[2] {
+		t.Fatal("evidence should carry both conflicting attestations in order")
+	}
+}
+
+func TestVotesForDifferentSlotsAreNotEquivocations(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = acceptingVerifier{}
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	fc.ProcessAttestation(makeFCAttestation(2, 2,
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	))
+	fc.ProcessAttestation(makeFCAttestation(2, 3,
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+	))
+	if n := len(fc.AttesterEquivocations()); n != 0 {
+		t.Fatalf("expected no evidence, got %d", n)
+	}
+}
+
+func TestDoubleProposalRecordsEvidence(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.Verifier = acceptingVerifier{}
+
+	// Two different valid blocks from proposer 3 at slot 3: one on slot 2,
+	// one skipping slot 2 and building on slot 1.
+	a, rootA := buildChildEnvelope(t, fc, hashes[2], 3)
+	b, rootB := buildChildEnvelope(t, fc, hashes[1], 3)
+	if rootA == rootB {
+		t.Fatal("test blocks should differ")
+	}
+
+	if err := fc.ProcessBlock(a); err != nil {
+		t.Fatalf("ProcessBlock(a): %v", err)
+	}
+	if err := fc.ProcessBlock(b); err != nil {
+		t.Fatalf("ProcessBlock(b): %v", err)
+	}
+
+	evidence := fc.ProposerEquivocations()
+	if len(evidence) != 1 {
+		t.Fatalf("expected 1 proposer equivocation, got %d", len(evidence))
+	}
+	e := evidence[0]
+	if e.ProposerIndex != 3 || e.Slot != 3 {
+		t.Fatalf("unexpected evidence: proposer=%d slot=%d", e.ProposerIndex, e.Slot)
+	}
+	if e.First.ParentRoot != hashes[2] || e.Second.ParentRoot != hashes[1] {
+		t.Fatal("evidence headers should match the conflicting blocks")
+	}
+}
+
+func TestInvalidVotesAreNotRecorded(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = acceptingVerifier{}
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	// A vote whose source is newer than its target fails validation, so it
+	// must not shadow the validator's real vote for the slot.
+	fc.ProcessAttestation(makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+	))
+	fc.ProcessAttestation(makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+	))
+	if n := len(fc.AttesterEquivocations()); n != 0 {
+		t.Fatalf("expected no evidence, got %d", n)
+	}
+}
+
+func TestVotesOutsideWindowAreNotRecorded(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = acceptingVerifier{}
+	fc.Time = 100 * types.IntervalsPerSlot
+
+	for _, head := range []uint64{3, 2} {
+		fc.ProcessAttestation(makeFCAttestation(1, 3,
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+			&types.Checkpoint{Root: hashes[1], Slot: 1},
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+		))
+	}
+	if n := len(fc.AttesterEquivocations()); n != 0 {
+		t.Fatalf("expected votes outside the window to be ignored, got %d", n)
+	}
+}
+
+func TestUnverifiedVotesAreNotRecorded(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.Verifier = forkchoice.NoopVerifier{}
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	for _, head := range []uint64{3, 2} {
+		fc.ProcessAttestation(makeFCAttestation(1, 3,
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+			&types.Checkpoint{Root: hashes[1], Slot: 1},
+			&types.Checkpoint{Root: hashes[head], Slot: head},
+		))
+	}
+	if n := len(fc.AttesterEquivocations()); n != 0 {
+		t.Fatalf("expected no evidence without signature verification, got %d", n)
+	}
+}
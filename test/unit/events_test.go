@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/types"
+)
+
+func voteFor(fc *forkchoice.Store, validatorID uint64, root [32]byte, slot uint64, source [32]byte) {
+	fc.LatestNewAttestations[validatorID] = makeFCAttestation(validatorID, slot,
+		&types.Checkpoint{Root: root, Slot: slot},
+		&types.Checkpoint{Root: source, Slot: 0},
+		&types.Checkpoint{Root: root, Slot: slot},
+	)
+}
+
+func nextEvent(t *testing.T, events <-chan forkchoice.Event) forkchoice.Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return forkchoice.Event{}
+}
+
+func TestEventsBlockAndHead(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	events, cancel := fc.SubscribeEvents(forkchoice.TopicBlock, forkchoice.TopicHead)
+	defer cancel()
+
+	envelope, root := buildChildEnvelope(t, fc, hashes[2], 3)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+	ev := nextEvent(t, events)
+	block, ok := ev.Data.(forkchoice.BlockEvent)
+	if ev.Topic != forkchoice.TopicBlock || !ok {
+		t.Fatalf("event = %+v, want block event", ev)
+	}
+	if block.Root != root || block.Slot != 3 || block.ParentRoot != hashes[2] {
+		t.Fatalf("block event = %+v, want slot 3 root %x", block, root[:4])
+	}
+
+	voteFor(fc, 0, root, 3, hashes[0])
+	fc.AcceptNewAttestations()
+	ev = nextEvent(t, events)
+	head, ok := ev.Data.(forkchoice.HeadEvent)
+	if ev.Topic != forkchoice.TopicHead || !ok {
+		t.Fatalf("event = %+v, want head event", ev)
+	}
+	if head.Root != root || head.Slot != 3 {
+		t.Fatalf("head event = %+v, want slot 3 root %x", head, root[:4])
+	}
+}
+
+func TestEventsReorg(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	voteFor(fc, 0, hashes[3], 3, hashes[0])
+	fc.AcceptNewAttestations()
+
+	fork, forkRoot := buildChildEnvelope(t, fc, hashes[1], 4)
+	if err := fc.ProcessBlock(fork); err != nil {
+		t.Fatalf("process fork block: %v", err)
+	}
+
+	events, cancel := fc.SubscribeEvents(forkchoice.TopicReorg)
+	defer cancel()
+
+	for id := uint64(1); id <= 3; id++ {
+		voteFor(fc, id, forkRoot, 4, hashes[0])
+	}
+	fc.AcceptNewAttestations()
+
+	ev := nextEvent(t, events)
+	reorg, ok := ev.Data.(forkchoice.ReorgEvent)
+	if !ok {
+		t.Fatalf("event = %+v, want reorg event", ev)
+	}
+	if reorg.OldHead != hashes[3] || reorg.NewHead != forkRoot {
+		t.Fatalf("reorg heads = %x -> %x, want %x -> %x", reorg.OldHead, reorg.NewHead, hashes[3], forkRoot)
+	}
+	if reorg.CommonAncestor != hashes[1] {
+		t.Fatalf("common ancestor = %x, want %x", reorg.CommonAncestor, hashes[1])
+	}
+	if reorg.Depth != 2 {
+		t.Fatalf("depth = %d, want 2", reorg.Depth)
+	}
+}
+
+func TestEventsTopicFilter(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	events, cancel := fc.SubscribeEvents(forkchoice.TopicFinalization)
+	defer cancel()
+
+	envelope, _ := buildChildEnvelope(t, fc, hashes[2], 3)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestAPIEventStream(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	srv := newAPIServer(t, fc)
+
+	resp, err := http.Get(srv.URL + "/lean/v0/events?topics=block")
+	if err != nil {
+		t.Fatalf("GET events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q, want text/event-stream", ct)
+	}
+
+	envelope, root := buildChildEnvelope(t, fc, hashes[2], 3)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	eventLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read event line: %v", err)
+	}
+	dataLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read data line: %v", err)
+	}
+	if eventLine != "event: block\n" {
+		t.Fatalf("event line = %q, want block", eventLine)
+	}
+	if !strings.Contains(dataLine, hexRoot(root)) {
+		t.Fatalf("data line %q does not contain root %s", dataLine, hexRoot(root))
+	}
+
+	if code := getJSON(t, srv.URL+"/lean/v0/events?topics=bogus", nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown topic status = %d, want 400", code)
+	}
+}
@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/types"
+)
+
+func TestForkDigestCommitsToGenesis(t *testing.T) {
+	validators := []*types.Validator{{Pubkey: [52]byte{1}}, {Pubkey: [52]byte{2}, Index: 1}}
+	base := types.ComputeGenesisRoot(1000, validators)
+	if base != types.ComputeGenesisRoot(1000, validators) {
+		t.Fatal("genesis root is not deterministic")
+	}
+	if base == types.ComputeGenesisRoot(1001, validators) {
+		t.Fatal("genesis root ignores genesis time")
+	}
+	if base == types.ComputeGenesisRoot(1000, validators[:1]) {
+		t.Fatal("genesis root ignores validators")
+	}
+
+	genesis := types.ComputeForkDigest(types.GenesisForkVersion, base)
+	if genesis == types.ComputeForkDigest(types.ForkVersion{1}, base) {
+		t.Fatal("fork digest ignores the fork version")
+	}
+}
+
+func TestForkSchedule(t *testing.T) {
+	next := types.Fork{Version: types.ForkVersion{1}, Slot: 100}
+	forks, err := types.NewForkSchedule(1000, nil, []types.Fork{next})
+	if err != nil {
+		t.Fatalf("NewForkSchedule: %v", err)
+	}
+	genesisDigest := types.ComputeForkDigest(types.GenesisForkVersion, forks.GenesisRoot)
+	nextDigest := types.ComputeForkDigest(next.Version, forks.GenesisRoot)
+
+	for _, tt := range []struct {
+		slot uint64
+		want types.ForkDigest
+	}{{0, genesisDigest}, {99, genesisDigest}, {100, nextDigest}, {1000, nextDigest}} {
+		if got := forks.DigestAt(tt.slot); got != tt.want {
+			t.Fatalf("DigestAt(%d) = %s, want %s", tt.slot, got, tt.want)
+		}
+	}
+	if f, ok := forks.NextFork(50); !ok || f != next {
+		t.Fatalf("NextFork(50) = %v, %v, want %v", f, ok, next)
+	}
+	if _, ok := forks.NextFork(100); ok {
+		t.Fatal("NextFork(100) should find no fork")
+	}
+
+	bad := [][]types.Fork{
+		{{Version: types.ForkVersion{1}, Slot: 0}},
+		{{Version: types.ForkVersion{1}, Slot: 10}, {Version: types.ForkVersion{2}, Slot: 10}},
+		{{Version: types.GenesisForkVersion, Slot: 10}},
+	}
+	for _, forks := range bad {
+		if _, err := types.NewForkSchedule(1000, nil, forks); err == nil {
+			t.Fatalf("NewForkSchedule(%v) should fail", forks)
+		}
+	}
+}
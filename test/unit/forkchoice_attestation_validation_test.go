@@ -80,14 +80,14 @@ func TestForkChoiceProcessAttestationValidGossip(t *testing.T) {
 	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
 	fc.Time = 10 * types.IntervalsPerSlot // current slot far ahead of vote slot
 
-	sa := makeFCAttestation(5, 2,
+	sa := makeFCAttestation(4, 2,
 		&types.Checkpoint{Root: hashes[2], Slot: 2},
 		&types.Checkpoint{Root: hashes[1], Slot: 1},
 		&types.Checkpoint{Root: hashes[2], Slot: 2},
 	)
 	fc.ProcessAttestation(sa)
 
-	got, ok := fc.LatestNewAttestations[5]
+	got, ok := fc.LatestNewAttestations[4]
 	if !ok {
 		t.Fatal("expected validator attestation in latest_new_attestations")
 	}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+// buildChildEnvelope builds a valid empty block on top of parentRoot without
+// importing it.
+func buildChildEnvelope(t *testing.T, fc *forkchoice.Store, parentRoot [32]byte, slot uint64) (*types.SignedBlockWithAttestation, [32]byte) {
+	t.Helper()
+	parentState, ok := fc.Storage.GetState(parentRoot)
+	if !ok {
+		t.Fatalf("parent state %x not found", parentRoot[:4])
+	}
+	advanced, err := statetransition.ProcessSlots(parentState, slot)
+	if err != nil {
+		t.Fatalf("process slots: %v", err)
+	}
+	block := &types.Block{
+		Slot:          slot,
+		ProposerIndex: slot % fc.NumValidators,
+		ParentRoot:    parentRoot,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	post, err := statetransition.ProcessBlock(advanced, block)
+	if err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+	block.StateRoot, _ = post.HashTreeRoot()
+	root, _ := block.HashTreeRoot()
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{Block: block},
+	}, root
+}
+
+func TestAttestationForUnknownBlockIsReplayed(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	envelope, root3 := buildChildEnvelope(t, fc, hashes[2], 3)
+	sa := makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: root3, Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	)
+	fc.ProcessAttestation(sa)
+
+	if len(fc.LatestNewAttestations) != 0 {
+		t.Fatal("attestation for unknown head should not be counted yet")
+	}
+	if fc.PendingAttestationCount() != 1 {
+		t.Fatalf("pending = %d, want 1", fc.PendingAttestationCount())
+	}
+
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
+	}
+
+	got, ok := fc.LatestNewAttestations[1]
+	if !ok {
+		t.Fatal("held attestation should be replayed once its block arrives")
+	}
+	if got.Message.Data.Head.Root != root3 {
+		t.Fatal("replayed attestation has wrong head")
+	}
+	if fc.PendingAttestationCount() != 0 {
+		t.Fatalf("pending = %d, want 0 after replay", fc.PendingAttestationCount())
+	}
+}
+
+func TestHeldAttestationKeepsNewestPerValidator(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.Time = 10 * types.IntervalsPerSlot
+	missing := [32]byte{0xee}
+
+	for _, slot := range []uint64{3, 4, 3} {
+		fc.ProcessAttestation(makeFCAttestation(2, slot,
+			&types.Checkpoint{Root: missing, Slot: slot},
+			&types.Checkpoint{Root: hashes[1], Slot: 1},
+			&types.Checkpoint{Root: hashes[2], Slot: 2},
+		))
+	}
+	if fc.PendingAttestationCount() != 1 {
+		t.Fatalf("pending = %d, want 1", fc.PendingAttestationCount())
+	}
+}
+
+func TestHeldAttestationsExpire(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.PendingAttestationSlots = 2
+	fc.Time = 2 * types.IntervalsPerSlot
+
+	fc.ProcessAttestation(makeFCAttestation(3, 2,
+		&types.Checkpoint{Root: [32]byte{0xee}, Slot: 2},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	))
+	if fc.PendingAttestationCount() != 1 {
+		t.Fatalf("pending = %d, want 1", fc.PendingAttestationCount())
+	}
+
+	// Held at slot 2; still kept through slot 4, dropped at slot 5.
+	for fc.Time < 4*types.IntervalsPerSlot {
+		fc.TickInterval(false)
+	}
+	if fc.PendingAttestationCount() != 1 {
+		t.Fatal("attestation should still be held within the expiry window")
+	}
+	for fc.Time < 5*types.IntervalsPerSlot {
+		fc.TickInterval(false)
+	}
+	if fc.PendingAttestationCount() != 0 {
+		t.Fatal("attestation should expire after PendingAttestationSlots")
+	}
+}
+
+func TestHeldAttestationsLimitedPerValidator(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	// One validator voting for many unknown roots only gets a few held.
+	for i := 0; i < 10; i++ {
+		fc.ProcessAttestation(makeFCAttestation(4, 3,
+			&types.Checkpoint{Root: [32]byte{0xe0, byte(i)}, Slot: 3},
+			&types.Checkpoint{Root: hashes[1], Slot: 1},
+			&types.Checkpoint{Root: hashes[2], Slot: 2},
+		))
+	}
+	if got := fc.PendingAttestationCount(); got != 4 {
+		t.Fatalf("pending = %d, want 4", got)
+	}
+
+	// Other validators are unaffected.
+	fc.ProcessAttestation(makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: [32]byte{0xe0, 0}, Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	))
+	if got := fc.PendingAttestationCount(); got != 5 {
+		t.Fatalf("pending = %d, want 5", got)
+	}
+}
+
+func TestAttestationForZeroRootIsNotHeld(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 2)
+	fc.Time = 10 * types.IntervalsPerSlot
+
+	fc.ProcessAttestation(makeFCAttestation(1, 3,
+		&types.Checkpoint{Root: types.ZeroHash, Slot: 3},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[2], Slot: 2},
+	))
+	if got := fc.PendingAttestationCount(); got != 0 {
+		t.Fatalf("pending = %d, want 0", got)
+	}
+	if _, ok := fc.LatestNewAttestations[1]; ok {
+		t.Fatal("attestation for the zero root should be rejected")
+	}
+}
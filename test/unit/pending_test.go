@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/types"
+)
+
+func makePendingEnvelope(slot uint64, parent [32]byte) (*types.SignedBlockWithAttestation, [32]byte) {
+	block := makeBlock(slot, slot, parent)
+	root, _ := block.HashTreeRoot()
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{Block: block},
+	}, root
+}
+
+func TestPendingBlocksTakeChildren(t *testing.T) {
+	p := node.NewPendingBlocks(10, 10, time.Minute)
+	now := time.Now()
+	parent := [32]byte{0xaa}
+
+	a, aRoot := makePendingEnvelope(5, parent)
+	b, _ := makePendingEnvelope(6, parent)
+
+	added, first := p.Add(aRoot, a, "peer", now)
+	if !added || !first {
+		t.Fatalf("first add: added=%v firstChild=%v", added, first)
+	}
+	bRoot, _ := b.Message.Block.HashTreeRoot()
+	added, first = p.Add(bRoot, b, "peer", now)
+	if !added || first {
+		t.Fatalf("second add: added=%v firstChild=%v", added, first)
+	}
+	if added, _ := p.Add(aRoot, a, "peer", now); added {
+		t.Fatal("duplicate block should not be added")
+	}
+
+	missing := p.MissingParents()
+	if _, ok := missing[parent]; !ok || len(missing) != 1 {
+		t.Fatalf("expected single missing parent, got %d", len(missing))
+	}
+
+	children := p.TakeChildren(parent)
+	if len(children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(children))
+	}
+	if p.Len() != 0 {
+		t.Fatalf("pool should be empty after take, got %d", p.Len())
+	}
+}
+
+func TestPendingBlocksLimits(t *testing.T) {
+	p := node.NewPendingBlocks(3, 2, time.Minute)
+	now := time.Now()
+
+	for i := uint64(0); i < 2; i++ {
+		sb, root := makePendingEnvelope(i+1, [32]byte{byte(i)})
+		if added, _ := p.Add(root, sb, "spammer", now); !added {
+			t.Fatalf("add %d should succeed", i)
+		}
+	}
+	sb, root := makePendingEnvelope(3, [32]byte{0x03})
+	if added, _ := p.Add(root, sb, "spammer", now); added {
+		t.Fatal("per-peer limit should reject third block")
+	}
+	if added, _ := p.Add(root, sb, "honest", now); !added {
+		t.Fatal("other peer should still be able to add")
+	}
+	sb, root = makePendingEnvelope(4, [32]byte{0x04})
+	if added, _ := p.Add(root, sb, "other", now); added {
+		t.Fatal("total limit should reject fourth block")
+	}
+}
+
+func TestPendingBlocksPruneExpires(t *testing.T) {
+	p := node.NewPendingBlocks(10, 10, time.Minute)
+	start := time.Now()
+
+	old, oldRoot := makePendingEnvelope(1, [32]byte{0x01})
+	fresh, freshRoot := makePendingEnvelope(2, [32]byte{0x02})
+	p.Add(oldRoot, old, "peer", start)
+	p.Add(freshRoot, fresh, "peer", start.Add(50*time.Second))
+
+	if dropped := p.Prune(start.Add(90 * time.Second)); dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+	if p.Contains(oldRoot) || !p.Contains(freshRoot) {
+		t.Fatal("expected only the expired block to be dropped")
+	}
+}
+
+func TestProcessBlockReportsUnknownParent(t *testing.T) {
+	fc, _ := makeGenesisFC(5)
+	sb, _ := makePendingEnvelope(2, [32]byte{0xde, 0xad})
+
+	err := fc.ProcessBlock(sb)
+	if !errors.Is(err, forkchoice.ErrUnknownParent) {
+		t.Fatalf("expected ErrUnknownParent, got %v", err)
+	}
+}
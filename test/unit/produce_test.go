@@ -1,8 +1,13 @@
 package unit
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/slashing"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -26,18 +31,24 @@ func TestProduceBlockCreatesValidBlock(t *testing.T) {
 		t.Fatal("block.StateRoot should not be zero")
 	}
 
-	// Block should be stored.
+	// Block should not be stored until it is signed and imported.
 	blockHash, _ := block.HashTreeRoot()
-	if _, ok := fc.Storage.GetBlock(blockHash); !ok {
-		t.Fatal("produced block should be stored")
+	if _, ok := fc.Storage.GetBlock(blockHash); ok {
+		t.Fatal("produced block should not be stored before import")
+	}
+	envelope.Signature[len(envelope.Signature)-1] = [3116]byte{1}
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("ProcessBlock: %v", err)
 	}
 	if _, ok := fc.Storage.GetState(blockHash); !ok {
-		t.Fatal("produced block state should be stored")
+		t.Fatal("imported block state should be stored")
 	}
-
-	// Signed envelope should be stored.
-	if _, ok := fc.Storage.GetSignedBlock(blockHash); !ok {
-		t.Fatal("produced signed block envelope should be stored")
+	stored, ok := fc.Storage.GetSignedBlock(blockHash)
+	if !ok {
+		t.Fatal("imported signed block envelope should be stored")
+	}
+	if stored.Signature[len(stored.Signature)-1] != ([3116]byte{1}) {
+		t.Fatal("stored envelope should carry the proposer signature")
 	}
 
 	// Proposer attestation should be present.
@@ -93,6 +104,37 @@ func TestProduceBlockIncludesAttestations(t *testing.T) {
 	}
 }
 
+func TestProposeBlockChecksSlashingProtectionBeforeImport(t *testing.T) {
+	fc, _ := buildForkChoiceWithBlocks(t, 5, 3)
+	db, err := slashing.Open("")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	// Proposer 4 already signed another block for slot 4.
+	if err := db.CheckBlock(4, 4, [32]byte{0xee}); err != nil {
+		t.Fatalf("CheckBlock: %v", err)
+	}
+	duties := &node.ValidatorDuties{Indices: []uint64{4}, FC: fc, Slashing: db}
+	head := fc.Head
+
+	if _, err := duties.ProposeBlock(context.Background(), 4, 4); !errors.Is(err, slashing.ErrDoubleProposal) {
+		t.Fatalf("err = %v, want %v", err, slashing.ErrDoubleProposal)
+	}
+	if fc.Head != head || len(fc.Storage.GetAllBlocks()) != 4 {
+		t.Fatal("refused block should not enter fork choice")
+	}
+
+	duties.Slashing, _ = slashing.Open("")
+	envelope, err := duties.ProposeBlock(context.Background(), 4, 4)
+	if err != nil {
+		t.Fatalf("ProposeBlock: %v", err)
+	}
+	root, _ := envelope.Message.Block.HashTreeRoot()
+	if _, ok := fc.Storage.GetSignedBlock(root); !ok {
+		t.Fatal("proposed block should be imported")
+	}
+}
+
 func TestProduceAttestationReturnsValidAttestation(t *testing.T) {
 	fc, _ := buildForkChoiceWithBlocks(t, 5, 2)
 
@@ -120,3 +162,34 @@ func TestProduceAttestationSourceIsLatestJustified(t *testing.T) {
 			sa.Message.Data.Source.Slot, fc.LatestJustified.Slot)
 	}
 }
+
+func TestProducedAttestationIsAccepted(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc.AdvanceTime(fc.GenesisTime+3*types.SecondsPerSlot, false)
+
+	sa := fc.ProduceAttestation(3, 0)
+	if sa.Message.Data.Source.Root != hashes[0] {
+		t.Fatalf("att.Data.Source.Root = %x, want genesis block root %x", sa.Message.Data.Source.Root, hashes[0])
+	}
+	if err := fc.ProcessAttestation(sa); err != nil {
+		t.Fatalf("ProcessAttestation: %v", err)
+	}
+}
+
+func TestResumedStoreProducesAcceptedAttestation(t *testing.T) {
+	built, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	fc, err := forkchoice.NewStoreFromStorage(built.Storage)
+	if err != nil {
+		t.Fatalf("NewStoreFromStorage: %v", err)
+	}
+	fc.Verifier = forkchoice.NoopVerifier{}
+	fc.AdvanceTime(fc.GenesisTime+3*types.SecondsPerSlot, false)
+
+	sa := fc.ProduceAttestation(3, 0)
+	if sa.Message.Data.Source.Root != hashes[0] {
+		t.Fatalf("att.Data.Source.Root = %x, want genesis block root %x", sa.Message.Data.Source.Root, hashes[0])
+	}
+	if err := fc.ProcessAttestation(sa); err != nil {
+		t.Fatalf("ProcessAttestation: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestProtoArrayVoteChangeMovesHead(t *testing.T) {
+	genesis, a, b := [32]byte{0}, [32]byte{1}, [32]byte{2}
+	p := forkchoice.NewProtoArray()
+	p.OnBlock(genesis, types.ZeroHash, 0)
+	p.OnBlock(a, genesis, 1)
+	p.OnBlock(b, genesis, 1)
+
+	votes := map[uint64]*types.SignedAttestation{
+		0: makeGhostAttestation(0, a, 1),
+		1: makeGhostAttestation(1, a, 1),
+		2: makeGhostAttestation(2, b, 1),
+	}
+	if head := p.Head(genesis, votes, 0); head != a {
+		t.Fatalf("expected head = a, got %x", head[:4])
+	}
+
+	// Two validators switch to b.
+	votes[0] = makeGhostAttestation(0, b, 1)
+	votes[1] = makeGhostAttestation(1, b, 1)
+	if head := p.Head(genesis, votes, 0); head != b {
+		t.Fatalf("expected head = b after vote change, got %x", head[:4])
+	}
+	if p.Weight(a) != 0 || p.Weight(b) != 3 || p.Weight(genesis) != 3 {
+		t.Fatalf("unexpected weights: a=%d b=%d genesis=%d", p.Weight(a), p.Weight(b), p.Weight(genesis))
+	}
+
+	// Removing votes takes their weight away.
+	delete(votes, 0)
+	delete(votes, 1)
+	p.ApplyVotes(votes)
+	if p.Weight(b) != 1 || p.Weight(genesis) != 1 {
+		t.Fatalf("unexpected weights after removal: b=%d genesis=%d", p.Weight(b), p.Weight(genesis))
+	}
+}
+
+func TestProtoArrayCountsVoteOnceBlockArrives(t *testing.T) {
+	genesis, a := [32]byte{0}, [32]byte{1}
+	p := forkchoice.NewProtoArray()
+	p.OnBlock(genesis, types.ZeroHash, 0)
+
+	votes := map[uint64]*types.SignedAttestation{0: makeGhostAttestation(0, a, 1)}
+	p.ApplyVotes(votes)
+
+	p.OnBlock(a, genesis, 1)
+	if head := p.Head(genesis, votes, 0); head != a {
+		t.Fatalf("expected head = a once block is known, got %x", head[:4])
+	}
+	if p.Weight(genesis) != 1 {
+		t.Fatalf("genesis weight = %d, want 1", p.Weight(genesis))
+	}
+}
+
+func TestProtoArrayTieBreaksOnSlotThenHash(t *testing.T) {
+	genesis, low, high := [32]byte{0}, [32]byte{0x01}, [32]byte{0xff}
+	p := forkchoice.NewProtoArray()
+	p.OnBlock(genesis, types.ZeroHash, 0)
+	p.OnBlock(low, genesis, 2)
+	p.OnBlock(high, genesis, 1)
+
+	votes := map[uint64]*types.SignedAttestation{
+		0: makeGhostAttestation(0, low, 2),
+		1: makeGhostAttestation(1, high, 1),
+	}
+	if head := p.Head(genesis, votes, 0); head != low {
+		t.Fatalf("expected higher-slot child to win tie, got %x", head[:4])
+	}
+
+	p2 := forkchoice.NewProtoArray()
+	p2.OnBlock(genesis, types.ZeroHash, 0)
+	p2.OnBlock(low, genesis, 1)
+	p2.OnBlock(high, genesis, 1)
+	votes[0] = makeGhostAttestation(0, low, 1)
+	if head := p2.Head(genesis, votes, 0); head != high {
+		t.Fatalf("expected larger hash to win tie, got %x", head[:4])
+	}
+}
+
+func TestProtoArrayPruneKeepsFinalizedSubtree(t *testing.T) {
+	genesis, a, b, c := [32]byte{0}, [32]byte{1}, [32]byte{2}, [32]byte{3}
+	p := forkchoice.NewProtoArray()
+	p.OnBlock(genesis, types.ZeroHash, 0)
+	p.OnBlock(a, genesis, 1)
+	p.OnBlock(b, genesis, 1)
+	p.OnBlock(c, a, 2)
+
+	votes := map[uint64]*types.SignedAttestation{
+		0: makeGhostAttestation(0, c, 2),
+		1: makeGhostAttestation(1, b, 1),
+	}
+	p.ApplyVotes(votes)
+	p.Prune(a)
+
+	if p.Contains(genesis) || p.Contains(b) {
+		t.Fatal("expected genesis and sibling branch to be pruned")
+	}
+	if !p.Contains(a) || !p.Contains(c) {
+		t.Fatal("expected finalized block and descendants to be kept")
+	}
+	if p.Weight(a) != 1 || p.Weight(c) != 1 {
+		t.Fatalf("unexpected weights after prune: a=%d c=%d", p.Weight(a), p.Weight(c))
+	}
+	if head := p.Head(a, votes, 0); head != c {
+		t.Fatalf("expected head = c after prune, got %x", head[:4])
+	}
+}
+
+// TestProtoArrayIncrementalMatchesFullRecompute applies random vote rounds
+// to one long-lived tree and checks each head against a tree built from
+// scratch with the same votes.
+func TestProtoArrayIncrementalMatchesFullRecompute(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	store := memory.New()
+	p := forkchoice.NewProtoArray()
+
+	genesis := makeBlock(0, 0, types.ZeroHash)
+	genesisRoot, _ := genesis.HashTreeRoot()
+	store.PutBlock(genesisRoot, genesis)
+	p.OnBlock(genesisRoot, genesis.ParentRoot, genesis.Slot)
+
+	roots := [][32]byte{genesisRoot}
+	slots := map[[32]byte]uint64{genesisRoot: 0}
+	votes := make(map[uint64]*types.SignedAttestation)
+
+	for round := 0; round < 50; round++ {
+		// Add a block on a random existing parent.
+		parent := roots[rng.Intn(len(roots))]
+		block := makeBlock(slots[parent]+1+uint64(rng.Intn(2)), uint64(round), parent)
+		root, _ := block.HashTreeRoot()
+		store.PutBlock(root, block)
+		p.OnBlock(root, parent, block.Slot)
+		roots = append(roots, root)
+		slots[root] = block.Slot
+
+		// Move a few validators' votes.
+		for i := 0; i < 3; i++ {
+			id := uint64(rng.Intn(8))
+			target := roots[rng.Intn(len(roots))]
+			votes[id] = makeGhostAttestation(id, target, slots[target])
+		}
+
+		for _, minScore := range []int{0, 3} {
+			got := p.Head(genesisRoot, votes, minScore)
+			want := forkchoice.GetForkChoiceHead(store, genesisRoot, votes, minScore)
+			if got != want {
+				t.Fatalf("round %d minScore %d: incremental head %x != full head %x", round, minScore, got[:4], want[:4])
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// addForkBlock stores a sibling block at slot with the given parent directly
+// in storage, bypassing the state transition.
+func addForkBlock(t *testing.T, store storage.Store, slot uint64, parent [32]byte) [32]byte {
+	t.Helper()
+	block := &types.Block{
+		Slot:          slot,
+		ProposerIndex: slot % 5,
+		ParentRoot:    parent,
+		StateRoot:     [32]byte{0x99},
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	root, err := block.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.PutBlock(root, block)
+	store.PutState(root, &types.State{Slot: slot})
+	return root
+}
+
+func TestPruneDropsNonCanonicalBranches(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+	fork := addForkBlock(t, fc.Storage, 2, hashes[1])
+
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+	fc.Prune()
+
+	if _, ok := fc.Storage.GetBlock(fork); ok {
+		t.Fatal("fork block below finalized should be pruned")
+	}
+	if _, ok := fc.Storage.GetState(fork); ok {
+		t.Fatal("fork state below finalized should be pruned")
+	}
+	for slot := uint64(0); slot <= 4; slot++ {
+		if _, ok := fc.Storage.GetBlock(hashes[slot]); !ok {
+			t.Fatalf("canonical block at slot %d should be kept", slot)
+		}
+	}
+}
+
+func TestPruneDropsStatesBelowFinalized(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+	fc.Prune()
+
+	for slot := uint64(0); slot < 3; slot++ {
+		if _, ok := fc.Storage.GetState(hashes[slot]); ok {
+			t.Fatalf("state at slot %d should be pruned", slot)
+		}
+	}
+	for slot := uint64(3); slot <= 4; slot++ {
+		if _, ok := fc.Storage.GetState(hashes[slot]); !ok {
+			t.Fatalf("state at slot %d should be kept", slot)
+		}
+	}
+}
+
+func TestPruneKeepsDescendantForks(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+	fork := addForkBlock(t, fc.Storage, 4, hashes[3])
+
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+	fc.Prune()
+
+	if _, ok := fc.Storage.GetBlock(fork); !ok {
+		t.Fatal("fork descending from finalized should be kept")
+	}
+}
+
+func TestPruneFinalizedBlocksOption(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+	fc.PruneFinalizedBlocks = true
+
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+	fc.Prune()
+
+	for slot := uint64(0); slot < 3; slot++ {
+		if _, ok := fc.Storage.GetBlock(hashes[slot]); ok {
+			t.Fatalf("finalized block at slot %d should be pruned", slot)
+		}
+	}
+	if _, ok := fc.Storage.GetBlock(hashes[3]); !ok {
+		t.Fatal("finalized block itself should be kept")
+	}
+}
+
+func TestPruneDropsStaleAttestations(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+	fc.LatestKnownAttestations[0] = makeFCAttestation(0, 1,
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+		&types.Checkpoint{Root: hashes[0], Slot: 0},
+		&types.Checkpoint{Root: hashes[1], Slot: 1},
+	)
+	fc.LatestNewAttestations[1] = makeFCAttestation(1, 4,
+		&types.Checkpoint{Root: hashes[4], Slot: 4},
+		&types.Checkpoint{Root: hashes[3], Slot: 3},
+		&types.Checkpoint{Root: hashes[4], Slot: 4},
+	)
+
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+	fc.Prune()
+
+	if _, ok := fc.LatestKnownAttestations[0]; ok {
+		t.Fatal("attestation with head below finalized should be pruned")
+	}
+	if _, ok := fc.LatestNewAttestations[1]; !ok {
+		t.Fatal("attestation with head above finalized should be kept")
+	}
+}
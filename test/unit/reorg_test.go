@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReorgRecordedInHistory(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 3)
+	voteFor(fc, 0, hashes[3], 3, hashes[0])
+	fc.AcceptNewAttestations()
+	if got := fc.RecentReorgs(); len(got) != 0 {
+		t.Fatalf("reorgs = %d, want 0 for a head extension", len(got))
+	}
+
+	fork, forkRoot := buildChildEnvelope(t, fc, hashes[2], 4)
+	if err := fc.ProcessBlock(fork); err != nil {
+		t.Fatalf("process fork block: %v", err)
+	}
+	for id := uint64(1); id <= 3; id++ {
+		voteFor(fc, id, forkRoot, 4, hashes[0])
+	}
+	fc.AcceptNewAttestations()
+
+	reorgs := fc.RecentReorgs()
+	if len(reorgs) != 1 {
+		t.Fatalf("reorgs = %d, want 1", len(reorgs))
+	}
+	if reorgs[0].CommonAncestor != hashes[2] || reorgs[0].Depth != 1 {
+		t.Fatalf("reorg = %+v, want ancestor at slot 2 and depth 1", reorgs[0])
+	}
+
+	srv := newAPIServer(t, fc)
+	var resp struct {
+		Data []struct {
+			Depth string `json:"depth"`
+		} `json:"data"`
+	}
+	if code := getJSON(t, srv.URL+"/lean/v0/reorgs", &resp); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Depth != "1" {
+		t.Fatalf("api reorgs = %+v, want one of depth 1", resp.Data)
+	}
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss"
+)
+
+// makeSigningFC returns a store verifying XMSS signatures and the keys of
+// its validators, which can sign slots 0 through 7.
+func makeSigningFC(t *testing.T, numValidators uint64) (*forkchoice.Store, []*xmss.PrivateKey) {
+	t.Helper()
+	keys := make([]*xmss.PrivateKey, numValidators)
+	validators := makeTestValidators(numValidators)
+	for i := range keys {
+		key, err := xmss.NewKey([xmss.SeedSize]byte{byte(i)}, 0, 8)
+		if err != nil {
+			t.Fatalf("NewKey: %v", err)
+		}
+		keys[i] = key
+		validators[i].Pubkey = key.PublicKey()
+	}
+	state := statetransition.GenerateGenesis(1000, validators)
+	genesisBlock := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesisBlock.StateRoot, _ = state.HashTreeRoot()
+	return forkchoice.NewStore(state, genesisBlock, memory.New()), keys
+}
+
+func signAttestation(t *testing.T, key *xmss.PrivateKey, att *types.Attestation) [3116]byte {
+	t.Helper()
+	root, err := forkchoice.AttestationSigningRoot(att)
+	if err != nil {
+		t.Fatalf("signing root: %v", err)
+	}
+	sig, err := key.Sign(att.Data.Slot, root)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return sig
+}
+
+// buildAttestedEnvelope builds a block at slot on parentRoot carrying atts
+// in its body and a proposer attestation, with every signature zero.
+func buildAttestedEnvelope(t *testing.T, fc *forkchoice.Store, parentRoot [32]byte, slot uint64, atts []*types.Attestation) (*types.SignedBlockWithAttestation, [32]byte) {
+	t.Helper()
+	parentState, _ := fc.Storage.GetState(parentRoot)
+	advanced, err := statetransition.ProcessSlots(parentState, slot)
+	if err != nil {
+		t.Fatalf("process slots: %v", err)
+	}
+	block := &types.Block{
+		Slot:          slot,
+		ProposerIndex: slot % fc.NumValidators,
+		ParentRoot:    parentRoot,
+		Body:          &types.BlockBody{Attestations: atts},
+	}
+	post, err := statetransition.ProcessBlock(advanced, block)
+	if err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+	block.StateRoot, _ = post.HashTreeRoot()
+	root, _ := block.HashTreeRoot()
+	genesis := &types.Checkpoint{Root: parentRoot, Slot: parentState.Slot}
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block: block,
+			ProposerAttestation: &types.Attestation{
+				ValidatorID: block.ProposerIndex,
+				Data: &types.AttestationData{
+					Slot:   slot,
+					Head:   &types.Checkpoint{Root: root, Slot: slot},
+					Target: &types.Checkpoint{Root: root, Slot: slot},
+					Source: genesis,
+				},
+			},
+		},
+		Signature: make([][3116]byte, len(atts)+1),
+	}, root
+}
+
+func TestBlockSignatures(t *testing.T) {
+	fc, keys := makeSigningFC(t, 4)
+	genesis := fc.Head
+	vote := &types.Attestation{
+		ValidatorID: 2,
+		Data: &types.AttestationData{
+			Slot:   0,
+			Head:   &types.Checkpoint{Root: genesis},
+			Target: &types.Checkpoint{Root: genesis},
+			Source: &types.Checkpoint{Root: genesis},
+		},
+	}
+	envelope, root := buildAttestedEnvelope(t, fc, genesis, 1, []*types.Attestation{vote})
+	proposer := keys[envelope.Message.Block.ProposerIndex]
+
+	// Zero signatures are rejected, starting with the body attestation.
+	if err := fc.ProcessBlock(envelope); !errors.Is(err, forkchoice.ErrInvalidAttestationSignature) {
+		t.Fatalf("unsigned block err = %v, want %v", err, forkchoice.ErrInvalidAttestationSignature)
+	}
+	envelope.Signature[0] = signAttestation(t, keys[2], vote)
+	if err := fc.ProcessBlock(envelope); !errors.Is(err, forkchoice.ErrInvalidProposerSignature) {
+		t.Fatalf("unsigned proposer err = %v, want %v", err, forkchoice.ErrInvalidProposerSignature)
+	}
+	// A signature by another validator does not pass for the proposer's.
+	envelope.Signature[1] = signAttestation(t, keys[0], envelope.Message.ProposerAttestation)
+	if err := fc.ProcessBlock(envelope); !errors.Is(err, forkchoice.ErrInvalidProposerSignature) {
+		t.Fatalf("wrong proposer key err = %v, want %v", err, forkchoice.ErrInvalidProposerSignature)
+	}
+	if _, ok := fc.Storage.GetBlock(root); ok {
+		t.Fatal("block with invalid signatures was stored")
+	}
+
+	envelope.Signature[1] = signAttestation(t, proposer, envelope.Message.ProposerAttestation)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("signed block: %v", err)
+	}
+	if _, ok := fc.Storage.GetBlock(root); !ok {
+		t.Fatal("signed block was not stored")
+	}
+}
+
+func TestGossipAttestationSignature(t *testing.T) {
+	fc, keys := makeSigningFC(t, 4)
+	genesis := fc.Head
+	envelope, root := buildAttestedEnvelope(t, fc, genesis, 1, []*types.Attestation{})
+	envelope.Signature[0] = signAttestation(t, keys[1], envelope.Message.ProposerAttestation)
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("process block: %v", err)
+	}
+	fc.Time = 2 * types.IntervalsPerSlot
+
+	sa := makeFCAttestation(3, 1,
+		&types.Checkpoint{Root: root, Slot: 1},
+		&types.Checkpoint{Root: genesis, Slot: 0},
+		&types.Checkpoint{Root: root, Slot: 1},
+	)
+	if err := fc.ProcessAttestation(sa); !errors.Is(err, forkchoice.ErrInvalidAttestationSignature) {
+		t.Fatalf("unsigned attestation err = %v, want %v", err, forkchoice.ErrInvalidAttestationSignature)
+	}
+	if _, ok := fc.LatestNewAttestations[3]; ok {
+		t.Fatal("unsigned attestation was counted")
+	}
+
+	sa.Signature = signAttestation(t, keys[3], sa.Message)
+	if err := fc.ProcessAttestation(sa); err != nil {
+		t.Fatalf("signed attestation: %v", err)
+	}
+	if _, ok := fc.LatestNewAttestations[3]; !ok {
+		t.Fatal("signed attestation was not counted")
+	}
+
+	unknown := makeFCAttestation(9, 1, sa.Message.Data.Head, sa.Message.Data.Source, sa.Message.Data.Target)
+	if err := fc.ProcessAttestation(unknown); !errors.Is(err, forkchoice.ErrUnknownValidator) {
+		t.Fatalf("unknown validator err = %v, want %v", err, forkchoice.ErrUnknownValidator)
+	}
+}
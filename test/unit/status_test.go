@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestCheckPeerStatus(t *testing.T) {
+	fc, hashes := buildForkChoiceWithBlocks(t, 5, 4)
+	// One vote for the tip moves the head off genesis.
+	fc.LatestNewAttestations[0] = makeFCAttestation(0, 4,
+		&types.Checkpoint{Root: hashes[4], Slot: 4},
+		&types.Checkpoint{Root: hashes[0], Slot: 0},
+		&types.Checkpoint{Root: hashes[4], Slot: 4},
+	)
+	fc.AcceptNewAttestations()
+	fc.LatestFinalized = &types.Checkpoint{Root: hashes[3], Slot: 3}
+
+	forks, err := types.NewForkSchedule(1000, nil, []types.Fork{{Version: types.ForkVersion{1}, Slot: 6}})
+	if err != nil {
+		t.Fatalf("fork schedule: %v", err)
+	}
+	status := func(finalized *types.Checkpoint, headSlot uint64) reqresp.Status {
+		return reqresp.Status{
+			ForkDigest: forks.DigestAt(0),
+			Finalized:  finalized,
+			Head:       &types.Checkpoint{Slot: headSlot},
+		}
+	}
+	withDigest := func(digest types.ForkDigest) reqresp.Status {
+		st := status(&types.Checkpoint{}, 4)
+		st.ForkDigest = digest
+		return st
+	}
+	tests := []struct {
+		name         string
+		status       reqresp.Status
+		ok           bool
+		incompatible bool
+	}{
+		{"genesis zero root", status(&types.Checkpoint{}, 4), true, false},
+		{"same finalized", status(&types.Checkpoint{Root: hashes[3], Slot: 3}, 4), true, false},
+		{"earlier canonical", status(&types.Checkpoint{Root: hashes[1], Slot: 1}, 4), true, false},
+		{"ahead of our finality", status(&types.Checkpoint{Root: [32]byte{0xee}, Slot: 5}, 6), true, false},
+		{"head within clock disparity", status(&types.Checkpoint{}, 6), true, false},
+		{"head ahead of the clock", status(&types.Checkpoint{}, 7), false, false},
+		{"conflicting root", status(&types.Checkpoint{Root: [32]byte{0xee}, Slot: 2}, 4), false, true},
+		{"conflicting genesis", status(&types.Checkpoint{Root: [32]byte{0xee}, Slot: 0}, 4), false, true},
+		{"head before finalized", status(&types.Checkpoint{Root: hashes[3], Slot: 3}, 2), false, false},
+		{"zero root after genesis", status(&types.Checkpoint{Slot: 2}, 4), false, false},
+		{"other network", withDigest(types.ForkDigest{0xde, 0xad}), false, true},
+		{"next fork within clock disparity", withDigest(forks.DigestAt(6)), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := node.CheckPeerStatus(fc, forks, 5, 1, tt.status)
+			if (err == nil) != tt.ok {
+				t.Fatalf("CheckPeerStatus() = %v, want ok=%v", err, tt.ok)
+			}
+			if got := errors.Is(err, node.ErrIncompatibleChain); got != tt.incompatible {
+				t.Fatalf("incompatible = %v (%v), want %v", got, err, tt.incompatible)
+			}
+		})
+	}
+}
+
+func TestCheckPeerStatusHeadDisparity(t *testing.T) {
+	fc, _ := buildForkChoiceWithBlocks(t, 5, 2)
+	forks, err := types.NewForkSchedule(1000, nil, nil)
+	if err != nil {
+		t.Fatalf("fork schedule: %v", err)
+	}
+	status := reqresp.Status{
+		ForkDigest: forks.DigestAt(0),
+		Finalized:  &types.Checkpoint{},
+		Head:       &types.Checkpoint{Slot: 8},
+	}
+	if err := node.CheckPeerStatus(fc, forks, 5, 1, status); !errors.Is(err, node.ErrHeadAhead) {
+		t.Fatalf("err = %v, want ErrHeadAhead", err)
+	}
+	if err := node.CheckPeerStatus(fc, forks, 5, 3, status); err != nil {
+		t.Fatalf("head within a wider window: %v", err)
+	}
+}
@@ -25,6 +25,8 @@ func makeGenesisFC(numValidators uint64) (*forkchoice.Store, *types.State) {
 
 	store := memory.New()
 	fc := forkchoice.NewStore(state, genesisBlock, store)
+	// Test validators have placeholder keys and sign nothing.
+	fc.Verifier = forkchoice.NoopVerifier{}
 	return fc, state
 }
 
@@ -117,3 +119,45 @@ func TestProduceAttestationAcceptsNewAttestationsFirst(t *testing.T) {
 		t.Fatal("expected attestation to be moved into latest_known_attestations")
 	}
 }
+
+func TestStoreVerifiesSignaturesByDefault(t *testing.T) {
+	fc, state := makeGenesisFC(5)
+	block, _ := fc.Storage.GetBlock(fc.Head)
+	if _, ok := forkchoice.NewStore(state, block, memory.New()).Verifier.(forkchoice.XMSSVerifier); !ok {
+		t.Fatal("NewStore should verify signatures with xmss by default")
+	}
+	resumed, err := forkchoice.NewStoreFromStorage(fc.Storage)
+	if err != nil {
+		t.Fatalf("NewStoreFromStorage: %v", err)
+	}
+	if _, ok := resumed.Verifier.(forkchoice.XMSSVerifier); !ok {
+		t.Fatal("NewStoreFromStorage should verify signatures with xmss by default")
+	}
+}
+
+func TestForkChoiceResumesFromStorage(t *testing.T) {
+	fc, _ := buildForkChoiceWithBlocks(t, 5, 3)
+
+	resumed, err := forkchoice.NewStoreFromStorage(fc.Storage)
+	if err != nil {
+		t.Fatalf("NewStoreFromStorage: %v", err)
+	}
+	if resumed.Head != fc.Head {
+		t.Fatalf("head = %x, want %x", resumed.Head[:4], fc.Head[:4])
+	}
+	if *resumed.LatestJustified != *fc.LatestJustified {
+		t.Fatalf("justified = %+v, want %+v", resumed.LatestJustified, fc.LatestJustified)
+	}
+	if *resumed.LatestFinalized != *fc.LatestFinalized {
+		t.Fatalf("finalized = %+v, want %+v", resumed.LatestFinalized, fc.LatestFinalized)
+	}
+	if resumed.NumValidators != 5 || resumed.GenesisTime != fc.GenesisTime {
+		t.Fatalf("unexpected resumed config: validators=%d genesis=%d", resumed.NumValidators, resumed.GenesisTime)
+	}
+}
+
+func TestForkChoiceResumeRequiresCheckpoints(t *testing.T) {
+	if _, err := forkchoice.NewStoreFromStorage(memory.New()); err == nil {
+		t.Fatal("expected error resuming from empty storage")
+	}
+}
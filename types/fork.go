@@ -0,0 +1,110 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// ForkVersion identifies a protocol fork.
+type ForkVersion [4]byte
+
+// ForkDigest namespaces gossip topics and is exchanged in Status so that
+// nodes on different networks or forks do not talk to each other.
+type ForkDigest [4]byte
+
+func (d ForkDigest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// GenesisForkVersion is the version of the fork active at genesis.
+var GenesisForkVersion = ForkVersion{}
+
+// Fork is a scheduled fork: Version is active from Slot on.
+type Fork struct {
+	Version ForkVersion
+	Slot    uint64
+}
+
+// ForkSchedule maps slots to fork digests for one network.
+type ForkSchedule struct {
+	// GenesisRoot commits to the genesis time and validator set.
+	GenesisRoot [32]byte
+	// Forks are in ascending slot order, starting with the genesis fork
+	// at slot 0.
+	Forks []Fork
+}
+
+// NewForkSchedule builds the schedule of the network with the given
+// genesis, activating the genesis fork at slot 0 followed by forks.
+func NewForkSchedule(genesisTime uint64, validators []*Validator, forks []Fork) (*ForkSchedule, error) {
+	s := &ForkSchedule{
+		GenesisRoot: ComputeGenesisRoot(genesisTime, validators),
+		Forks:       []Fork{{Version: GenesisForkVersion, Slot: 0}},
+	}
+	for _, f := range forks {
+		prev := s.Forks[len(s.Forks)-1]
+		if f.Slot <= prev.Slot {
+			return nil, fmt.Errorf("fork %x at slot %d is not after fork %x at slot %d", f.Version, f.Slot, prev.Version, prev.Slot)
+		}
+		if f.Version == prev.Version {
+			return nil, fmt.Errorf("fork at slot %d repeats version %x", f.Slot, f.Version)
+		}
+		s.Forks = append(s.Forks, f)
+	}
+	return s, nil
+}
+
+// ComputeGenesisRoot hashes the genesis time and validator pubkeys:
+// sha256(uint64_le(genesis_time) || pubkey_0 || pubkey_1 || ...).
+func ComputeGenesisRoot(genesisTime uint64, validators []*Validator) [32]byte {
+	h := sha256.New()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], genesisTime)
+	h.Write(buf[:])
+	for _, v := range validators {
+		h.Write(v.Pubkey[:])
+	}
+	var root [32]byte
+	copy(root[:], h.Sum(nil))
+	return root
+}
+
+// ComputeForkDigest returns the first four bytes of
+// sha256(version || genesis_root).
+func ComputeForkDigest(version ForkVersion, genesisRoot [32]byte) ForkDigest {
+	h := sha256.New()
+	h.Write(version[:])
+	h.Write(genesisRoot[:])
+	var digest ForkDigest
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+// ForkAt returns the fork active at slot.
+func (s *ForkSchedule) ForkAt(slot uint64) Fork {
+	fork := s.Forks[0]
+	for _, f := range s.Forks[1:] {
+		if f.Slot > slot {
+			break
+		}
+		fork = f
+	}
+	return fork
+}
+
+// NextFork returns the first fork scheduled after slot.
+func (s *ForkSchedule) NextFork(slot uint64) (Fork, bool) {
+	for _, f := range s.Forks {
+		if f.Slot > slot {
+			return f, true
+		}
+	}
+	return Fork{}, false
+}
+
+// DigestAt returns the fork digest active at slot.
+func (s *ForkSchedule) DigestAt(slot uint64) ForkDigest {
+	return ComputeForkDigest(s.ForkAt(slot).Version, s.GenesisRoot)
+}
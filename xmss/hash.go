@@ -0,0 +1,236 @@
+package xmss
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// Sizes in field elements of the hash inputs and outputs.
+const (
+	hashLen      = 8
+	parameterLen = 5
+	rhoLen       = 7
+	tweakLen     = 2
+	messageLen   = 9
+	// capacityLen is the capacity of the sponge that hashes leaves.
+	capacityLen = 9
+	// messageHashLen is the number of elements the message hash draws
+	// its hypercube vertex from.
+	messageHashLen = 15
+)
+
+// digest is a tweakable hash output: a chain value or tree node.
+type digest [hashLen]uint32
+
+// Domain separators of the tweaks.
+const (
+	tweakChain   = 0x00
+	tweakTree    = 0x01
+	tweakMessage = 0x02
+)
+
+// chainTweak encodes the position of a chain step.
+func chainTweak(epoch uint64, chain, position int) [tweakLen]uint32 {
+	return tweakElements(epoch<<24 | uint64(chain)<<16 | uint64(position)<<8 | tweakChain)
+}
+
+// treeTweak encodes the position of a tree node; leaves are at level 0.
+func treeTweak(level int, index uint64) [tweakLen]uint32 {
+	return tweakElements(uint64(level)<<40 | index<<8 | tweakTree)
+}
+
+// tweakElements writes v in base p, least significant digit first.
+func tweakElements(v uint64) [tweakLen]uint32 {
+	return [tweakLen]uint32{uint32(v % fieldOrder), uint32(v / fieldOrder)}
+}
+
+// compress hashes input, at most the permutation's width, into out: the
+// zero-padded input is permuted and added back to the result.
+func compress(p *poseidon2, input []uint32, out []uint32) {
+	var buf [24]uint32
+	state := buf[:p.width]
+	copy(state, input)
+	p.permute(state)
+	for i := range out {
+		out[i] = fadd(state[i], input[i])
+	}
+}
+
+// chainHash advances a hash chain by one step: position is the step being
+// computed, 1 through ChainLength-1.
+func chainHash(param *[parameterLen]uint32, epoch uint64, chain, position int, v digest) digest {
+	var input [parameterLen + tweakLen + hashLen]uint32
+	tweak := chainTweak(epoch, chain, position)
+	copy(input[:], param[:])
+	copy(input[parameterLen:], tweak[:])
+	copy(input[parameterLen+tweakLen:], v[:])
+	var out digest
+	compress(poseidon16, input[:], out[:])
+	return out
+}
+
+// walkChain advances v from position from to position to.
+func walkChain(param *[parameterLen]uint32, epoch uint64, chain, from, to int, v digest) digest {
+	for pos := from + 1; pos <= to; pos++ {
+		v = chainHash(param, epoch, chain, pos, v)
+	}
+	return v
+}
+
+// nodeHash computes the tree node at level and index from its children.
+func nodeHash(param *[parameterLen]uint32, level int, index uint64, left, right digest) digest {
+	var input [parameterLen + tweakLen + 2*hashLen]uint32
+	tweak := treeTweak(level, index)
+	copy(input[:], param[:])
+	copy(input[parameterLen:], tweak[:])
+	copy(input[parameterLen+tweakLen:], left[:])
+	copy(input[parameterLen+tweakLen+hashLen:], right[:])
+	var out digest
+	compress(poseidon24, input[:], out[:])
+	return out
+}
+
+// leafCapacity separates the leaf sponge by the lengths of its inputs.
+var leafCapacity = func() [capacityLen]uint32 {
+	acc := new(big.Int)
+	for _, n := range []int64{parameterLen, tweakLen, NumChains, hashLen} {
+		acc.Lsh(acc, 32).Or(acc, big.NewInt(n))
+	}
+	var input [24]uint32
+	baseP(acc, input[:])
+	var out [capacityLen]uint32
+	compress(poseidon24, input[:], out[:])
+	return out
+}()
+
+// leafHash compresses the chain ends of an epoch into a tree leaf with a
+// sponge over the width-24 permutation.
+func leafHash(param *[parameterLen]uint32, epoch uint64, ends *[NumChains]digest) digest {
+	const rate = 24 - capacityLen
+	tweak := treeTweak(0, epoch)
+	input := make([]uint32, 0, parameterLen+tweakLen+NumChains*hashLen+rate)
+	input = append(input, param[:]...)
+	input = append(input, tweak[:]...)
+	for i := range ends {
+		input = append(input, ends[i][:]...)
+	}
+	for len(input)%rate != 0 {
+		input = append(input, 0)
+	}
+
+	var state [24]uint32
+	copy(state[rate:], leafCapacity[:])
+	for ; len(input) > 0; input = input[rate:] {
+		for i := 0; i < rate; i++ {
+			state[i] = fadd(state[i], input[i])
+		}
+		poseidon24.permute(state[:])
+	}
+	var out digest
+	copy(out[:], state[:])
+	return out
+}
+
+// messageDigits hashes a message to a vertex in the top FinalLayer+1
+// layers of the hypercube and returns its digits.
+func messageDigits(param *[parameterLen]uint32, epoch uint64, rho *[rhoLen]uint32, msg [MessageSize]byte) [NumChains]int {
+	var input [24]uint32
+	copy(input[:], rho[:])
+	copy(input[rhoLen:], param[:])
+	tweak := tweakElements(epoch<<8 | tweakMessage)
+	copy(input[rhoLen+parameterLen:], tweak[:])
+	var le [MessageSize]byte
+	for i := range msg {
+		le[i] = msg[MessageSize-1-i]
+	}
+	baseP(new(big.Int).SetBytes(le[:]), input[rhoLen+parameterLen+tweakLen:][:messageLen])
+	// The last element numbers the invocation; one suffices.
+
+	var out [messageHashLen]uint32
+	compress(poseidon24, input[:], out[:])
+	acc, p := new(big.Int), big.NewInt(fieldOrder)
+	for _, x := range out {
+		acc.Mul(acc, p).Add(acc, big.NewInt(int64(x)))
+	}
+	layerSizesOnce.Do(initLayerSizes)
+	return vertexOf(acc.Mod(acc, topSize))
+}
+
+// baseP writes v in base p into out, least significant digit first.
+func baseP(v *big.Int, out []uint32) {
+	v = new(big.Int).Set(v)
+	p, digit := big.NewInt(fieldOrder), new(big.Int)
+	for i := range out {
+		v.DivMod(v, p, digit)
+		out[i] = uint32(digit.Uint64())
+	}
+}
+
+// prfDomain separates the PRF from other uses of SHAKE128.
+var prfDomain = [16]byte{0xae, 0xae, 0x22, 0xff, 0x00, 0x01, 0xfa, 0xff, 0x21, 0xaf, 0x12, 0x00, 0x01, 0x11, 0xff, 0x00}
+
+// Domain separators of the PRF. leanSig draws the parameter and the
+// padding nodes from a random source at key generation; here they come
+// from the seed as well, so a key is rebuilt from its seed alone.
+const (
+	prfChain     = 0x00
+	prfRho       = 0x01
+	prfParameter = 0x02
+	prfPadding   = 0x03
+)
+
+// prf derives field elements from the key seed: SHAKE128 over the
+// domain, the seed and data, read as 16-byte big-endian integers
+// reduced mod p.
+func prf(seed *[SeedSize]byte, domain byte, out []uint32, data ...[]byte) {
+	h := sha3.NewSHAKE128()
+	h.Write(prfDomain[:])
+	h.Write([]byte{domain})
+	h.Write(seed[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	var buf [16]byte
+	for i := range out {
+		h.Read(buf[:])
+		hi := binary.BigEndian.Uint64(buf[:8]) % fieldOrder
+		out[i] = uint32(bits.Rem64(hi, binary.BigEndian.Uint64(buf[8:]), fieldOrder))
+	}
+}
+
+// chainStart derives the secret start of a chain.
+func chainStart(seed *[SeedSize]byte, epoch uint64, chain int) digest {
+	var d digest
+	prf(seed, prfChain, d[:], binary.BigEndian.AppendUint32(nil, uint32(epoch)), binary.BigEndian.AppendUint64(nil, uint64(chain)))
+	return d
+}
+
+// rhoFor derives the randomness of attempt counter at signing msg in
+// epoch. Signing is deterministic, so a key never needs a random source
+// after generation.
+func rhoFor(seed *[SeedSize]byte, epoch uint64, msg [MessageSize]byte, counter uint64) [rhoLen]uint32 {
+	var rho [rhoLen]uint32
+	prf(seed, prfRho, rho[:], binary.BigEndian.AppendUint32(nil, uint32(epoch)), msg[:], binary.BigEndian.AppendUint64(nil, counter))
+	return rho
+}
+
+// putElements writes elements as little-endian 32-bit words.
+func putElements(dst []byte, src []uint32) {
+	for i, x := range src {
+		binary.LittleEndian.PutUint32(dst[4*i:], x)
+	}
+}
+
+// readElements reads little-endian 32-bit words and reports whether all
+// are canonical field elements.
+func readElements(dst []uint32, src []byte) bool {
+	for i := range dst {
+		dst[i] = binary.LittleEndian.Uint32(src[4*i:])
+		if dst[i] >= fieldOrder {
+			return false
+		}
+	}
+	return true
+}
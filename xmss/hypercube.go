@@ -0,0 +1,75 @@
+package xmss
+
+import (
+	"math/big"
+	"sync"
+)
+
+// The message hash maps onto the vertices of the hypercube [0, ChainLength)^
+// NumChains. Layer d holds the vertices whose digits sum to
+// NumChains*(ChainLength-1) - d, so layer 0 is the all-top vertex and
+// low layers are cheap to verify: the verifier walks d chain steps.
+
+var (
+	layerSizesOnce sync.Once
+	// layerSizes[n][d] is the number of vertices of the n-dimensional
+	// hypercube in layer d, for d up to FinalLayer.
+	layerSizes [][]*big.Int
+	// topSize is the number of vertices in layers 0 through FinalLayer.
+	topSize *big.Int
+)
+
+func initLayerSizes() {
+	layerSizes = make([][]*big.Int, NumChains+1)
+	for n := range layerSizes {
+		layerSizes[n] = make([]*big.Int, FinalLayer+1)
+		for d := range layerSizes[n] {
+			size := new(big.Int)
+			switch {
+			case n == 0:
+				if d == 0 {
+					size.SetInt64(1)
+				}
+			default:
+				for j := 0; j < ChainLength && j <= d; j++ {
+					size.Add(size, layerSizes[n-1][d-j])
+				}
+			}
+			layerSizes[n][d] = size
+		}
+	}
+	topSize = new(big.Int)
+	for _, size := range layerSizes[NumChains] {
+		topSize.Add(topSize, size)
+	}
+}
+
+// vertexOf maps x, below the number of vertices in layers 0 through
+// FinalLayer, to a vertex. Layers are ordered from the top and the
+// vertices of a layer by their digits, each descending from ChainLength-1.
+func vertexOf(x *big.Int) [NumChains]int {
+	layerSizesOnce.Do(initLayerSizes)
+	x = new(big.Int).Set(x)
+	d := 0
+	for x.Cmp(layerSizes[NumChains][d]) >= 0 {
+		x.Sub(x, layerSizes[NumChains][d])
+		d++
+	}
+
+	var digits [NumChains]int
+	for i := 0; i < NumChains-1; i++ {
+		rest := NumChains - 1 - i
+		j := max(0, d-(ChainLength-1)*rest)
+		for ; j < min(ChainLength-1, d); j++ {
+			count := layerSizes[rest][d-j]
+			if x.Cmp(count) < 0 {
+				break
+			}
+			x.Sub(x, count)
+		}
+		digits[i] = ChainLength - 1 - j
+		d -= j
+	}
+	digits[NumChains-1] = ChainLength - 1 - d - int(x.Int64())
+	return digits
+}
@@ -0,0 +1,227 @@
+package xmss
+
+// Arithmetic in the KoalaBear field, p = 2^31 - 2^24 + 1. Elements are
+// kept in canonical form, below p.
+const fieldOrder = 0x7f000001
+
+func fadd(a, b uint32) uint32 {
+	s := a + b
+	if s >= fieldOrder {
+		s -= fieldOrder
+	}
+	return s
+}
+
+func fmul(a, b uint32) uint32 {
+	return uint32(uint64(a) * uint64(b) % fieldOrder)
+}
+
+func fpow(a uint32, e uint64) uint32 {
+	r := uint32(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = fmul(r, a)
+		}
+		a = fmul(a, a)
+	}
+	return r
+}
+
+func fneg(a uint32) uint32 {
+	if a == 0 {
+		return 0
+	}
+	return fieldOrder - a
+}
+
+// finvPow2 returns 1/2^k.
+func finvPow2(k uint64) uint32 {
+	return fpow(fpow(2, k), fieldOrder-2)
+}
+
+// Poseidon2 over KoalaBear with the x^3 S-box, at width 16 for chain steps
+// and width 24 for tree nodes, leaves and message hashes.
+const (
+	fullRounds      = 8
+	partialRounds16 = 20
+	partialRounds24 = 23
+)
+
+// poseidon2 is a Poseidon2 permutation of a fixed width.
+type poseidon2 struct {
+	width int
+	// external holds the round constants of the full rounds, the first
+	// half before the partial rounds and the second half after them.
+	external [][]uint32
+	internal []uint32
+	// diag is V in the internal matrix 1 + Diag(V).
+	diag []uint32
+}
+
+var (
+	poseidon16 = newPoseidon2(16, partialRounds16, []uint32{
+		fneg(2), 1, 2, finvPow2(1), 3, 4, fneg(finvPow2(1)), fneg(3), fneg(4),
+		finvPow2(8), finvPow2(3), finvPow2(24),
+		fneg(finvPow2(8)), fneg(finvPow2(3)), fneg(finvPow2(4)), fneg(finvPow2(24)),
+	})
+	poseidon24 = newPoseidon2(24, partialRounds24, []uint32{
+		fneg(2), 1, 2, finvPow2(1), 3, 4, fneg(finvPow2(1)), fneg(3), fneg(4),
+		finvPow2(8), finvPow2(2), finvPow2(3), finvPow2(4), finvPow2(5), finvPow2(6), finvPow2(24),
+		fneg(finvPow2(8)), fneg(finvPow2(3)), fneg(finvPow2(4)), fneg(finvPow2(5)),
+		fneg(finvPow2(6)), fneg(finvPow2(7)), fneg(finvPow2(9)), fneg(finvPow2(24)),
+	})
+)
+
+// newPoseidon2 derives the round constants with the Grain LFSR of the
+// Poseidon2 reference parameter script.
+func newPoseidon2(width, partialRounds int, diag []uint32) *poseidon2 {
+	g := newGrain(width, fullRounds, partialRounds)
+	p := &poseidon2{width: width, diag: diag}
+	row := func() []uint32 {
+		rc := make([]uint32, width)
+		for i := range rc {
+			rc[i] = g.fieldElement()
+		}
+		return rc
+	}
+	for r := 0; r < fullRounds/2; r++ {
+		p.external = append(p.external, row())
+	}
+	for r := 0; r < partialRounds; r++ {
+		p.internal = append(p.internal, row()[0])
+	}
+	for r := 0; r < fullRounds/2; r++ {
+		p.external = append(p.external, row())
+	}
+	return p
+}
+
+// permute applies the permutation to state, which has the width's length.
+func (p *poseidon2) permute(state []uint32) {
+	p.externalLayer(state)
+	for _, rc := range p.external[:fullRounds/2] {
+		p.fullRound(state, rc)
+	}
+	for _, rc := range p.internal {
+		state[0] = sbox(fadd(state[0], rc))
+		p.internalLayer(state)
+	}
+	for _, rc := range p.external[fullRounds/2:] {
+		p.fullRound(state, rc)
+	}
+}
+
+func (p *poseidon2) fullRound(state, rc []uint32) {
+	for i := range state {
+		state[i] = sbox(fadd(state[i], rc[i]))
+	}
+	p.externalLayer(state)
+}
+
+func sbox(x uint32) uint32 {
+	return fmul(fmul(x, x), x)
+}
+
+// externalLayer multiplies each block of four by M4 and adds to every
+// element the sum of its column across blocks.
+func (p *poseidon2) externalLayer(state []uint32) {
+	for i := 0; i < len(state); i += 4 {
+		mat4(state[i : i+4])
+	}
+	var sums [4]uint32
+	for i, x := range state {
+		sums[i%4] = fadd(sums[i%4], x)
+	}
+	for i := range state {
+		state[i] = fadd(state[i], sums[i%4])
+	}
+}
+
+// mat4 multiplies x by the circulant-like matrix
+//
+//	[2 3 1 1]
+//	[1 2 3 1]
+//	[1 1 2 3]
+//	[3 1 1 2]
+func mat4(x []uint32) {
+	t01 := fadd(x[0], x[1])
+	t23 := fadd(x[2], x[3])
+	t0123 := fadd(t01, t23)
+	t01123 := fadd(t0123, x[1])
+	t01233 := fadd(t0123, x[3])
+	x[3] = fadd(t01233, fadd(x[0], x[0]))
+	x[1] = fadd(t01123, fadd(x[2], x[2]))
+	x[0] = fadd(t01123, t01)
+	x[2] = fadd(t01233, t23)
+}
+
+// internalLayer multiplies state by 1 + Diag(V): each element becomes the
+// sum of the state plus itself scaled by its diagonal entry.
+func (p *poseidon2) internalLayer(state []uint32) {
+	var sum uint32
+	for _, x := range state {
+		sum = fadd(sum, x)
+	}
+	for i, x := range state {
+		state[i] = fadd(sum, fmul(p.diag[i], x))
+	}
+}
+
+// grain is the Grain LFSR that generates Poseidon round constants.
+type grain struct {
+	bits [80]byte
+}
+
+func newGrain(width, fullRounds, partialRounds int) *grain {
+	g := new(grain)
+	pos := 0
+	put := func(v uint64, n int) {
+		for i := n - 1; i >= 0; i-- {
+			g.bits[pos] = byte(v>>i) & 1
+			pos++
+		}
+	}
+	put(1, 2)   // prime field
+	put(0, 4)   // x^alpha S-box
+	put(31, 12) // field size in bits
+	put(uint64(width), 12)
+	put(uint64(fullRounds), 10)
+	put(uint64(partialRounds), 10)
+	for pos < len(g.bits) {
+		g.bits[pos] = 1
+		pos++
+	}
+	for i := 0; i < 160; i++ {
+		g.step()
+	}
+	return g
+}
+
+func (g *grain) step() byte {
+	b := g.bits[62] ^ g.bits[51] ^ g.bits[38] ^ g.bits[23] ^ g.bits[13] ^ g.bits[0]
+	copy(g.bits[:], g.bits[1:])
+	g.bits[79] = b
+	return b
+}
+
+// bit returns the next output bit: of each pair of LFSR bits, the second
+// is output if the first is set and both are discarded otherwise.
+func (g *grain) bit() byte {
+	for g.step() == 0 {
+		g.step()
+	}
+	return g.step()
+}
+
+// fieldElement samples 31-bit big-endian values until one is below p.
+func (g *grain) fieldElement() uint32 {
+	for {
+		var v uint32
+		for i := 0; i < 31; i++ {
+			v = v<<1 | uint32(g.bit())
+		}
+		if v < fieldOrder {
+			return v
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package xmss
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// The regression vectors were recorded from this package, not from leanSig.
+// They catch unintended changes to the permutation, keys and signatures but
+// say nothing about compatibility with other clients.
+const regressionPath = "testdata/regression.json"
+
+type vectors struct {
+	Permutations []permutationVector `json:"permutations"`
+	Keys         []keyVector         `json:"keys"`
+}
+
+type permutationVector struct {
+	Input  []uint32 `json:"input"`
+	Output []uint32 `json:"output"`
+}
+
+type keyVector struct {
+	Seed       string            `json:"seed"`
+	FirstEpoch uint64            `json:"first_epoch"`
+	NumEpochs  uint64            `json:"num_epochs"`
+	Pubkey     string            `json:"pubkey"`
+	Signatures []signatureVector `json:"signatures"`
+}
+
+type signatureVector struct {
+	Epoch     uint64 `json:"epoch"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+func permutationFor(width int) *poseidon2 {
+	if width == 16 {
+		return poseidon16
+	}
+	return poseidon24
+}
+
+func TestRegressionVectors(t *testing.T) {
+	data, err := os.ReadFile(regressionPath)
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var v vectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("decode vectors: %v", err)
+	}
+
+	for _, pv := range v.Permutations {
+		state := append([]uint32(nil), pv.Input...)
+		permutationFor(len(state)).permute(state)
+		for i := range state {
+			if state[i] != pv.Output[i] {
+				t.Fatalf("poseidon2 width %d = %v, want %v", len(state), state, pv.Output)
+			}
+		}
+	}
+
+	for _, kv := range v.Keys {
+		var seed [SeedSize]byte
+		hexDecode(t, kv.Seed, seed[:])
+		k, err := NewKey(seed, kv.FirstEpoch, kv.NumEpochs)
+		if err != nil {
+			t.Fatalf("NewKey: %v", err)
+		}
+		var pk [PubkeySize]byte
+		hexDecode(t, kv.Pubkey, pk[:])
+		if k.PublicKey() != pk {
+			t.Fatalf("pubkey = %x, want %s", k.PublicKey(), kv.Pubkey)
+		}
+		for _, sv := range kv.Signatures {
+			var msg [MessageSize]byte
+			var want [SignatureSize]byte
+			hexDecode(t, sv.Message, msg[:])
+			hexDecode(t, sv.Signature, want[:])
+			sig, err := k.Sign(sv.Epoch, msg)
+			if err != nil {
+				t.Fatalf("Sign(%d): %v", sv.Epoch, err)
+			}
+			if sig != want {
+				t.Fatalf("signature at epoch %d differs from vector", sv.Epoch)
+			}
+			if err := Verify(pk, sv.Epoch, msg, &want); err != nil {
+				t.Fatalf("Verify(%d): %v", sv.Epoch, err)
+			}
+		}
+	}
+}
+
+func hexDecode(t *testing.T, s string, out []byte) {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(out) {
+		t.Fatalf("invalid hex of %d bytes: %q", len(out), s)
+	}
+	copy(out, b)
+}
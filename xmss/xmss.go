@@ -0,0 +1,303 @@
+// Package xmss implements a generalized XMSS modelled on leanSig's: a
+// Merkle tree of one-time Winternitz keys, one per epoch, with target-sum
+// encoding so that no checksum chains are needed. Hashing is Poseidon2
+// over the KoalaBear field and the message hash lands in the top layers of
+// the hypercube, so a verifier walks only a few chain steps. Keys and
+// signatures are field elements serialized as little-endian words: a
+// 52-byte public key and a 3116-byte signature.
+//
+// The package has not been checked against leanSig's own test vectors, so
+// byte compatibility with other clients is unverified. The vectors in
+// testdata only pin this implementation.
+//
+// A key must never sign twice in the same epoch; doing so reveals enough
+// of its one-time key to forge signatures for that epoch. Callers are
+// responsible for tracking which epochs have been used.
+package xmss
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Scheme parameters.
+const (
+	// LogLifetime is the height of the tree: a key covers epochs
+	// [0, 2^LogLifetime).
+	LogLifetime = 32
+	// NumChains is the number of Winternitz chains per one-time key.
+	NumChains = 64
+	// ChainLength is the number of values in a chain, so each chain
+	// encodes one base-ChainLength digit of the message hash.
+	ChainLength = 8
+	// FinalLayer is the lowest hypercube layer the message hash maps to.
+	FinalLayer = 77
+	// TargetSum is the digit sum a message hash must have to be signed.
+	// It puts the vertex in layer 73, so a verifier walks 73 chain steps.
+	TargetSum = 375
+
+	HashSize      = 4 * hashLen
+	ParameterSize = 4 * parameterLen
+	RhoSize       = 4 * rhoLen
+	SeedSize      = 32
+	MessageSize   = 32
+
+	// PubkeySize is the size of a public key: tree root, then parameter.
+	PubkeySize = HashSize + ParameterSize
+	// SignatureSize is the size of a signature: rho, the authentication
+	// path, the chain values and zero padding.
+	SignatureSize = 3116
+)
+
+const (
+	pathOffset    = RhoSize
+	chainsOffset  = pathOffset + LogLifetime*HashSize
+	paddingOffset = chainsOffset + NumChains*HashSize
+
+	// maxSigningAttempts bounds the search for randomness giving a message
+	// hash with the target sum. Each attempt succeeds with about 4%
+	// probability, so the bound is never reached in practice.
+	maxSigningAttempts = 100000
+)
+
+// ErrInvalidSignature is returned by Verify for signatures that do not
+// verify.
+var ErrInvalidSignature = errors.New("invalid xmss signature")
+
+// PrivateKey signs for a contiguous, power-of-two aligned range of epochs.
+// Epochs outside the range cannot be signed; the tree above the range is
+// filled with pseudorandom nodes nobody knows preimages of.
+type PrivateKey struct {
+	seed  [SeedSize]byte
+	param [parameterLen]uint32
+	first uint64
+	// levels[l] holds the nodes at height l of the subtree over the
+	// active range; levels[0] are the leaves.
+	levels [][]digest
+	root   digest
+}
+
+// GenerateKey creates a key from a seed read from rand. See NewKey.
+func GenerateKey(rand io.Reader, firstEpoch, numEpochs uint64) (*PrivateKey, error) {
+	var seed [SeedSize]byte
+	if _, err := io.ReadFull(rand, seed[:]); err != nil {
+		return nil, fmt.Errorf("read seed: %w", err)
+	}
+	return NewKey(seed, firstEpoch, numEpochs)
+}
+
+// NewKey derives the key able to sign epochs [firstEpoch,
+// firstEpoch+numEpochs) from seed. numEpochs must be a power of two and
+// firstEpoch a multiple of it. Generation hashes every one-time key in
+// the range, so it takes time linear in numEpochs.
+func NewKey(seed [SeedSize]byte, firstEpoch, numEpochs uint64) (*PrivateKey, error) {
+	if numEpochs == 0 || numEpochs&(numEpochs-1) != 0 {
+		return nil, fmt.Errorf("epoch count %d is not a power of two", numEpochs)
+	}
+	if firstEpoch%numEpochs != 0 {
+		return nil, fmt.Errorf("first epoch %d is not a multiple of epoch count %d", firstEpoch, numEpochs)
+	}
+	if firstEpoch+numEpochs > 1<<LogLifetime {
+		return nil, fmt.Errorf("epochs [%d, %d) exceed lifetime 2^%d", firstEpoch, firstEpoch+numEpochs, LogLifetime)
+	}
+	k := &PrivateKey{seed: seed, first: firstEpoch}
+	prf(&k.seed, prfParameter, k.param[:])
+
+	leaves := make([]digest, numEpochs)
+	for i := range leaves {
+		leaves[i] = k.leaf(firstEpoch + uint64(i))
+	}
+	k.levels = [][]digest{leaves}
+	height := bits.TrailingZeros64(numEpochs)
+	for l := 1; l <= height; l++ {
+		below := k.levels[l-1]
+		nodes := make([]digest, len(below)/2)
+		for i := range nodes {
+			index := firstEpoch>>l + uint64(i)
+			nodes[i] = nodeHash(&k.param, l, index, below[2*i], below[2*i+1])
+		}
+		k.levels = append(k.levels, nodes)
+	}
+
+	// Climb from the subtree root to the root of the full tree.
+	node, index := k.levels[height][0], firstEpoch>>height
+	for l := height; l < LogLifetime; l++ {
+		sibling := k.node(l, index^1)
+		if index&1 == 0 {
+			node = nodeHash(&k.param, l+1, index>>1, node, sibling)
+		} else {
+			node = nodeHash(&k.param, l+1, index>>1, sibling, node)
+		}
+		index >>= 1
+	}
+	k.root = node
+	return k, nil
+}
+
+// keyEncodingSize is the size of an encoded private key: seed, first epoch
+// and epoch count.
+const keyEncodingSize = SeedSize + 16
+
+// MarshalBinary encodes the key as its seed and epoch range, from which
+// ParseKey rebuilds it. The encoding is secret.
+func (k *PrivateKey) MarshalBinary() ([]byte, error) {
+	first, end := k.Epochs()
+	buf := make([]byte, keyEncodingSize)
+	copy(buf, k.seed[:])
+	binary.LittleEndian.PutUint64(buf[SeedSize:], first)
+	binary.LittleEndian.PutUint64(buf[SeedSize+8:], end-first)
+	return buf, nil
+}
+
+// ParseKey decodes a key encoded by MarshalBinary. Like NewKey, it takes
+// time linear in the number of epochs the key covers.
+func ParseKey(data []byte) (*PrivateKey, error) {
+	if len(data) != keyEncodingSize {
+		return nil, fmt.Errorf("invalid key length: %d", len(data))
+	}
+	var seed [SeedSize]byte
+	copy(seed[:], data)
+	first := binary.LittleEndian.Uint64(data[SeedSize:])
+	numEpochs := binary.LittleEndian.Uint64(data[SeedSize+8:])
+	return NewKey(seed, first, numEpochs)
+}
+
+// PublicKey returns the public key: the tree root followed by the hash
+// parameter.
+func (k *PrivateKey) PublicKey() [PubkeySize]byte {
+	var pk [PubkeySize]byte
+	putElements(pk[:HashSize], k.root[:])
+	putElements(pk[HashSize:], k.param[:])
+	return pk
+}
+
+// Epochs returns the range [first, end) of epochs the key can sign.
+func (k *PrivateKey) Epochs() (first, end uint64) {
+	return k.first, k.first + uint64(len(k.levels[0]))
+}
+
+// Sign signs msg in epoch. Signing is deterministic: the same epoch and
+// message always give the same signature, while a different message in
+// the same epoch must never be signed.
+func (k *PrivateKey) Sign(epoch uint64, msg [MessageSize]byte) ([SignatureSize]byte, error) {
+	var sig [SignatureSize]byte
+	if first, end := k.Epochs(); epoch < first || epoch >= end {
+		return sig, fmt.Errorf("epoch %d outside key range [%d, %d)", epoch, first, end)
+	}
+
+	var rho [rhoLen]uint32
+	var digits [NumChains]int
+	found := false
+	for counter := uint64(0); counter < maxSigningAttempts; counter++ {
+		rho = rhoFor(&k.seed, epoch, msg, counter)
+		digits = messageDigits(&k.param, epoch, &rho, msg)
+		if digitSum(digits) == TargetSum {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return sig, fmt.Errorf("no encoding with target sum after %d attempts", maxSigningAttempts)
+	}
+
+	putElements(sig[:pathOffset], rho[:])
+	index := epoch
+	for l := 0; l < LogLifetime; l++ {
+		sibling := k.node(l, index^1)
+		putElements(sig[pathOffset+l*HashSize:], sibling[:])
+		index >>= 1
+	}
+	for i, d := range digits {
+		v := walkChain(&k.param, epoch, i, 0, d, chainStart(&k.seed, epoch, i))
+		putElements(sig[chainsOffset+i*HashSize:], v[:])
+	}
+	return sig, nil
+}
+
+// Verify checks sig over msg in epoch against pubkey. It returns
+// ErrInvalidSignature, wrapped with the reason, if the signature does not
+// verify.
+func Verify(pubkey [PubkeySize]byte, epoch uint64, msg [MessageSize]byte, sig *[SignatureSize]byte) error {
+	if epoch >= 1<<LogLifetime {
+		return fmt.Errorf("%w: epoch %d beyond lifetime", ErrInvalidSignature, epoch)
+	}
+	for _, b := range sig[paddingOffset:] {
+		if b != 0 {
+			return fmt.Errorf("%w: non-zero padding", ErrInvalidSignature)
+		}
+	}
+	var root digest
+	var param [parameterLen]uint32
+	if !readElements(root[:], pubkey[:HashSize]) || !readElements(param[:], pubkey[HashSize:]) {
+		return fmt.Errorf("%w: non-canonical public key", ErrInvalidSignature)
+	}
+	var rho [rhoLen]uint32
+	if !readElements(rho[:], sig[:pathOffset]) {
+		return fmt.Errorf("%w: non-canonical randomness", ErrInvalidSignature)
+	}
+
+	digits := messageDigits(&param, epoch, &rho, msg)
+	if digitSum(digits) != TargetSum {
+		return fmt.Errorf("%w: message hash does not have the target sum", ErrInvalidSignature)
+	}
+	var ends [NumChains]digest
+	for i, d := range digits {
+		var v digest
+		if !readElements(v[:], sig[chainsOffset+i*HashSize:]) {
+			return fmt.Errorf("%w: non-canonical chain value", ErrInvalidSignature)
+		}
+		ends[i] = walkChain(&param, epoch, i, d, ChainLength-1, v)
+	}
+
+	node, index := leafHash(&param, epoch, &ends), epoch
+	for l := 0; l < LogLifetime; l++ {
+		var sibling digest
+		if !readElements(sibling[:], sig[pathOffset+l*HashSize:]) {
+			return fmt.Errorf("%w: non-canonical path node", ErrInvalidSignature)
+		}
+		if index&1 == 0 {
+			node = nodeHash(&param, l+1, index>>1, node, sibling)
+		} else {
+			node = nodeHash(&param, l+1, index>>1, sibling, node)
+		}
+		index >>= 1
+	}
+	if root != node {
+		return fmt.Errorf("%w: root mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// leaf computes the tree leaf of epoch from its one-time key.
+func (k *PrivateKey) leaf(epoch uint64) digest {
+	var ends [NumChains]digest
+	for i := range ends {
+		ends[i] = walkChain(&k.param, epoch, i, 0, ChainLength-1, chainStart(&k.seed, epoch, i))
+	}
+	return leafHash(&k.param, epoch, &ends)
+}
+
+// node returns the tree node at level and index: from the active subtree
+// if it lies inside it, otherwise a padding node.
+func (k *PrivateKey) node(level int, index uint64) digest {
+	if level < len(k.levels) {
+		first := k.first >> level
+		if index >= first && index-first < uint64(len(k.levels[level])) {
+			return k.levels[level][index-first]
+		}
+	}
+	var d digest
+	prf(&k.seed, prfPadding, d[:], []byte{byte(level)}, binary.BigEndian.AppendUint64(nil, index))
+	return d
+}
+
+func digitSum(digits [NumChains]int) int {
+	sum := 0
+	for _, d := range digits {
+		sum += d
+	}
+	return sum
+}
@@ -0,0 +1,176 @@
+package xmss
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func testKey(t *testing.T, first, n uint64) *PrivateKey {
+	t.Helper()
+	var seed [SeedSize]byte
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	k, err := NewKey(seed, first, n)
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	return k
+}
+
+func TestSignVerify(t *testing.T) {
+	k := testKey(t, 16, 16)
+	pk := k.PublicKey()
+	msg := [MessageSize]byte{1, 2, 3}
+
+	for _, epoch := range []uint64{16, 17, 24, 31} {
+		sig, err := k.Sign(epoch, msg)
+		if err != nil {
+			t.Fatalf("Sign(%d): %v", epoch, err)
+		}
+		if err := Verify(pk, epoch, msg, &sig); err != nil {
+			t.Fatalf("Verify(%d): %v", epoch, err)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	k := testKey(t, 0, 8)
+	pk := k.PublicKey()
+	msg := [MessageSize]byte{0xaa}
+	sig, err := k.Sign(3, msg)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	tampered := func(i int) *[SignatureSize]byte {
+		s := sig
+		s[i] ^= 1
+		return &s
+	}
+	// Adding p to an element keeps its value mod p but is not canonical.
+	nonCanonical := func(i int) *[SignatureSize]byte {
+		s := sig
+		binary.LittleEndian.PutUint32(s[i:], binary.LittleEndian.Uint32(s[i:])+fieldOrder)
+		return &s
+	}
+	other := testKey(t, 8, 8).PublicKey()
+	cases := []struct {
+		name   string
+		pubkey [PubkeySize]byte
+		epoch  uint64
+		msg    [MessageSize]byte
+		sig    *[SignatureSize]byte
+	}{
+		{"wrong message", pk, 3, [MessageSize]byte{0xab}, &sig},
+		{"wrong epoch", pk, 4, msg, &sig},
+		{"wrong key", other, 3, msg, &sig},
+		{"epoch beyond lifetime", pk, 1 << LogLifetime, msg, &sig},
+		{"tampered rho", pk, 3, msg, tampered(0)},
+		{"tampered path", pk, 3, msg, tampered(pathOffset + 5*HashSize)},
+		{"tampered chain", pk, 3, msg, tampered(chainsOffset + 10*HashSize)},
+		{"non-zero padding", pk, 3, msg, tampered(SignatureSize - 1)},
+		{"non-canonical chain value", pk, 3, msg, nonCanonical(chainsOffset)},
+	}
+	for _, tc := range cases {
+		if err := Verify(tc.pubkey, tc.epoch, tc.msg, tc.sig); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, ErrInvalidSignature)
+		}
+	}
+}
+
+func TestSignOutsideRange(t *testing.T) {
+	k := testKey(t, 8, 8)
+	if first, end := k.Epochs(); first != 8 || end != 16 {
+		t.Fatalf("epochs = [%d, %d), want [8, 16)", first, end)
+	}
+	for _, epoch := range []uint64{7, 16} {
+		if _, err := k.Sign(epoch, [MessageSize]byte{}); err == nil {
+			t.Fatalf("Sign(%d) succeeded outside key range", epoch)
+		}
+	}
+}
+
+func TestNewKeyRange(t *testing.T) {
+	var seed [SeedSize]byte
+	for _, tc := range []struct{ first, n uint64 }{
+		{0, 0},
+		{0, 3},
+		{4, 8},
+		{1<<LogLifetime - 4, 8},
+	} {
+		if _, err := NewKey(seed, tc.first, tc.n); err == nil {
+			t.Errorf("NewKey(first=%d, n=%d) succeeded", tc.first, tc.n)
+		}
+	}
+}
+
+func TestKeyDeterministic(t *testing.T) {
+	// Regression vectors are in testdata/regression.json.
+	if testKey(t, 0, 4).PublicKey() != testKey(t, 0, 4).PublicKey() {
+		t.Fatal("keys from the same seed should be equal")
+	}
+
+	a, err := GenerateKey(rand.Reader, 0, 2)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	b, err := GenerateKey(rand.Reader, 0, 2)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if a.PublicKey() == b.PublicKey() {
+		t.Fatal("random keys should differ")
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	k := testKey(t, 4, 4)
+	data, err := k.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	parsed, err := ParseKey(data)
+	if err != nil {
+		t.Fatalf("ParseKey: %v", err)
+	}
+	if parsed.PublicKey() != k.PublicKey() {
+		t.Fatal("parsed key has a different public key")
+	}
+	if first, end := parsed.Epochs(); first != 4 || end != 8 {
+		t.Fatalf("epochs = [%d, %d), want [4, 8)", first, end)
+	}
+	if _, err := ParseKey(data[1:]); err == nil {
+		t.Fatal("expected truncated key to be rejected")
+	}
+}
+
+func TestVertexOf(t *testing.T) {
+	// Layers 0, 1 and 2 hold 1, 64 and 64*65/2 vertices.
+	seen := make(map[[NumChains]int]bool)
+	for x := int64(0); x < 1+64+64*65/2; x++ {
+		digits := vertexOf(big.NewInt(x))
+		layer := 2
+		switch {
+		case x == 0:
+			layer = 0
+		case x <= NumChains:
+			layer = 1
+		}
+		if sum := digitSum(digits); sum != NumChains*(ChainLength-1)-layer {
+			t.Fatalf("vertexOf(%d) has digit sum %d, want layer %d", x, sum, layer)
+		}
+		if seen[digits] {
+			t.Fatalf("vertexOf(%d) repeats a vertex", x)
+		}
+		seen[digits] = true
+	}
+
+	last := vertexOf(new(big.Int).Sub(topSize, big.NewInt(1)))
+	if sum := digitSum(last); sum != NumChains*(ChainLength-1)-FinalLayer {
+		t.Fatalf("last vertex has digit sum %d, want layer %d", sum, FinalLayer)
+	}
+}